@@ -0,0 +1,96 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const CpuConfigKind = "CpuConfig"
+
+// Supported pinning policies.
+const (
+	// SharedPinningPolicy lets the container run on all usable CPUs of the
+	// allocated NUMA node, together with other containers sharing it.
+	SharedPinningPolicy PinningPolicy = "Shared"
+	// ExclusivePinningPolicy pins the container to as many CPUs of the
+	// allocated NUMA node as it consumes, which are not pinned for other
+	// exclusive shares of the node at the same time.
+	ExclusivePinningPolicy PinningPolicy = "Exclusive"
+)
+
+// Supported SMT policies.
+const (
+	// AllowSMTPolicy uses the hardware threads of a core individually.
+	AllowSMTPolicy SMTPolicy = "Allow"
+	// FullCoresSMTPolicy only pins containers to all threads of a core, so
+	// that no other container runs on a sibling thread. It requires the
+	// Exclusive pinning policy.
+	FullCoresSMTPolicy SMTPolicy = "FullCores"
+	// SingleThreadSMTPolicy only uses the first thread of each core and
+	// leaves its siblings idle.
+	SingleThreadSMTPolicy SMTPolicy = "SingleThread"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CpuConfig holds the set of parameters for configuring the CPUs of a NUMA
+// node.
+type CpuConfig struct {
+	metav1.TypeMeta `json:",inline"`
+	PinningPolicy   PinningPolicy `json:"pinningPolicy,omitempty"`
+	SMT             SMTPolicy     `json:"smt,omitempty"`
+	// ReservedCores lists CPUs in the Linux CPU list format (e.g. "0-1,6")
+	// that must not be assigned to the container, e.g. because they are
+	// reserved for housekeeping.
+	ReservedCores string `json:"reservedCores,omitempty"`
+}
+
+// PinningPolicy defines how containers are pinned to CPUs.
+type PinningPolicy string
+
+// SMTPolicy defines how the hardware threads of a core are used.
+type SMTPolicy string
+
+// DefaultCpuConfig provides the default CPU configuration.
+func DefaultCpuConfig() *CpuConfig {
+	return &CpuConfig{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: GroupName + "/" + Version,
+			Kind:       CpuConfigKind,
+		},
+		PinningPolicy: SharedPinningPolicy,
+		SMT:           AllowSMTPolicy,
+	}
+}
+
+// Normalize updates a CpuConfig config with implied default values based on other settings.
+func (c *CpuConfig) Normalize() error {
+	if c == nil {
+		return fmt.Errorf("config is 'nil'")
+	}
+	if c.PinningPolicy == "" {
+		c.PinningPolicy = SharedPinningPolicy
+	}
+	if c.SMT == "" {
+		c.SMT = AllowSMTPolicy
+	}
+	return nil
+}
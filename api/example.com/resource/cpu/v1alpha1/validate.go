@@ -0,0 +1,54 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"k8s.io/utils/cpuset"
+)
+
+// Validate ensures that CpuConfig has a valid set of values.
+func (c *CpuConfig) Validate() error {
+	switch c.PinningPolicy {
+	case SharedPinningPolicy, ExclusivePinningPolicy:
+	default:
+		return fmt.Errorf("unknown pinning policy: %v", c.PinningPolicy)
+	}
+	switch c.SMT {
+	case AllowSMTPolicy, SingleThreadSMTPolicy:
+	case FullCoresSMTPolicy:
+		if c.PinningPolicy != ExclusivePinningPolicy {
+			return fmt.Errorf("SMT policy %v requires the %v pinning policy", c.SMT, ExclusivePinningPolicy)
+		}
+	default:
+		return fmt.Errorf("unknown SMT policy: %v", c.SMT)
+	}
+	if _, err := c.Reserved(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Reserved returns the set of reserved CPUs.
+func (c *CpuConfig) Reserved() (cpuset.CPUSet, error) {
+	reserved, err := cpuset.Parse(c.ReservedCores)
+	if err != nil {
+		return cpuset.CPUSet{}, fmt.Errorf("invalid reservedCores: %w", err)
+	}
+	return reserved, nil
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCpuConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		cpuConfig   *CpuConfig
+		expectedErr string
+	}{
+		"empty CpuConfig": {
+			cpuConfig:   &CpuConfig{},
+			expectedErr: "unknown pinning policy: ",
+		},
+		"default CpuConfig": {
+			cpuConfig: DefaultCpuConfig(),
+		},
+		"exclusive full cores with reserved cores": {
+			cpuConfig: &CpuConfig{
+				PinningPolicy: ExclusivePinningPolicy,
+				SMT:           FullCoresSMTPolicy,
+				ReservedCores: "0-1,6",
+			},
+		},
+		"shared single thread": {
+			cpuConfig: &CpuConfig{
+				PinningPolicy: SharedPinningPolicy,
+				SMT:           SingleThreadSMTPolicy,
+			},
+		},
+		"unknown pinning policy": {
+			cpuConfig: &CpuConfig{
+				PinningPolicy: "Dedicated",
+				SMT:           AllowSMTPolicy,
+			},
+			expectedErr: "unknown pinning policy: Dedicated",
+		},
+		"unknown SMT policy": {
+			cpuConfig: &CpuConfig{
+				PinningPolicy: SharedPinningPolicy,
+				SMT:           "Off",
+			},
+			expectedErr: "unknown SMT policy: Off",
+		},
+		"shared full cores": {
+			cpuConfig: &CpuConfig{
+				PinningPolicy: SharedPinningPolicy,
+				SMT:           FullCoresSMTPolicy,
+			},
+			expectedErr: "SMT policy FullCores requires the Exclusive pinning policy",
+		},
+		"invalid reserved cores": {
+			cpuConfig: &CpuConfig{
+				PinningPolicy: SharedPinningPolicy,
+				SMT:           AllowSMTPolicy,
+				ReservedCores: "3-1",
+			},
+			expectedErr: "invalid reservedCores: invalid range \"3-1\" (3 > 1)",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.cpuConfig.Validate()
+			if test.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, test.expectedErr)
+		})
+	}
+}
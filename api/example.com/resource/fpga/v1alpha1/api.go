@@ -0,0 +1,63 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const FpgaConfigKind = "FpgaConfig"
+
+// DefaultBitstream is the bitstream loaded onto devices whose claims do not
+// select one. It exposes the plain accelerator without any custom logic.
+const DefaultBitstream = "base"
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FpgaConfig holds the set of parameters for configuring an FPGA.
+type FpgaConfig struct {
+	metav1.TypeMeta `json:",inline"`
+	// Bitstream names the image the FPGA is programmed with before the
+	// claim's containers start. Reprogramming takes a while, so it is
+	// skipped when the device already runs the bitstream.
+	Bitstream string `json:"bitstream,omitempty"`
+}
+
+// DefaultFpgaConfig provides the default FPGA configuration.
+func DefaultFpgaConfig() *FpgaConfig {
+	return &FpgaConfig{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: GroupName + "/" + Version,
+			Kind:       FpgaConfigKind,
+		},
+		Bitstream: DefaultBitstream,
+	}
+}
+
+// Normalize updates a FpgaConfig config with implied default values based on other settings.
+func (c *FpgaConfig) Normalize() error {
+	if c == nil {
+		return fmt.Errorf("config is 'nil'")
+	}
+	if c.Bitstream == "" {
+		c.Bitstream = DefaultBitstream
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Validate ensures that FpgaConfig has a valid set of values.
+func (c *FpgaConfig) Validate() error {
+	if c.Bitstream == "" {
+		return fmt.Errorf("no bitstream set")
+	}
+	if errs := validation.IsDNS1123Subdomain(c.Bitstream); len(errs) > 0 {
+		return fmt.Errorf("invalid bitstream %q: %s", c.Bitstream, strings.Join(errs, ", "))
+	}
+	return nil
+}
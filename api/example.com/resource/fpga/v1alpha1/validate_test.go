@@ -0,0 +1,55 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFpgaConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		fpgaConfig  *FpgaConfig
+		expectedErr string
+	}{
+		"empty FpgaConfig": {
+			fpgaConfig:  &FpgaConfig{},
+			expectedErr: "no bitstream set",
+		},
+		"default FpgaConfig": {
+			fpgaConfig: DefaultFpgaConfig(),
+		},
+		"custom bitstream": {
+			fpgaConfig: &FpgaConfig{Bitstream: "video-transcode.v2"},
+		},
+		"invalid bitstream": {
+			fpgaConfig:  &FpgaConfig{Bitstream: "AES_256"},
+			expectedErr: `invalid bitstream "AES_256": a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.fpgaConfig.Validate()
+			if test.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, test.expectedErr)
+		})
+	}
+}
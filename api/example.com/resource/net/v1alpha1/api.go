@@ -19,11 +19,29 @@ package v1alpha1
 import (
 	"fmt"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const NetConfigKind = "NetConfig"
 
+// Supported traffic classes.
+const (
+	BackgroundTrafficClass TrafficClass = "Background"
+	BestEffortTrafficClass TrafficClass = "BestEffort"
+	VideoTrafficClass      TrafficClass = "Video"
+	VoiceTrafficClass      TrafficClass = "Voice"
+)
+
+// trafficClassPriorities maps each traffic class to its default IEEE 802.1p
+// priority.
+var trafficClassPriorities = map[TrafficClass]int32{
+	BackgroundTrafficClass: 1,
+	BestEffortTrafficClass: 0,
+	VideoTrafficClass:      5,
+	VoiceTrafficClass:      6,
+}
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -31,6 +49,9 @@ const NetConfigKind = "NetConfig"
 type NetConfig struct {
 	metav1.TypeMeta `json:",inline"`
 	BandwidthBurst  *BandwidthBurstEntry `json:"bwBurst,omitempty"`
+	// QoS configures the traffic class, priority, rate ceilings and DSCP
+	// marking of the device's traffic. No QoS is applied if unset.
+	QoS *QoSConfig `json:"qos,omitempty"`
 }
 
 // DefaultNetConfig provides the default bandwidth configuration.
@@ -52,6 +73,14 @@ func (c *NetConfig) Normalize() error {
 	if c.BandwidthBurst == nil {
 		c.BandwidthBurst = &BandwidthBurstEntry{}
 	}
+	if c.QoS != nil {
+		if c.QoS.TrafficClass == "" {
+			c.QoS.TrafficClass = BestEffortTrafficClass
+		}
+		if priority, ok := trafficClassPriorities[c.QoS.TrafficClass]; ok && c.QoS.Priority == nil {
+			c.QoS.Priority = &priority
+		}
+	}
 	return nil
 }
 
@@ -67,3 +96,29 @@ type BandwidthBurstEntry struct {
 	IngressBurst uint64 `json:"ingressBurst"` // Maximum ingress burst size in bits
 	EgressBurst  uint64 `json:"egressBurst"`  // Maximum egress burst size in bits
 }
+
+// TrafficClass defines the class of service of the traffic of a device.
+type TrafficClass string
+
+// QoSConfig defines the quality of service of the traffic of a device.
+type QoSConfig struct {
+	// TrafficClass defaults to BestEffort.
+	TrafficClass TrafficClass `json:"trafficClass,omitempty"`
+	// Priority is the IEEE 802.1p priority (0-7) of the traffic. Defaults to
+	// the priority of the traffic class.
+	Priority *int32 `json:"priority,omitempty"`
+	// MaxRate is the ceiling up to which the traffic may borrow unused
+	// bandwidth beyond the bandwidth consumed by the claim.
+	MaxRate *MaxRateEntry `json:"maxRate,omitempty"`
+	// DSCP is the Differentiated Services Code Point (0-63) to mark the
+	// traffic with. The traffic is not marked if unset.
+	DSCP *int32 `json:"dscp,omitempty"`
+}
+
+// MaxRateEntry defines the rate ceilings in bits per second. A ceiling must
+// not be below the corresponding bandwidth consumed by the claim. An unset
+// ceiling means the traffic is limited to the consumed bandwidth.
+type MaxRateEntry struct {
+	Ingress *resource.Quantity `json:"ingress,omitempty"`
+	Egress  *resource.Quantity `json:"egress,omitempty"`
+}
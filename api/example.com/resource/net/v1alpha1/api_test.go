@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/ptr"
 )
 
 func TestGpuConfigNormalize(t *testing.T) {
@@ -39,6 +40,36 @@ func TestGpuConfigNormalize(t *testing.T) {
 				BandwidthBurst: &BandwidthBurstEntry{},
 			},
 		},
+		"empty QoS": {
+			netConfig: &NetConfig{QoS: &QoSConfig{}},
+			expected: &NetConfig{
+				BandwidthBurst: &BandwidthBurstEntry{},
+				QoS: &QoSConfig{
+					TrafficClass: BestEffortTrafficClass,
+					Priority:     ptr.To(int32(0)),
+				},
+			},
+		},
+		"QoS with traffic class": {
+			netConfig: &NetConfig{QoS: &QoSConfig{TrafficClass: VoiceTrafficClass}},
+			expected: &NetConfig{
+				BandwidthBurst: &BandwidthBurstEntry{},
+				QoS: &QoSConfig{
+					TrafficClass: VoiceTrafficClass,
+					Priority:     ptr.To(int32(6)),
+				},
+			},
+		},
+		"QoS with explicit priority": {
+			netConfig: &NetConfig{QoS: &QoSConfig{TrafficClass: VoiceTrafficClass, Priority: ptr.To(int32(3))}},
+			expected: &NetConfig{
+				BandwidthBurst: &BandwidthBurstEntry{},
+				QoS: &QoSConfig{
+					TrafficClass: VoiceTrafficClass,
+					Priority:     ptr.To(int32(3)),
+				},
+			},
+		},
 	}
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -20,6 +20,8 @@ import (
 	"errors"
 	"fmt"
 	"math"
+
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // Validate ensures that NetConfig has a valid set of values.
@@ -27,7 +29,51 @@ func (c *NetConfig) Validate() error {
 	if c.BandwidthBurst == nil {
 		return errors.New("no burst set")
 	}
-	return c.BandwidthBurst.Validate()
+	if err := c.BandwidthBurst.Validate(); err != nil {
+		return err
+	}
+	if c.QoS != nil {
+		return c.QoS.Validate()
+	}
+	return nil
+}
+
+// ValidateConsumedBandwidth ensures that the rate ceilings of NetConfig are
+// not below the ingress and egress bandwidth consumed by a claim. A nil
+// bandwidth was not consumed and is not checked.
+func (c *NetConfig) ValidateConsumedBandwidth(ingress, egress *resource.Quantity) error {
+	if c.QoS == nil || c.QoS.MaxRate == nil {
+		return nil
+	}
+	if err := validateCeiling(c.QoS.MaxRate.Ingress, ingress); err != nil {
+		return fmt.Errorf("invalid maxRate.ingress: %v", err)
+	}
+	if err := validateCeiling(c.QoS.MaxRate.Egress, egress); err != nil {
+		return fmt.Errorf("invalid maxRate.egress: %v", err)
+	}
+	return nil
+}
+
+// Validate ensures that QoSConfig has a valid set of values.
+func (q *QoSConfig) Validate() error {
+	if _, ok := trafficClassPriorities[q.TrafficClass]; !ok {
+		return fmt.Errorf("unknown traffic class: %v", q.TrafficClass)
+	}
+	if q.Priority != nil && (*q.Priority < 0 || *q.Priority > 7) {
+		return fmt.Errorf("priority must be between 0 and 7: %v", *q.Priority)
+	}
+	if q.DSCP != nil && (*q.DSCP < 0 || *q.DSCP > 63) {
+		return fmt.Errorf("dscp must be between 0 and 63: %v", *q.DSCP)
+	}
+	if q.MaxRate != nil {
+		if q.MaxRate.Ingress != nil && q.MaxRate.Ingress.Sign() <= 0 {
+			return fmt.Errorf("invalid maxRate.ingress: rate must be positive: %v", q.MaxRate.Ingress)
+		}
+		if q.MaxRate.Egress != nil && q.MaxRate.Egress.Sign() <= 0 {
+			return fmt.Errorf("invalid maxRate.egress: rate must be positive: %v", q.MaxRate.Egress)
+		}
+	}
+	return nil
 }
 
 // Validate ensures that GpuSharingStrategy has a valid set of values.
@@ -47,3 +93,13 @@ func validateRateAndBurst(burst uint64) error {
 	}
 	return nil
 }
+
+func validateCeiling(ceiling, consumed *resource.Quantity) error {
+	if ceiling == nil || consumed == nil {
+		return nil
+	}
+	if ceiling.Cmp(*consumed) < 0 {
+		return fmt.Errorf("rate %v is below the consumed bandwidth %v", ceiling, consumed)
+	}
+	return nil
+}
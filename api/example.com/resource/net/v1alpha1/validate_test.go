@@ -22,6 +22,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
 )
 
 func TestNetConfigValidate(t *testing.T) {
@@ -62,6 +64,59 @@ func TestNetConfigValidate(t *testing.T) {
 			},
 			expected: errors.New("invalid egressBurst: burst cannot be more than 4GB"),
 		},
+		"valid NetConfig with QoS": {
+			netConfig: &NetConfig{
+				BandwidthBurst: &BandwidthBurstEntry{},
+				QoS: &QoSConfig{
+					TrafficClass: VoiceTrafficClass,
+					Priority:     ptr.To(int32(7)),
+					MaxRate: &MaxRateEntry{
+						Ingress: ptr.To(resource.MustParse("10G")),
+					},
+					DSCP: ptr.To(int32(46)),
+				},
+			},
+			expected: nil,
+		},
+		"invalid NetConfig with unknown traffic class": {
+			netConfig: &NetConfig{
+				BandwidthBurst: &BandwidthBurstEntry{},
+				QoS:            &QoSConfig{TrafficClass: "Gold"},
+			},
+			expected: errors.New("unknown traffic class: Gold"),
+		},
+		"invalid NetConfig with out-of-range priority": {
+			netConfig: &NetConfig{
+				BandwidthBurst: &BandwidthBurstEntry{},
+				QoS: &QoSConfig{
+					TrafficClass: BestEffortTrafficClass,
+					Priority:     ptr.To(int32(8)),
+				},
+			},
+			expected: errors.New("priority must be between 0 and 7: 8"),
+		},
+		"invalid NetConfig with out-of-range DSCP": {
+			netConfig: &NetConfig{
+				BandwidthBurst: &BandwidthBurstEntry{},
+				QoS: &QoSConfig{
+					TrafficClass: BestEffortTrafficClass,
+					DSCP:         ptr.To(int32(64)),
+				},
+			},
+			expected: errors.New("dscp must be between 0 and 63: 64"),
+		},
+		"invalid NetConfig with zero max rate": {
+			netConfig: &NetConfig{
+				BandwidthBurst: &BandwidthBurstEntry{},
+				QoS: &QoSConfig{
+					TrafficClass: BestEffortTrafficClass,
+					MaxRate: &MaxRateEntry{
+						Egress: ptr.To(resource.MustParse("0")),
+					},
+				},
+			},
+			expected: errors.New("invalid maxRate.egress: rate must be positive: 0"),
+		},
 	}
 
 	for name, test := range tests {
@@ -71,3 +126,51 @@ func TestNetConfigValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestNetConfigValidateConsumedBandwidth(t *testing.T) {
+	tests := map[string]struct {
+		maxRate  *MaxRateEntry
+		ingress  *resource.Quantity
+		egress   *resource.Quantity
+		expected error
+	}{
+		"no max rate": {
+			ingress: ptr.To(resource.MustParse("10G")),
+		},
+		"ceilings above consumed bandwidth": {
+			maxRate: &MaxRateEntry{
+				Ingress: ptr.To(resource.MustParse("20G")),
+				Egress:  ptr.To(resource.MustParse("10G")),
+			},
+			ingress: ptr.To(resource.MustParse("10G")),
+			egress:  ptr.To(resource.MustParse("10G")),
+		},
+		"no consumed bandwidth": {
+			maxRate: &MaxRateEntry{
+				Ingress: ptr.To(resource.MustParse("1G")),
+			},
+		},
+		"ingress ceiling below consumed bandwidth": {
+			maxRate: &MaxRateEntry{
+				Ingress: ptr.To(resource.MustParse("500M")),
+			},
+			ingress:  ptr.To(resource.MustParse("1G")),
+			expected: errors.New("invalid maxRate.ingress: rate 500M is below the consumed bandwidth 1G"),
+		},
+		"egress ceiling below consumed bandwidth": {
+			maxRate: &MaxRateEntry{
+				Egress: ptr.To(resource.MustParse("1G")),
+			},
+			egress:   ptr.To(resource.MustParse("2G")),
+			expected: errors.New("invalid maxRate.egress: rate 1G is below the consumed bandwidth 2G"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := &NetConfig{QoS: &QoSConfig{MaxRate: test.maxRate}}
+			err := config.ValidateConsumedBandwidth(test.ingress, test.egress)
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
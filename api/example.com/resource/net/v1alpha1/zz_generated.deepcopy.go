@@ -39,6 +39,31 @@ func (in *BandwidthBurstEntry) DeepCopy() *BandwidthBurstEntry {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaxRateEntry) DeepCopyInto(out *MaxRateEntry) {
+	*out = *in
+	if in.Ingress != nil {
+		in, out := &in.Ingress, &out.Ingress
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Egress != nil {
+		in, out := &in.Egress, &out.Egress
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaxRateEntry.
+func (in *MaxRateEntry) DeepCopy() *MaxRateEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(MaxRateEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetConfig) DeepCopyInto(out *NetConfig) {
 	*out = *in
@@ -48,6 +73,11 @@ func (in *NetConfig) DeepCopyInto(out *NetConfig) {
 		*out = new(BandwidthBurstEntry)
 		**out = **in
 	}
+	if in.QoS != nil {
+		in, out := &in.QoS, &out.QoS
+		*out = new(QoSConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetConfig.
@@ -67,3 +97,33 @@ func (in *NetConfig) DeepCopyObject() runtime.Object {
 	}
 	return nil
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QoSConfig) DeepCopyInto(out *QoSConfig) {
+	*out = *in
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxRate != nil {
+		in, out := &in.MaxRate, &out.MaxRate
+		*out = new(MaxRateEntry)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DSCP != nil {
+		in, out := &in.DSCP, &out.DSCP
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QoSConfig.
+func (in *QoSConfig) DeepCopy() *QoSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(QoSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
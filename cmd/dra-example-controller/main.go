@@ -26,6 +26,7 @@ import (
 	resourceapi "k8s.io/api/resource/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -82,8 +83,11 @@ func (e *enablePlugins) Set(v string) error {
 
 func main() {
 	var driverName string
+	var networkPoolsFile string
 	var enabled enablePlugins
 	flag.StringVar(&driverName, "driver-name", "gpu.example.com", "The driver name to filter ResourceClaims by.")
+	flag.StringVar(&networkPoolsFile, "network-pools-file", "",
+		"Path to a YAML or JSON file describing network-attached device pools to publish as ResourceSlices. Disabled when empty.")
 	flag.Var(&enabled, "enable-plugin",
 		fmt.Sprintf("Enable a plugin (can be specified multiple times). Available: %s", strings.Join(pluginNames(), ", ")))
 	opts := zap.Options{Development: true}
@@ -111,6 +115,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	if networkPoolsFile != "" {
+		kubeClient, err := kubernetes.NewForConfig(mgr.GetConfig())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating Kubernetes client: %v\n", err)
+			os.Exit(1)
+		}
+		if err := mgr.Add(NewPoolPublisher(driverName, networkPoolsFile, kubeClient)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error setting up pool publisher: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		fmt.Fprintf(os.Stderr, "Error running manager: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,190 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/dynamic-resource-allocation/resourceslice"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/dra-example-driver/internal/profiles/inventory"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles/helpers"
+)
+
+// NetworkPools is the content of the file describing the network-attached
+// device pools published by the controller, for example:
+//
+//	pools:
+//	- name: fabric-a
+//	  nodeSelector:
+//	    nodeSelectorTerms:
+//	    - matchExpressions:
+//	      - key: example.com/fabric
+//	        operator: In
+//	        values: ["a"]
+//	  devices:
+//	  - name: gpu-100
+//	- name: shared
+//	  allNodes: true
+//	  devices:
+//	  - name: gpu-200
+//
+// Device names must follow the naming scheme of the profile used by the
+// kubelet plugin, e.g. "gpu-<n>" for the gpu profile, since the kubelet
+// plugin prepares them like its own devices.
+type NetworkPools struct {
+	Pools []NetworkPool `json:"pools,omitempty"`
+}
+
+// NetworkPool is a pool of devices which is not local to a single node.
+// Exactly one of NodeSelector and AllNodes must be set.
+type NetworkPool struct {
+	// Name is the name of the pool. It must not collide with the name of a
+	// node, which the kubelet plugin uses for its node-local pool.
+	Name string `json:"name"`
+	// NodeSelector selects the nodes which have access to the devices.
+	NodeSelector *corev1.NodeSelector `json:"nodeSelector,omitempty"`
+	// AllNodes makes the devices accessible from all nodes.
+	AllNodes bool `json:"allNodes,omitempty"`
+
+	inventory.Inventory `json:",inline"`
+}
+
+// Validate ensures that a NetworkPool has a valid set of values.
+func (p *NetworkPool) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("pool without name")
+	}
+	if (p.NodeSelector != nil) == p.AllNodes {
+		return fmt.Errorf("pool %q: exactly one of nodeSelector and allNodes must be set", p.Name)
+	}
+	if p.NodeSelector != nil && len(p.NodeSelector.NodeSelectorTerms) != 1 {
+		return fmt.Errorf("pool %q: nodeSelector must use exactly one term", p.Name)
+	}
+	if err := p.Inventory.Validate(); err != nil {
+		return fmt.Errorf("pool %q: %w", p.Name, err)
+	}
+	return nil
+}
+
+// DriverResources returns the ResourceSlices to publish for the pools.
+func (n *NetworkPools) DriverResources() *resourceslice.DriverResources {
+	resources := &resourceslice.DriverResources{
+		Pools: make(map[string]resourceslice.Pool, len(n.Pools)),
+	}
+	for _, pool := range n.Pools {
+		resources.Pools[pool.Name] = resourceslice.Pool{
+			NodeSelector: pool.NodeSelector,
+			AllNodes:     pool.AllNodes,
+			Slices:       helpers.PoolSlices(pool.SharedCounters, pool.Devices),
+		}
+	}
+	return resources
+}
+
+func readNetworkPools(path string) (*NetworkPools, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read network pools file: %w", err)
+	}
+	pools := new(NetworkPools)
+	if err := yaml.UnmarshalStrict(data, pools); err != nil {
+		return nil, fmt.Errorf("decode network pools file %s: %w", path, err)
+	}
+	names := make(map[string]bool)
+	for i := range pools.Pools {
+		pool := &pools.Pools[i]
+		if err := pool.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid network pools file %s: %w", path, err)
+		}
+		if names[pool.Name] {
+			return nil, fmt.Errorf("invalid network pools file %s: duplicate pool %q", path, pool.Name)
+		}
+		names[pool.Name] = true
+	}
+	return pools, nil
+}
+
+// PoolPublisher publishes ResourceSlices for network-attached device pools
+// which are not tied to a single node. The pools are read from a file which
+// is watched for changes.
+//
+// The ResourceSlices have no owner, so they are not garbage collected when
+// the controller is uninstalled.
+type PoolPublisher struct {
+	driverName string
+	path       string
+	kubeClient kubernetes.Interface
+}
+
+func NewPoolPublisher(driverName, path string, kubeClient kubernetes.Interface) *PoolPublisher {
+	return &PoolPublisher{
+		driverName: driverName,
+		path:       path,
+		kubeClient: kubeClient,
+	}
+}
+
+// Start implements [sigs.k8s.io/controller-runtime/pkg/manager.Runnable]. It
+// publishes the pools until ctx is canceled.
+func (p *PoolPublisher) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("pool-publisher")
+
+	// Watch the file before reading it, so that no change is missed.
+	watcher, err := helpers.NewFileWatcher(p.path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := watcher.Close(); err != nil {
+			logger.Error(err, "Failed to close file watcher")
+		}
+	}()
+
+	pools, err := readNetworkPools(p.path)
+	if err != nil {
+		return err
+	}
+
+	controller, err := resourceslice.StartController(ctx, resourceslice.Options{
+		DriverName: p.driverName,
+		KubeClient: p.kubeClient,
+		Resources:  pools.DriverResources(),
+	})
+	if err != nil {
+		return fmt.Errorf("start ResourceSlice controller: %w", err)
+	}
+	defer controller.Stop()
+	logger.Info("Publishing network-attached pools", "pools", len(pools.Pools))
+
+	return watcher.Run(ctx, func() {
+		pools, err := readNetworkPools(p.path)
+		if err != nil {
+			// Keep publishing the last valid pools.
+			logger.Error(err, "Failed to reload network pools")
+			return
+		}
+		logger.Info("Republishing network-attached pools", "pools", len(pools.Pools))
+		controller.Update(pools.DriverResources())
+	})
+}
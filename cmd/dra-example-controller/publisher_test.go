@@ -0,0 +1,160 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resourceapi "k8s.io/api/resource/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+const testNetworkPools = `
+pools:
+- name: fabric-a
+  nodeSelector:
+    nodeSelectorTerms:
+    - matchExpressions:
+      - key: example.com/fabric
+        operator: In
+        values: ["a"]
+  devices:
+  - name: gpu-100
+  - name: gpu-101
+- name: shared
+  allNodes: true
+  devices:
+  - name: gpu-200
+`
+
+func writeNetworkPools(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "pools.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestReadNetworkPools(t *testing.T) {
+	pools, err := readNetworkPools(writeNetworkPools(t, testNetworkPools))
+	require.NoError(t, err)
+
+	resources := pools.DriverResources()
+	require.Len(t, resources.Pools, 2)
+
+	fabric := resources.Pools["fabric-a"]
+	require.NotNil(t, fabric.NodeSelector)
+	assert.False(t, fabric.AllNodes)
+	require.Len(t, fabric.Slices, 1)
+	assert.Len(t, fabric.Slices[0].Devices, 2)
+
+	shared := resources.Pools["shared"]
+	assert.Nil(t, shared.NodeSelector)
+	assert.True(t, shared.AllNodes)
+	require.Len(t, shared.Slices, 1)
+	assert.Len(t, shared.Slices[0].Devices, 1)
+}
+
+func TestReadNetworkPoolsInvalid(t *testing.T) {
+	tests := map[string]struct {
+		content     string
+		expectedErr string
+	}{
+		"missing name": {
+			content:     "pools:\n- allNodes: true\n",
+			expectedErr: "pool without name",
+		},
+		"no node selection": {
+			content:     "pools:\n- name: a\n",
+			expectedErr: `pool "a": exactly one of nodeSelector and allNodes must be set`,
+		},
+		"both node selections": {
+			content:     "pools:\n- name: a\n  allNodes: true\n  nodeSelector:\n    nodeSelectorTerms: [{}]\n",
+			expectedErr: `pool "a": exactly one of nodeSelector and allNodes must be set`,
+		},
+		"multiple node selector terms": {
+			content:     "pools:\n- name: a\n  nodeSelector:\n    nodeSelectorTerms: [{}, {}]\n",
+			expectedErr: `pool "a": nodeSelector must use exactly one term`,
+		},
+		"invalid devices": {
+			content:     "pools:\n- name: a\n  allNodes: true\n  devices:\n  - name: gpu-0\n  - name: gpu-0\n",
+			expectedErr: `pool "a": duplicate device "gpu-0"`,
+		},
+		"duplicate pool": {
+			content:     "pools:\n- name: a\n  allNodes: true\n- name: a\n  allNodes: true\n",
+			expectedErr: `duplicate pool "a"`,
+		},
+		"unknown field": {
+			content:     "pools:\n- name: a\n  allNodes: true\n  nodeName: node-1\n",
+			expectedErr: `unknown field "nodeName"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := readNetworkPools(writeNetworkPools(t, test.content))
+			require.ErrorContains(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestPoolPublisher(t *testing.T) {
+	const driverName = "gpu.example.com"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	kubeClient := fake.NewClientset()
+	// The fake clientset does not implement GenerateName.
+	var generated atomic.Int32
+	kubeClient.PrependReactor("create", "resourceslices", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		slice := action.(clienttesting.CreateAction).GetObject().(*resourceapi.ResourceSlice)
+		if slice.Name == "" {
+			slice.Name = fmt.Sprintf("%s%d", slice.GenerateName, generated.Add(1))
+		}
+		return false, nil, nil
+	})
+	publisher := NewPoolPublisher(driverName, writeNetworkPools(t, testNetworkPools), kubeClient)
+	done := make(chan error)
+	go func() {
+		done <- publisher.Start(ctx)
+	}()
+
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		slices, err := kubeClient.ResourceV1().ResourceSlices().List(ctx, metav1.ListOptions{})
+		require.NoError(c, err)
+		pools := make(map[string]bool)
+		for _, slice := range slices.Items {
+			assert.Equal(c, driverName, slice.Spec.Driver)
+			assert.Nil(c, slice.Spec.NodeName)
+			pools[slice.Spec.Pool.Name] = slice.Spec.AllNodes != nil && *slice.Spec.AllNodes
+		}
+		assert.Equal(c, map[string]bool{"fabric-a": false, "shared": true}, pools)
+	}, 10*time.Second, 100*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -1,5 +1,5 @@
 /*
- * Copyright 2023 The Kubernetes Authors.
+ * Copyright The Kubernetes Authors.
  *
  * Licensed under the Apache License, Version 2.0 (the "License");
  * you may not use this file except in compliance with the License.
@@ -17,309 +17,16 @@
 package main
 
 import (
-	"context"
-	"errors"
 	"fmt"
 	"os"
-	"os/signal"
-	"path/filepath"
-	"syscall"
-	"time"
 
-	"github.com/urfave/cli/v2"
-
-	coreclientset "k8s.io/client-go/kubernetes"
-	"k8s.io/dynamic-resource-allocation/kubeletplugin"
-	"k8s.io/klog/v2"
-
-	"sigs.k8s.io/dra-example-driver/internal/profiles"
-	"sigs.k8s.io/dra-example-driver/internal/profiles/cpu"
-	"sigs.k8s.io/dra-example-driver/internal/profiles/gpu"
-	"sigs.k8s.io/dra-example-driver/internal/profiles/net"
-	"sigs.k8s.io/dra-example-driver/pkg/flags"
-	"sigs.k8s.io/dra-example-driver/pkg/metrics"
-)
-
-const (
-	DriverPluginCheckpointFile = "checkpoint.json"
+	"sigs.k8s.io/dra-example-driver/internal/profiles/registry"
+	"sigs.k8s.io/dra-example-driver/pkg/kubeletplugin"
 )
 
-type Flags struct {
-	kubeClientConfig flags.KubeClientConfig
-	loggingConfig    *flags.LoggingConfig
-
-	nodeName                      string
-	cdiRoot                       string
-	numDevices                    int
-	kubeletRegistrarDirectoryPath string
-	kubeletPluginsDirectoryPath   string
-	healthcheckPort               int
-	metricsPort                   int
-	profile                       string
-	driverName                    string
-	podUID                        string
-	gpuPartitions                 int
-	gpuDeviceStatus               bool
-	bindingConditions             bool
-	gpuAllowMultipleAllocations   bool
-	cpuNUMANodes                  int
-	cpusPerNUMANode               int
-}
-
-type Config struct {
-	flags         *Flags
-	coreclient    coreclientset.Interface
-	cancelMainCtx func(error)
-
-	profile profiles.Profile
-}
-
-var validProfiles = map[string]func(flags Flags) profiles.Profile{
-	gpu.ProfileName: func(flags Flags) profiles.Profile {
-		return gpu.NewProfile(flags.nodeName, flags.numDevices, flags.gpuPartitions, flags.gpuDeviceStatus, flags.bindingConditions, flags.gpuAllowMultipleAllocations)
-	},
-	cpu.ProfileName: func(flags Flags) profiles.Profile {
-		return cpu.NewProfile(flags.nodeName, flags.driverName, flags.cpuNUMANodes, flags.cpusPerNUMANode)
-	},
-	net.ProfileName: func(flags Flags) profiles.Profile {
-		return net.NewProfile(flags.nodeName, flags.numDevices)
-	},
-}
-
-var validProfileNames = func() []string {
-	var valid []string
-	for profileName := range validProfiles {
-		valid = append(valid, profileName)
-	}
-	return valid
-}()
-
-func (c Config) DriverPluginPath() string {
-	return filepath.Join(c.flags.kubeletPluginsDirectoryPath, c.flags.driverName)
-}
-
 func main() {
-	if err := newApp().Run(os.Args); err != nil {
+	if err := kubeletplugin.NewApp(registry.New()).Run(os.Args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
-
-func newApp() *cli.App {
-	flags := &Flags{
-		loggingConfig: flags.NewLoggingConfig(),
-	}
-	cliFlags := []cli.Flag{
-		&cli.StringFlag{
-			Name:        "node-name",
-			Usage:       "The name of the node to be worked on.",
-			Required:    true,
-			Destination: &flags.nodeName,
-			EnvVars:     []string{"NODE_NAME"},
-		},
-		&cli.StringFlag{
-			Name:        "cdi-root",
-			Usage:       "Absolute path to the directory where CDI files will be generated.",
-			Value:       "/etc/cdi",
-			Destination: &flags.cdiRoot,
-			EnvVars:     []string{"CDI_ROOT"},
-		},
-		&cli.IntFlag{
-			Name:        "num-devices",
-			Usage:       "The number of devices to be generated. Only relevant for the " + gpu.ProfileName + " profile.",
-			Value:       8,
-			Destination: &flags.numDevices,
-			EnvVars:     []string{"NUM_DEVICES"},
-		},
-		&cli.StringFlag{
-			Name:        "kubelet-registrar-directory-path",
-			Usage:       "Absolute path to the directory where kubelet stores plugin registrations.",
-			Value:       kubeletplugin.KubeletRegistryDir,
-			Destination: &flags.kubeletRegistrarDirectoryPath,
-			EnvVars:     []string{"KUBELET_REGISTRAR_DIRECTORY_PATH"},
-		},
-		&cli.StringFlag{
-			Name:        "kubelet-plugins-directory-path",
-			Usage:       "Absolute path to the directory where kubelet stores plugin data.",
-			Value:       kubeletplugin.KubeletPluginsDir,
-			Destination: &flags.kubeletPluginsDirectoryPath,
-			EnvVars:     []string{"KUBELET_PLUGINS_DIRECTORY_PATH"},
-		},
-		&cli.IntFlag{
-			Name:        "healthcheck-port",
-			Usage:       "Port to start a gRPC healthcheck service. When positive, a literal port number. When zero, a random port is allocated. When negative, the healthcheck service is disabled.",
-			Value:       -1,
-			Destination: &flags.healthcheckPort,
-			EnvVars:     []string{"HEALTHCHECK_PORT"},
-		},
-		&cli.IntFlag{
-			Name:        "metrics-port",
-			Usage:       "Port to expose Prometheus metrics at /metrics. When positive, a literal port number. When zero, a random port is allocated. When negative, metrics are disabled.",
-			Value:       -1,
-			Destination: &flags.metricsPort,
-			EnvVars:     []string{"METRICS_PORT"},
-		},
-		&cli.StringFlag{
-			Name:        "device-profile",
-			Usage:       fmt.Sprintf("Name of the device profile. Valid values are %q.", validProfileNames),
-			Value:       gpu.ProfileName,
-			Destination: &flags.profile,
-			EnvVars:     []string{"DEVICE_PROFILE"},
-		},
-		&cli.StringFlag{
-			Name:        "driver-name",
-			Usage:       "Name of the DRA driver. Its default is derived from the device profile.",
-			Destination: &flags.driverName,
-			EnvVars:     []string{"DRIVER_NAME"},
-		},
-		&cli.StringFlag{
-			Name:        "pod-uid",
-			Usage:       "UID of the pod (used for seamless upgrades to create unique socket names).",
-			Destination: &flags.podUID,
-			EnvVars:     []string{"POD_UID"},
-		},
-		&cli.IntFlag{
-			Name:        "gpu-partitions",
-			Usage:       "Number of partitions per GPU. When set to a value greater than 0, GPUs are exposed with shared counters allowing flexible partitioning (DRAPartitionableDevices feature).",
-			Value:       0,
-			Destination: &flags.gpuPartitions,
-			EnvVars:     []string{"GPU_PARTITIONS"},
-		},
-		&cli.BoolFlag{
-			Name:        "gpu-device-status",
-			Usage:       "Enable adding allocated device attributes (e.g., model, uuid, driverVersion) into ResourceClaim.status.devices[].data. Disabled by default.",
-			Destination: &flags.gpuDeviceStatus,
-			EnvVars:     []string{"GPU_DEVICE_STATUS"},
-		},
-		&cli.BoolFlag{
-			Name:        "binding-conditions",
-			Usage:       "Enable or disable binding conditions processing in the DRA driver.",
-			Value:       false,
-			Destination: &flags.bindingConditions,
-			EnvVars:     []string{"BINDING_CONDITIONS"},
-		},
-		&cli.BoolFlag{
-			Name:        "gpu-allow-multiple-allocations",
-			Usage:       "Allow GPU devices to be allocated to multiple DeviceRequests. Disabled by default.",
-			Destination: &flags.gpuAllowMultipleAllocations,
-			EnvVars:     []string{"GPU_ALLOW_MULTIPLE_ALLOCATIONS"},
-		},
-		&cli.IntFlag{
-			Name:        "cpu-numa-nodes",
-			Usage:       "Number of fake NUMA-node devices to advertise. Only relevant for the " + cpu.ProfileName + " profile.",
-			Value:       8,
-			Destination: &flags.cpuNUMANodes,
-			EnvVars:     []string{"CPU_NUMA_NODES"},
-		},
-		&cli.IntFlag{
-			Name:        "cpus-per-numa-node",
-			Usage:       "Number of CPUs each fake NUMA-node device advertises as consumable capacity. Only relevant for the " + cpu.ProfileName + " profile.",
-			Value:       4,
-			Destination: &flags.cpusPerNUMANode,
-			EnvVars:     []string{"CPUS_PER_NUMA_NODE"},
-		},
-	}
-	cliFlags = append(cliFlags, flags.kubeClientConfig.Flags()...)
-	cliFlags = append(cliFlags, flags.loggingConfig.Flags()...)
-
-	app := &cli.App{
-		Name:            "dra-example-kubeletplugin",
-		Usage:           "dra-example-kubeletplugin implements a DRA driver plugin.",
-		ArgsUsage:       " ",
-		HideHelpCommand: true,
-		Flags:           cliFlags,
-		Before: func(c *cli.Context) error {
-			if c.Args().Len() > 0 {
-				return fmt.Errorf("arguments not supported: %v", c.Args().Slice())
-			}
-			return flags.loggingConfig.Apply()
-		},
-		Action: func(c *cli.Context) error {
-			ctx := c.Context
-			clientSets, err := flags.kubeClientConfig.NewClientSets()
-			if err != nil {
-				return fmt.Errorf("create client: %w", err)
-			}
-
-			if flags.driverName == "" {
-				flags.driverName = flags.profile + ".example.com"
-			}
-
-			newProfile, ok := validProfiles[flags.profile]
-			if !ok {
-				return fmt.Errorf("invalid device profile %q, valid profiles are %q", flags.profile, validProfileNames)
-			}
-
-			config := &Config{
-				flags:      flags,
-				coreclient: clientSets.Core,
-				profile:    newProfile(*flags),
-			}
-
-			return RunPlugin(ctx, config)
-		},
-	}
-
-	return app
-}
-
-func RunPlugin(ctx context.Context, config *Config) error {
-	logger := klog.FromContext(ctx)
-
-	err := os.MkdirAll(config.DriverPluginPath(), 0750)
-	if err != nil {
-		return err
-	}
-
-	info, err := os.Stat(config.flags.cdiRoot)
-	switch {
-	case err != nil && os.IsNotExist(err):
-		err := os.MkdirAll(config.flags.cdiRoot, 0750)
-		if err != nil {
-			return err
-		}
-	case err != nil:
-		return err
-	case !info.IsDir():
-		return fmt.Errorf("path for cdi file generation is not a directory: '%v'", err)
-	}
-
-	ctx, stop := signal.NotifyContext(ctx, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
-	defer stop()
-	ctx, cancel := context.WithCancelCause(ctx)
-	config.cancelMainCtx = cancel
-
-	metricsServer, err := metrics.StartServer(ctx, config.flags.metricsPort)
-	if err != nil {
-		return fmt.Errorf("start metrics server: %w", err)
-	}
-	defer func(ctx context.Context) {
-		shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 5*time.Second)
-		defer shutdownCancel()
-		if err := metricsServer.Stop(shutdownCtx); err != nil {
-			logger.Error(err, "failed to stop metrics server")
-		}
-	}(context.WithoutCancel(ctx))
-
-	driver, err := NewDriver(ctx, config)
-	if err != nil {
-		return err
-	}
-
-	<-ctx.Done()
-	// restore default signal behavior as soon as possible in case graceful
-	// shutdown gets stuck.
-	stop()
-	if err := context.Cause(ctx); err != nil && !errors.Is(err, context.Canceled) {
-		// A canceled context is the normal case here when the process receives
-		// a signal. Only log the error for more interesting cases.
-		logger.Error(err, "error from context")
-	}
-
-	err = driver.Shutdown(logger)
-	if err != nil {
-		logger.Error(err, "Unable to cleanly shutdown driver")
-	}
-
-	return nil
-}
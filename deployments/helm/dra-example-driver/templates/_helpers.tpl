@@ -128,3 +128,13 @@ The driver name.
 {{- define "dra-example-driver.driverName" -}}
 {{ default (print .Values.deviceProfile ".example.com") .Values.driverName }}
 {{- end -}}
+
+{{/*
+The comma-separated device profiles served by the kubelet plugin and the webhook.
+*/}}
+{{- define "dra-example-driver.deviceProfiles" -}}
+{{- if and .Values.additionalDeviceProfiles .Values.driverName -}}
+{{- fail "driverName must be empty when additionalDeviceProfiles are set" -}}
+{{- end -}}
+{{ prepend .Values.additionalDeviceProfiles .Values.deviceProfile | join "," }}
+{{- end -}}
@@ -26,8 +26,10 @@ import (
 // be read by the driver to recover intermediate state.
 //
 // The example driver can deterministically reconstruct the entire CDI config
-// for any given claim from the ResourceClaim, so it doesn't need to persist any
-// other data. Other drivers may need to include more data in their checkpoints
+// for any given claim from the ResourceClaim, so it only needs to persist the
+// config shared by claims using the same device, see
+// [PreparedDevice.SharedConfig], and the state of devices which outlives
+// claims, see [DeviceState]. Other drivers may need to include more data in their checkpoints
 // if first-time setup produces non-deterministic data or side-effects that need
 // to be undone when the claim is unprepared.
 //
@@ -36,8 +38,38 @@ type Checkpoint struct {
 	metav1.TypeMeta
 
 	PreparedClaims []PreparedClaim
+	// DeviceStates records state of devices which outlives the claims
+	// they were prepared for, like the image an FPGA was programmed with.
+	DeviceStates []DeviceState
 }
 
 type PreparedClaim struct {
 	UID types.UID
+	// Namespace and Name identify the ResourceClaim in the API server, so
+	// that claims which were deleted or deallocated while the driver was not
+	// running can be found and unprepared.
+	Namespace string
+	Name      string
+	// Devices identifies the devices prepared for the claim, so that claims
+	// remain attributable to a device even after the device is no longer
+	// advertised by the driver.
+	Devices []PreparedDevice
+}
+
+type PreparedDevice struct {
+	PoolName   string
+	DeviceName string
+	ShareID    *types.UID
+	// SharedConfig is the part of the device's config which applies to the
+	// device as a whole. All claims using the device at the same time must
+	// agree on it.
+	SharedConfig string
+}
+
+// DeviceState is the state a device was left in by the claim last prepared
+// for it. The meaning of State is defined by the device profile.
+type DeviceState struct {
+	PoolName   string
+	DeviceName string
+	State      string
 }
@@ -26,8 +26,8 @@ import (
 // be read by the driver to recover intermediate state.
 //
 // The example driver can deterministically reconstruct the entire CDI config
-// for any given claim from the ResourceClaim, so it doesn't need to persist any
-// other data. Other drivers may need to include more data in their checkpoints
+// for any given claim from the ResourceClaim, so it only needs to persist the
+// state of devices which outlives claims, see [DeviceState]. Other drivers may need to include more data in their checkpoints
 // if first-time setup produces non-deterministic data or side-effects that need
 // to be undone when the claim is unprepared.
 //
@@ -36,8 +36,38 @@ type Checkpoint struct {
 	metav1.TypeMeta `json:",inline"`
 
 	PreparedClaims []PreparedClaim `json:"preparedClaims,omitempty"`
+	// DeviceStates records state of devices which outlives the claims
+	// they were prepared for, like the image an FPGA was programmed with.
+	DeviceStates []DeviceState `json:"deviceStates,omitempty"`
 }
 
 type PreparedClaim struct {
 	UID types.UID `json:"uid,omitempty"`
+	// Namespace and Name identify the ResourceClaim in the API server, so
+	// that claims which were deleted or deallocated while the driver was not
+	// running can be found and unprepared.
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	// Devices identifies the devices prepared for the claim, so that claims
+	// remain attributable to a device even after the device is no longer
+	// advertised by the driver.
+	Devices []PreparedDevice `json:"devices,omitempty"`
+}
+
+type PreparedDevice struct {
+	PoolName   string     `json:"poolName,omitempty"`
+	DeviceName string     `json:"deviceName,omitempty"`
+	ShareID    *types.UID `json:"shareID,omitempty"`
+	// SharedConfig is the part of the device's config which applies to the
+	// device as a whole. All claims using the device at the same time must
+	// agree on it.
+	SharedConfig string `json:"sharedConfig,omitempty"`
+}
+
+// DeviceState is the state a device was left in by the claim last prepared
+// for it. The meaning of State is defined by the device profile.
+type DeviceState struct {
+	PoolName   string `json:"poolName,omitempty"`
+	DeviceName string `json:"deviceName,omitempty"`
+	State      string `json:"state,omitempty"`
 }
@@ -47,6 +47,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*DeviceState)(nil), (*checkpoint.DeviceState)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_DeviceState_To_checkpoint_DeviceState(a.(*DeviceState), b.(*checkpoint.DeviceState), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*checkpoint.DeviceState)(nil), (*DeviceState)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_checkpoint_DeviceState_To_v1_DeviceState(a.(*checkpoint.DeviceState), b.(*DeviceState), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*PreparedClaim)(nil), (*checkpoint.PreparedClaim)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1_PreparedClaim_To_checkpoint_PreparedClaim(a.(*PreparedClaim), b.(*checkpoint.PreparedClaim), scope)
 	}); err != nil {
@@ -57,11 +67,22 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*PreparedDevice)(nil), (*checkpoint.PreparedDevice)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_PreparedDevice_To_checkpoint_PreparedDevice(a.(*PreparedDevice), b.(*checkpoint.PreparedDevice), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*checkpoint.PreparedDevice)(nil), (*PreparedDevice)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_checkpoint_PreparedDevice_To_v1_PreparedDevice(a.(*checkpoint.PreparedDevice), b.(*PreparedDevice), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
 func autoConvert_v1_Checkpoint_To_checkpoint_Checkpoint(in *Checkpoint, out *checkpoint.Checkpoint, s conversion.Scope) error {
 	out.PreparedClaims = *(*[]checkpoint.PreparedClaim)(unsafe.Pointer(&in.PreparedClaims))
+	out.DeviceStates = *(*[]checkpoint.DeviceState)(unsafe.Pointer(&in.DeviceStates))
 	return nil
 }
 
@@ -72,6 +93,7 @@ func Convert_v1_Checkpoint_To_checkpoint_Checkpoint(in *Checkpoint, out *checkpo
 
 func autoConvert_checkpoint_Checkpoint_To_v1_Checkpoint(in *checkpoint.Checkpoint, out *Checkpoint, s conversion.Scope) error {
 	out.PreparedClaims = *(*[]PreparedClaim)(unsafe.Pointer(&in.PreparedClaims))
+	out.DeviceStates = *(*[]DeviceState)(unsafe.Pointer(&in.DeviceStates))
 	return nil
 }
 
@@ -80,8 +102,35 @@ func Convert_checkpoint_Checkpoint_To_v1_Checkpoint(in *checkpoint.Checkpoint, o
 	return autoConvert_checkpoint_Checkpoint_To_v1_Checkpoint(in, out, s)
 }
 
+func autoConvert_v1_DeviceState_To_checkpoint_DeviceState(in *DeviceState, out *checkpoint.DeviceState, s conversion.Scope) error {
+	out.PoolName = in.PoolName
+	out.DeviceName = in.DeviceName
+	out.State = in.State
+	return nil
+}
+
+// Convert_v1_DeviceState_To_checkpoint_DeviceState is an autogenerated conversion function.
+func Convert_v1_DeviceState_To_checkpoint_DeviceState(in *DeviceState, out *checkpoint.DeviceState, s conversion.Scope) error {
+	return autoConvert_v1_DeviceState_To_checkpoint_DeviceState(in, out, s)
+}
+
+func autoConvert_checkpoint_DeviceState_To_v1_DeviceState(in *checkpoint.DeviceState, out *DeviceState, s conversion.Scope) error {
+	out.PoolName = in.PoolName
+	out.DeviceName = in.DeviceName
+	out.State = in.State
+	return nil
+}
+
+// Convert_checkpoint_DeviceState_To_v1_DeviceState is an autogenerated conversion function.
+func Convert_checkpoint_DeviceState_To_v1_DeviceState(in *checkpoint.DeviceState, out *DeviceState, s conversion.Scope) error {
+	return autoConvert_checkpoint_DeviceState_To_v1_DeviceState(in, out, s)
+}
+
 func autoConvert_v1_PreparedClaim_To_checkpoint_PreparedClaim(in *PreparedClaim, out *checkpoint.PreparedClaim, s conversion.Scope) error {
 	out.UID = types.UID(in.UID)
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	out.Devices = *(*[]checkpoint.PreparedDevice)(unsafe.Pointer(&in.Devices))
 	return nil
 }
 
@@ -92,6 +141,9 @@ func Convert_v1_PreparedClaim_To_checkpoint_PreparedClaim(in *PreparedClaim, out
 
 func autoConvert_checkpoint_PreparedClaim_To_v1_PreparedClaim(in *checkpoint.PreparedClaim, out *PreparedClaim, s conversion.Scope) error {
 	out.UID = types.UID(in.UID)
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	out.Devices = *(*[]PreparedDevice)(unsafe.Pointer(&in.Devices))
 	return nil
 }
 
@@ -99,3 +151,29 @@ func autoConvert_checkpoint_PreparedClaim_To_v1_PreparedClaim(in *checkpoint.Pre
 func Convert_checkpoint_PreparedClaim_To_v1_PreparedClaim(in *checkpoint.PreparedClaim, out *PreparedClaim, s conversion.Scope) error {
 	return autoConvert_checkpoint_PreparedClaim_To_v1_PreparedClaim(in, out, s)
 }
+
+func autoConvert_v1_PreparedDevice_To_checkpoint_PreparedDevice(in *PreparedDevice, out *checkpoint.PreparedDevice, s conversion.Scope) error {
+	out.PoolName = in.PoolName
+	out.DeviceName = in.DeviceName
+	out.ShareID = (*types.UID)(unsafe.Pointer(in.ShareID))
+	out.SharedConfig = in.SharedConfig
+	return nil
+}
+
+// Convert_v1_PreparedDevice_To_checkpoint_PreparedDevice is an autogenerated conversion function.
+func Convert_v1_PreparedDevice_To_checkpoint_PreparedDevice(in *PreparedDevice, out *checkpoint.PreparedDevice, s conversion.Scope) error {
+	return autoConvert_v1_PreparedDevice_To_checkpoint_PreparedDevice(in, out, s)
+}
+
+func autoConvert_checkpoint_PreparedDevice_To_v1_PreparedDevice(in *checkpoint.PreparedDevice, out *PreparedDevice, s conversion.Scope) error {
+	out.PoolName = in.PoolName
+	out.DeviceName = in.DeviceName
+	out.ShareID = (*types.UID)(unsafe.Pointer(in.ShareID))
+	out.SharedConfig = in.SharedConfig
+	return nil
+}
+
+// Convert_checkpoint_PreparedDevice_To_v1_PreparedDevice is an autogenerated conversion function.
+func Convert_checkpoint_PreparedDevice_To_v1_PreparedDevice(in *checkpoint.PreparedDevice, out *PreparedDevice, s conversion.Scope) error {
+	return autoConvert_checkpoint_PreparedDevice_To_v1_PreparedDevice(in, out, s)
+}
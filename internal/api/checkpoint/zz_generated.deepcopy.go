@@ -22,6 +22,7 @@ package checkpoint
 
 import (
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -31,6 +32,13 @@ func (in *Checkpoint) DeepCopyInto(out *Checkpoint) {
 	if in.PreparedClaims != nil {
 		in, out := &in.PreparedClaims, &out.PreparedClaims
 		*out = make([]PreparedClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DeviceStates != nil {
+		in, out := &in.DeviceStates, &out.DeviceStates
+		*out = make([]DeviceState, len(*in))
 		copy(*out, *in)
 	}
 }
@@ -53,9 +61,31 @@ func (in *Checkpoint) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeviceState) DeepCopyInto(out *DeviceState) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceState.
+func (in *DeviceState) DeepCopy() *DeviceState {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceState)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PreparedClaim) DeepCopyInto(out *PreparedClaim) {
 	*out = *in
+	if in.Devices != nil {
+		in, out := &in.Devices, &out.Devices
+		*out = make([]PreparedDevice, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreparedClaim.
@@ -67,3 +97,23 @@ func (in *PreparedClaim) DeepCopy() *PreparedClaim {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreparedDevice) DeepCopyInto(out *PreparedDevice) {
+	*out = *in
+	if in.ShareID != nil {
+		in, out := &in.ShareID, &out.ShareID
+		*out = new(types.UID)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreparedDevice.
+func (in *PreparedDevice) DeepCopy() *PreparedDevice {
+	if in == nil {
+		return nil
+	}
+	out := new(PreparedDevice)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -17,20 +17,26 @@
 package cpu
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"strconv"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	resourceapi "k8s.io/api/resource/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/dynamic-resource-allocation/resourceslice"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/cpuset"
 	"k8s.io/utils/ptr"
 	cdiapi "tags.cncf.io/container-device-interface/pkg/cdi"
 	cdispec "tags.cncf.io/container-device-interface/specs-go"
 
-	"sigs.k8s.io/dra-example-driver/internal/profiles"
-	"sigs.k8s.io/dra-example-driver/internal/profiles/helpers"
+	configapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/cpu/v1alpha1"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles/helpers"
 )
 
 const ProfileName = "cpu"
@@ -41,19 +47,29 @@ const ProfileName = "cpu"
 // distinct names) coexist without their capacity keys colliding.
 const CPUCapacitySuffix = "cpu"
 
+// threadsPerCore is the number of hardware threads of each simulated core.
+const threadsPerCore = 2
+
 type Profile struct {
-	nodeName        string
-	driverName      string
-	numNUMANodes    int
-	cpusPerNUMANode int
+	nodeName           string
+	driverName         string
+	numNUMANodes       int
+	cpusPerNUMANode    int
+	enableDeviceStatus bool
+	// pinning tracks the CPUs pinned for exclusive shares of the devices.
+	// Without it, exclusive shares of the same device may be pinned to the
+	// same CPUs.
+	pinning *Pinning
 }
 
-func NewProfile(nodeName, driverName string, numNUMANodes, cpusPerNUMANode int) Profile {
+func NewProfile(nodeName, driverName string, numNUMANodes, cpusPerNUMANode int, enableDeviceStatus bool, pinning *Pinning) Profile {
 	return Profile{
-		nodeName:        nodeName,
-		driverName:      driverName,
-		numNUMANodes:    numNUMANodes,
-		cpusPerNUMANode: cpusPerNUMANode,
+		nodeName:           nodeName,
+		driverName:         driverName,
+		numNUMANodes:       numNUMANodes,
+		cpusPerNUMANode:    cpusPerNUMANode,
+		enableDeviceStatus: enableDeviceStatus,
+		pinning:            pinning,
 	}
 }
 
@@ -73,6 +89,9 @@ func (p Profile) EnumerateDevices() (resourceslice.DriverResources, error) {
 			AllowMultipleAllocations: ptr.To(true),
 			Attributes: map[resourceapi.QualifiedName]resourceapi.DeviceAttribute{
 				"numaNodeID": {IntValue: ptr.To(int64(i))},
+				// The same attribute as the NUMA node of gpu and net
+				// devices, see [profiles.Topology].
+				profiles.NUMANodeAttribute: {IntValue: ptr.To(int64(i))},
 			},
 			Capacity: map[resourceapi.QualifiedName]resourceapi.DeviceCapacity{
 				capacityKey: {Value: *resource.NewQuantity(int64(p.cpusPerNUMANode), resource.DecimalSI)},
@@ -90,28 +109,63 @@ func (p Profile) EnumerateDevices() (resourceslice.DriverResources, error) {
 	}, nil
 }
 
-// SchemeBuilder implements [profiles.ConfigHandler]. The CPU profile does not
-// accept opaque configuration yet.
+// SchemeBuilder implements [profiles.ConfigHandler].
 func (p Profile) SchemeBuilder() runtime.SchemeBuilder {
-	return runtime.NewSchemeBuilder()
+	return runtime.NewSchemeBuilder(
+		configapi.AddToScheme,
+	)
 }
 
 // Validate implements [profiles.ConfigHandler].
 func (p Profile) Validate(config runtime.Object) error {
-	if config != nil {
-		return errors.New("configuration not allowed")
+	cpuConfig, ok := config.(*configapi.CpuConfig)
+	if !ok {
+		return fmt.Errorf("expected v1alpha1.CpuConfig but got: %T", config)
 	}
-	return nil
+	if err := cpuConfig.Normalize(); err != nil {
+		return err
+	}
+	return cpuConfig.Validate()
 }
 
-// ApplyConfig implements [profiles.ConfigHandler]. It rejects any non-nil
-// configuration and otherwise injects env vars per allocated NUMA device so
-// the demo container can show which device was allocated and how much CPU
-// capacity was consumed.
+// ApplyConfig implements [profiles.ConfigHandler].
 func (p Profile) ApplyConfig(config runtime.Object, results []*resourceapi.DeviceRequestAllocationResult) (profiles.PerDeviceCDIContainerEdits, error) {
-	if config != nil {
-		return nil, errors.New("configuration not allowed")
+	if config == nil {
+		config = configapi.DefaultCpuConfig()
 	}
+	if config, ok := config.(*configapi.CpuConfig); ok {
+		return p.applyCpuConfig(config, results)
+	}
+	return nil, fmt.Errorf("runtime object is not a recognized configuration")
+}
+
+// applyCpuConfig injects env vars per allocated NUMA device so the demo
+// container can show which device was allocated, how much CPU capacity was
+// consumed and which cpuset it would be pinned to. A real driver would apply
+// the cpuset to the container's cgroup instead.
+func (p Profile) applyCpuConfig(config *configapi.CpuConfig, results []*resourceapi.DeviceRequestAllocationResult) (profiles.PerDeviceCDIContainerEdits, error) {
+	// Normalize the config to set any implied defaults.
+	if err := config.Normalize(); err != nil {
+		return nil, fmt.Errorf("error normalizing CPU config: %w", err)
+	}
+
+	// Validate the config to ensure its integrity.
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("error validating CPU config: %w", err)
+	}
+
+	reserved, err := config.Reserved()
+	if err != nil {
+		return nil, err
+	}
+
+	pinned, err := p.pinningState(config)
+	if err != nil {
+		return nil, err
+	}
+	// CPUs picked for earlier results of the same device, which are only
+	// pinned once the device is configured.
+	picked := make(map[string]cpuset.CPUSet)
 
 	capacityKey := p.CapacityKey()
 	edits := make(profiles.PerDeviceCDIContainerEdits, len(results))
@@ -120,10 +174,21 @@ func (p Profile) ApplyConfig(config runtime.Object, results []*resourceapi.Devic
 		envID := result.Device[len("numa-"):]
 		envs := []string{
 			fmt.Sprintf("CPU_DEVICE_%s=%s", envID, result.Device),
+			fmt.Sprintf("CPU_DEVICE_%s_PINNING_POLICY=%s", envID, config.PinningPolicy),
+			fmt.Sprintf("CPU_DEVICE_%s_SMT=%s", envID, config.SMT),
 		}
-		if cpu, ok := result.ConsumedCapacity[capacityKey]; ok {
+		if cpu, consumed := result.ConsumedCapacity[capacityKey]; consumed {
 			envs = append(envs, fmt.Sprintf("CPU_DEVICE_%s_CONSUMED_CPU=%s", envID, cpu.String()))
 		}
+
+		device := pinningKey(result)
+		cpus, err := p.pinnedCPUs(config, reserved, result, pinned, picked[device])
+		if err != nil {
+			return nil, err
+		}
+		picked[device] = picked[device].Union(cpus)
+		envs = append(envs, fmt.Sprintf("CPU_DEVICE_%s_CPUSET=%s", envID, cpus.String()))
+
 		// Key edits by the share-aware device id so that multiple shares of one
 		// NUMA device (consumable capacity) keep their own edits instead of
 		// overwriting each other.
@@ -134,3 +199,192 @@ func (p Profile) ApplyConfig(config runtime.Object, results []*resourceapi.Devic
 	}
 	return edits, nil
 }
+
+// ConfigureDevice implements [profiles.DeviceConfigurer]. With the Exclusive
+// pinning policy, it pins the CPUs of the device's share for the claim, so
+// that they are not picked for other shares. Devices allocated with admin
+// access are only observed and pin no CPUs.
+func (p Profile) ConfigureDevice(ctx context.Context, claimUID types.UID, config runtime.Object, result *resourceapi.DeviceRequestAllocationResult) error {
+	if p.pinning == nil || ptr.Deref(result.AdminAccess, false) {
+		return nil
+	}
+	cpuConfig := configapi.DefaultCpuConfig()
+	if config != nil {
+		c, ok := config.(*configapi.CpuConfig)
+		if !ok {
+			return fmt.Errorf("runtime object is not a recognized configuration")
+		}
+		cpuConfig = c.DeepCopy()
+	}
+	if err := cpuConfig.Normalize(); err != nil {
+		return fmt.Errorf("error normalizing CPU config: %w", err)
+	}
+	if cpuConfig.PinningPolicy != configapi.ExclusivePinningPolicy {
+		return nil
+	}
+	reserved, err := cpuConfig.Reserved()
+	if err != nil {
+		return err
+	}
+
+	device := pinningKey(result)
+	err = p.pinning.Pin(device, claimUID, shareID(result), func(taken cpuset.CPUSet) (cpuset.CPUSet, error) {
+		return p.assignCPUs(cpuConfig, reserved, result, taken)
+	})
+	if err != nil {
+		return err
+	}
+	klog.FromContext(ctx).V(4).Info("Pinned CPUs", "device", device, "claim", claimUID, "shareID", shareID(result))
+	return nil
+}
+
+// ReleaseDevices implements [profiles.DeviceConfigurer].
+func (p Profile) ReleaseDevices(ctx context.Context, claimUID types.UID) error {
+	if p.pinning == nil {
+		return nil
+	}
+	return p.pinning.Release(claimUID)
+}
+
+// pinningState returns the CPUs pinned for exclusive shares of the devices
+// when they are tracked and config pins CPUs exclusively, nil otherwise.
+func (p Profile) pinningState(config *configapi.CpuConfig) (*PinningState, error) {
+	if p.pinning == nil || config.PinningPolicy != configapi.ExclusivePinningPolicy {
+		return nil, nil
+	}
+	return p.pinning.State()
+}
+
+// pinnedCPUs returns the CPUs a container using the allocated device is
+// pinned to with the given normalized config. With the Exclusive pinning
+// policy, the CPUs pinned in state for the share of result are returned if
+// there are any. Otherwise, CPUs neither pinned in state for other shares of
+// the device nor in taken are picked.
+func (p Profile) pinnedCPUs(config *configapi.CpuConfig, reserved cpuset.CPUSet, result *resourceapi.DeviceRequestAllocationResult, state *PinningState, taken cpuset.CPUSet) (cpuset.CPUSet, error) {
+	if state != nil && config.PinningPolicy == configapi.ExclusivePinningPolicy {
+		device := pinningKey(result)
+		if pinned, ok := state.lookup(device, shareID(result)); ok {
+			return cpuset.Parse(pinned.CPUs)
+		}
+		others, err := state.taken(device, shareID(result))
+		if err != nil {
+			return cpuset.CPUSet{}, err
+		}
+		taken = taken.Union(others)
+	}
+	return p.assignCPUs(config, reserved, result, taken)
+}
+
+// assignCPUs returns the CPUs a container using the allocated device is
+// pinned to with the given normalized config. With the Exclusive pinning
+// policy, the taken CPUs are not picked.
+func (p Profile) assignCPUs(config *configapi.CpuConfig, reserved cpuset.CPUSet, result *resourceapi.DeviceRequestAllocationResult, taken cpuset.CPUSet) (cpuset.CPUSet, error) {
+	index, err := numaIndex(result.Device)
+	if err != nil {
+		return cpuset.CPUSet{}, err
+	}
+
+	cpus := p.usableCPUs(index, reserved, config.SMT)
+	if config.PinningPolicy == configapi.ExclusivePinningPolicy {
+		count := cpus.Size()
+		if cpu, consumed := result.ConsumedCapacity[p.CapacityKey()]; consumed {
+			if cpu.MilliValue()%1000 != 0 {
+				return cpuset.CPUSet{}, fmt.Errorf("device %s: %v pinning policy requires whole CPUs, got %s", result.Device, config.PinningPolicy, cpu.String())
+			}
+			count = int(cpu.Value())
+		}
+		cpus, err = pickCPUs(cpus.Difference(taken), count, config.SMT == configapi.FullCoresSMTPolicy)
+		if err != nil {
+			return cpuset.CPUSet{}, fmt.Errorf("device %s: %w", result.Device, err)
+		}
+	}
+	if cpus.IsEmpty() {
+		return cpuset.CPUSet{}, fmt.Errorf("device %s: no usable CPUs", result.Device)
+	}
+	return cpus, nil
+}
+
+// pinningKey returns the key of the allocated device in [PinningState].
+func pinningKey(result *resourceapi.DeviceRequestAllocationResult) string {
+	return result.Pool + "/" + result.Device
+}
+
+// shareID returns the share of the allocated device, empty when the whole
+// device is allocated.
+func shareID(result *resourceapi.DeviceRequestAllocationResult) string {
+	return string(ptr.Deref(result.ShareID, ""))
+}
+
+// numaIndex returns the index of the NUMA node of a device named
+// "numa-<index>".
+func numaIndex(device string) (int, error) {
+	index, err := strconv.Atoi(strings.TrimPrefix(device, "numa-"))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected device name %q", device)
+	}
+	return index, nil
+}
+
+// numaCPUs returns the simulated CPUs of the NUMA node with the given index. CPUs
+// are numbered consecutively across NUMA nodes, with the [threadsPerCore]
+// threads of a core next to each other.
+func (p Profile) numaCPUs(index int) cpuset.CPUSet {
+	first := index * p.cpusPerNUMANode
+	var cpus []int
+	for cpu := first; cpu < first+p.cpusPerNUMANode; cpu++ {
+		cpus = append(cpus, cpu)
+	}
+	return cpuset.New(cpus...)
+}
+
+// usableCPUs returns the CPUs of a NUMA node which are neither reserved nor
+// left idle by the SMT policy.
+func (p Profile) usableCPUs(index int, reserved cpuset.CPUSet, smt configapi.SMTPolicy) cpuset.CPUSet {
+	cpus := p.numaCPUs(index).Difference(reserved)
+	if smt != configapi.SingleThreadSMTPolicy {
+		return cpus
+	}
+	var firstThreads []int
+	for _, cpu := range cpus.List() {
+		if cpu%threadsPerCore == 0 {
+			firstThreads = append(firstThreads, cpu)
+		}
+	}
+	return cpuset.New(firstThreads...)
+}
+
+// pickCPUs returns count CPUs out of the usable ones, preferring the lowest
+// CPU ids. With fullCores, only cores whose threads are all usable are
+// picked and count must be a multiple of [threadsPerCore].
+func pickCPUs(usable cpuset.CPUSet, count int, fullCores bool) (cpuset.CPUSet, error) {
+	if !fullCores {
+		if count > usable.Size() {
+			return cpuset.CPUSet{}, fmt.Errorf("%d CPUs requested but only %d usable", count, usable.Size())
+		}
+		return cpuset.New(usable.List()[:count]...), nil
+	}
+
+	if count%threadsPerCore != 0 {
+		return cpuset.CPUSet{}, fmt.Errorf("%d CPUs requested but full cores have %d threads", count, threadsPerCore)
+	}
+	var picked []int
+	for _, cpu := range usable.List() {
+		if len(picked) == count {
+			break
+		}
+		if cpu%threadsPerCore != 0 {
+			continue
+		}
+		core := make([]int, 0, threadsPerCore)
+		for thread := range threadsPerCore {
+			core = append(core, cpu+thread)
+		}
+		if cpuset.New(core...).IsSubsetOf(usable) {
+			picked = append(picked, core...)
+		}
+	}
+	if len(picked) < count {
+		return cpuset.CPUSet{}, fmt.Errorf("%d CPUs requested but only %d usable in full cores", count, len(picked))
+	}
+	return cpuset.New(picked...), nil
+}
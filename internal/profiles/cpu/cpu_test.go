@@ -25,10 +25,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	resourceapi "k8s.io/api/resource/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	configapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/cpu/v1alpha1"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
 )
 
 func TestNewProfile(t *testing.T) {
-	profile := NewProfile("test-node", "cpu.example.com", 2, 4)
+	profile := NewProfile("test-node", "cpu.example.com", 2, 4, false, nil)
 
 	assert.Equal(t, "test-node", profile.nodeName)
 	assert.Equal(t, "cpu.example.com", profile.driverName)
@@ -38,7 +42,7 @@ func TestNewProfile(t *testing.T) {
 
 func TestEnumerateDevices(t *testing.T) {
 	const cpusPerNUMA = 4
-	profile := NewProfile("test-node", "cpu.example.com", 3, cpusPerNUMA)
+	profile := NewProfile("test-node", "cpu.example.com", 3, cpusPerNUMA, false, nil)
 	wantKey := profile.CapacityKey()
 
 	resources, err := profile.EnumerateDevices()
@@ -59,6 +63,7 @@ func TestEnumerateDevices(t *testing.T) {
 		numaID := device.Attributes["numaNodeID"]
 		require.NotNil(t, numaID.IntValue)
 		assert.Equal(t, int64(i), *numaID.IntValue)
+		assert.Equal(t, numaID, device.Attributes[profiles.NUMANodeAttribute])
 
 		cap, ok := device.Capacity[wantKey]
 		require.True(t, ok, "device %q missing %q capacity entry", device.Name, wantKey)
@@ -73,19 +78,146 @@ func TestEnumerateDevices(t *testing.T) {
 }
 
 func TestApplyConfig(t *testing.T) {
-	profile := NewProfile("test-node", "cpu.example.com", 2, 4)
-	results := []*resourceapi.DeviceRequestAllocationResult{{
-		Device: "numa-0",
-		ConsumedCapacity: map[resourceapi.QualifiedName]resource.Quantity{
-			profile.CapacityKey(): resource.MustParse("2"),
+	profile := NewProfile("test-node", "cpu.example.com", 2, 8, false, nil)
+	consumed := func(device string, cpus string) *resourceapi.DeviceRequestAllocationResult {
+		return &resourceapi.DeviceRequestAllocationResult{
+			Device: device,
+			ConsumedCapacity: map[resourceapi.QualifiedName]resource.Quantity{
+				profile.CapacityKey(): resource.MustParse(cpus),
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		config      *configapi.CpuConfig
+		result      *resourceapi.DeviceRequestAllocationResult
+		expected    []string
+		expectedErr string
+	}{
+		"default config": {
+			result: consumed("numa-0", "2"),
+			expected: []string{
+				"CPU_DEVICE_0=numa-0",
+				"CPU_DEVICE_0_PINNING_POLICY=Shared",
+				"CPU_DEVICE_0_SMT=Allow",
+				"CPU_DEVICE_0_CONSUMED_CPU=2",
+				"CPU_DEVICE_0_CPUSET=0-7",
+			},
+		},
+		"shared with reserved cores": {
+			config: &configapi.CpuConfig{ReservedCores: "8-9,15"},
+			result: consumed("numa-1", "500m"),
+			expected: []string{
+				"CPU_DEVICE_1=numa-1",
+				"CPU_DEVICE_1_PINNING_POLICY=Shared",
+				"CPU_DEVICE_1_SMT=Allow",
+				"CPU_DEVICE_1_CONSUMED_CPU=500m",
+				"CPU_DEVICE_1_CPUSET=10-14",
+			},
+		},
+		"shared single thread": {
+			config: &configapi.CpuConfig{SMT: configapi.SingleThreadSMTPolicy},
+			result: consumed("numa-1", "1"),
+			expected: []string{
+				"CPU_DEVICE_1=numa-1",
+				"CPU_DEVICE_1_PINNING_POLICY=Shared",
+				"CPU_DEVICE_1_SMT=SingleThread",
+				"CPU_DEVICE_1_CONSUMED_CPU=1",
+				"CPU_DEVICE_1_CPUSET=8,10,12,14",
+			},
+		},
+		"exclusive": {
+			config: &configapi.CpuConfig{PinningPolicy: configapi.ExclusivePinningPolicy, ReservedCores: "0"},
+			result: consumed("numa-0", "3"),
+			expected: []string{
+				"CPU_DEVICE_0=numa-0",
+				"CPU_DEVICE_0_PINNING_POLICY=Exclusive",
+				"CPU_DEVICE_0_SMT=Allow",
+				"CPU_DEVICE_0_CONSUMED_CPU=3",
+				"CPU_DEVICE_0_CPUSET=1-3",
+			},
+		},
+		"exclusive full cores": {
+			config: &configapi.CpuConfig{PinningPolicy: configapi.ExclusivePinningPolicy, SMT: configapi.FullCoresSMTPolicy, ReservedCores: "1"},
+			result: consumed("numa-0", "4"),
+			expected: []string{
+				"CPU_DEVICE_0=numa-0",
+				"CPU_DEVICE_0_PINNING_POLICY=Exclusive",
+				"CPU_DEVICE_0_SMT=FullCores",
+				"CPU_DEVICE_0_CONSUMED_CPU=4",
+				"CPU_DEVICE_0_CPUSET=2-5",
+			},
+		},
+		"exclusive single thread": {
+			config: &configapi.CpuConfig{PinningPolicy: configapi.ExclusivePinningPolicy, SMT: configapi.SingleThreadSMTPolicy},
+			result: consumed("numa-0", "2"),
+			expected: []string{
+				"CPU_DEVICE_0=numa-0",
+				"CPU_DEVICE_0_PINNING_POLICY=Exclusive",
+				"CPU_DEVICE_0_SMT=SingleThread",
+				"CPU_DEVICE_0_CONSUMED_CPU=2",
+				"CPU_DEVICE_0_CPUSET=0,2",
+			},
+		},
+		"exclusive whole device": {
+			config: &configapi.CpuConfig{PinningPolicy: configapi.ExclusivePinningPolicy},
+			result: &resourceapi.DeviceRequestAllocationResult{Device: "numa-1"},
+			expected: []string{
+				"CPU_DEVICE_1=numa-1",
+				"CPU_DEVICE_1_PINNING_POLICY=Exclusive",
+				"CPU_DEVICE_1_SMT=Allow",
+				"CPU_DEVICE_1_CPUSET=8-15",
+			},
+		},
+		"exclusive fractional CPUs": {
+			config:      &configapi.CpuConfig{PinningPolicy: configapi.ExclusivePinningPolicy},
+			result:      consumed("numa-0", "1500m"),
+			expectedErr: "device numa-0: Exclusive pinning policy requires whole CPUs, got 1500m",
 		},
-	}}
+		"exclusive full cores odd CPUs": {
+			config:      &configapi.CpuConfig{PinningPolicy: configapi.ExclusivePinningPolicy, SMT: configapi.FullCoresSMTPolicy},
+			result:      consumed("numa-0", "3"),
+			expectedErr: "device numa-0: 3 CPUs requested but full cores have 2 threads",
+		},
+		"exclusive too many reserved cores": {
+			config:      &configapi.CpuConfig{PinningPolicy: configapi.ExclusivePinningPolicy, ReservedCores: "0-5"},
+			result:      consumed("numa-0", "4"),
+			expectedErr: "device numa-0: 4 CPUs requested but only 2 usable",
+		},
+		"all cores reserved": {
+			config:      &configapi.CpuConfig{ReservedCores: "0-15"},
+			result:      consumed("numa-0", "1"),
+			expectedErr: "device numa-0: no usable CPUs",
+		},
+		"invalid config": {
+			config:      &configapi.CpuConfig{SMT: configapi.FullCoresSMTPolicy},
+			result:      consumed("numa-0", "1"),
+			expectedErr: "error validating CPU config: SMT policy FullCores requires the Exclusive pinning policy",
+		},
+	}
 
-	edits, err := profile.ApplyConfig(nil, results)
-	require.NoError(t, err)
-	require.Contains(t, edits, "numa-0")
-	assert.ElementsMatch(t,
-		[]string{"CPU_DEVICE_0=numa-0", "CPU_DEVICE_0_CONSUMED_CPU=2"},
-		edits["numa-0"].Env,
-	)
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var config runtime.Object
+			if test.config != nil {
+				config = test.config
+			}
+			edits, err := profile.ApplyConfig(config, []*resourceapi.DeviceRequestAllocationResult{test.result})
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Contains(t, edits, test.result.Device)
+			assert.ElementsMatch(t, test.expected, edits[test.result.Device].Env)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	profile := NewProfile("test-node", "cpu.example.com", 2, 8, false, nil)
+
+	assert.NoError(t, profile.Validate(&configapi.CpuConfig{}))
+	assert.EqualError(t, profile.Validate(&configapi.CpuConfig{ReservedCores: "2-"}), `invalid reservedCores: strconv.Atoi: parsing "": invalid syntax`)
+	assert.EqualError(t, profile.Validate(nil), "expected v1alpha1.CpuConfig but got: <nil>")
 }
@@ -0,0 +1,225 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cpu
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/cpuset"
+	"sigs.k8s.io/yaml"
+)
+
+// PinningFile is the name of the file in the driver's state directory in
+// which the CPUs pinned for exclusive shares of the NUMA devices are
+// persisted.
+const PinningFile = "cpu-pinning.json"
+
+// Pinning tracks the CPUs of each NUMA device which are pinned exclusively
+// for a share of the device, so that no CPU is pinned for two shares at the
+// same time. The pinned CPUs are persisted in a JSON file, so that they
+// survive restarts of the driver and can be inspected on the node, for
+// example:
+//
+//	{
+//	  "devices": {
+//	    "test-node/numa-0": [
+//	      {"claim": "6c3f4f0e-...", "shareID": "0b7a...", "cpus": "0-1"}
+//	    ]
+//	  }
+//	}
+type Pinning struct {
+	mu   sync.Mutex
+	path string
+}
+
+// PinningState is the content of the pinning file.
+type PinningState struct {
+	// Devices holds the CPUs pinned for the shares of each NUMA device,
+	// keyed by "<pool>/<device>".
+	Devices map[string][]PinnedCPUs `json:"devices,omitempty"`
+}
+
+// PinnedCPUs are the CPUs pinned for one share of a NUMA device.
+type PinnedCPUs struct {
+	// Claim is the claim the share is allocated to.
+	Claim types.UID `json:"claim"`
+	// ShareID identifies the share of the device, empty when the whole
+	// device is allocated.
+	ShareID string `json:"shareID,omitempty"`
+	// CPUs is the pinned cpuset in Linux CPU list format.
+	CPUs string `json:"cpus"`
+}
+
+// NewPinning returns the pinned CPUs persisted in the file at path. The file
+// is created on the first change.
+func NewPinning(path string) *Pinning {
+	return &Pinning{path: path}
+}
+
+// State reads back the currently pinned CPUs.
+func (p *Pinning) State() (*PinningState, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.read()
+}
+
+// Pin pins CPUs of a device for a share of it allocated to a claim. pick
+// returns the CPUs to pin given the CPUs already pinned for other shares of
+// the device. Pinning the same share for the same claim again keeps the CPUs
+// pinned before.
+func (p *Pinning) Pin(device string, claimUID types.UID, shareID string, pick func(taken cpuset.CPUSet) (cpuset.CPUSet, error)) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, err := p.read()
+	if err != nil {
+		return err
+	}
+	if pinned, ok := state.lookup(device, shareID); ok {
+		if pinned.Claim != claimUID {
+			return fmt.Errorf("CPUs %s of device %s are pinned for claim %s", pinned.CPUs, device, pinned.Claim)
+		}
+		return nil
+	}
+	taken, err := state.taken(device, shareID)
+	if err != nil {
+		return err
+	}
+	cpus, err := pick(taken)
+	if err != nil {
+		return err
+	}
+	if overlap := cpus.Intersection(taken); !overlap.IsEmpty() {
+		return fmt.Errorf("CPUs %s of device %s are already pinned", overlap, device)
+	}
+
+	if state.Devices == nil {
+		state.Devices = make(map[string][]PinnedCPUs)
+	}
+	state.Devices[device] = append(state.Devices[device], PinnedCPUs{
+		Claim:   claimUID,
+		ShareID: shareID,
+		CPUs:    cpus.String(),
+	})
+	return p.write(state)
+}
+
+// Release unpins the CPUs of all devices pinned for a claim.
+func (p *Pinning) Release(claimUID types.UID) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, err := p.read()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for device, pinned := range state.Devices {
+		remaining := slices.DeleteFunc(slices.Clone(pinned), func(pinned PinnedCPUs) bool { return pinned.Claim == claimUID })
+		if len(remaining) == len(pinned) {
+			continue
+		}
+		if len(remaining) == 0 {
+			delete(state.Devices, device)
+		} else {
+			state.Devices[device] = remaining
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return p.write(state)
+}
+
+// lookup returns the CPUs of a device pinned for a share of it.
+func (s *PinningState) lookup(device, shareID string) (PinnedCPUs, bool) {
+	for _, pinned := range s.Devices[device] {
+		if pinned.ShareID == shareID {
+			return pinned, true
+		}
+	}
+	return PinnedCPUs{}, false
+}
+
+// taken returns the CPUs of a device pinned for all shares of it but the
+// given one.
+func (s *PinningState) taken(device, shareID string) (cpuset.CPUSet, error) {
+	taken := cpuset.New()
+	for _, pinned := range s.Devices[device] {
+		if pinned.ShareID == shareID {
+			continue
+		}
+		cpus, err := cpuset.Parse(pinned.CPUs)
+		if err != nil {
+			return cpuset.CPUSet{}, fmt.Errorf("invalid CPUs pinned for claim %s on device %s: %w", pinned.Claim, device, err)
+		}
+		taken = taken.Union(cpus)
+	}
+	return taken, nil
+}
+
+// read reads the pinning file. A missing file means that no CPU was pinned
+// yet.
+func (p *Pinning) read() (*PinningState, error) {
+	state := new(PinningState)
+	data, err := os.ReadFile(p.path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read pinning file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("decode pinning file %s: %w", p.path, err)
+	}
+	return state, nil
+}
+
+// write atomically replaces the pinning file with state.
+func (p *Pinning) write(state *PinningState) (err error) {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode pinning state: %w", err)
+	}
+	dir := filepath.Dir(p.path)
+	tmp, err := os.CreateTemp(dir, "tmp-pinning-*")
+	if err != nil {
+		return fmt.Errorf("create temp file in %s: %w", dir, err)
+	}
+	defer func() {
+		if err1 := tmp.Close(); err1 != nil && err == nil {
+			err = fmt.Errorf("close temp file: %w", err1)
+		}
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("write temp file %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p.path); err != nil {
+		return fmt.Errorf("rename %s to %s: %w", tmp.Name(), p.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cpu
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/cpuset"
+)
+
+func TestPinning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), PinningFile)
+	pinning := NewPinning(path)
+	pick := func(count int) func(taken cpuset.CPUSet) (cpuset.CPUSet, error) {
+		return func(taken cpuset.CPUSet) (cpuset.CPUSet, error) {
+			return pickCPUs(cpuset.New(0, 1, 2, 3, 4, 5).Difference(taken), count, false)
+		}
+	}
+
+	state, err := pinning.State()
+	require.NoError(t, err)
+	assert.Empty(t, state.Devices, "no CPU is pinned before the first change")
+
+	require.NoError(t, pinning.Pin("node/numa-0", "claim-a", "share-a", pick(2)))
+	require.NoError(t, pinning.Pin("node/numa-0", "claim-a", "share-a", pick(4)), "pinning again must keep the pinned CPUs")
+	require.NoError(t, pinning.Pin("node/numa-0", "claim-b", "share-b", pick(2)))
+	require.NoError(t, pinning.Pin("node/numa-1", "claim-b", "share-c", pick(1)))
+
+	err = pinning.Pin("node/numa-0", "claim-c", "share-a", pick(1))
+	require.EqualError(t, err, "CPUs 0-1 of device node/numa-0 are pinned for claim claim-a")
+	err = pinning.Pin("node/numa-0", "claim-c", "share-d", pick(4))
+	require.EqualError(t, err, "4 CPUs requested but only 2 usable")
+
+	// The state is read back from disk.
+	state, err = NewPinning(path).State()
+	require.NoError(t, err)
+	assert.Equal(t, &PinningState{Devices: map[string][]PinnedCPUs{
+		"node/numa-0": {
+			{Claim: "claim-a", ShareID: "share-a", CPUs: "0-1"},
+			{Claim: "claim-b", ShareID: "share-b", CPUs: "2-3"},
+		},
+		"node/numa-1": {
+			{Claim: "claim-b", ShareID: "share-c", CPUs: "0"},
+		},
+	}}, state)
+
+	require.NoError(t, pinning.Release("claim-b"))
+	require.NoError(t, pinning.Release("claim-b"), "releasing again must succeed")
+	require.NoError(t, pinning.Pin("node/numa-0", "claim-c", "share-d", pick(4)))
+
+	state, err = pinning.State()
+	require.NoError(t, err)
+	assert.Equal(t, &PinningState{Devices: map[string][]PinnedCPUs{
+		"node/numa-0": {
+			{Claim: "claim-a", ShareID: "share-a", CPUs: "0-1"},
+			{Claim: "claim-c", ShareID: "share-d", CPUs: "2-5"},
+		},
+	}}, state)
+}
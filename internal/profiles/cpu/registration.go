@@ -0,0 +1,61 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cpu
+
+import (
+	"path/filepath"
+
+	"github.com/urfave/cli/v2"
+
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+// Registration returns the registration of the cpu profile with its command
+// line flags.
+func Registration() profiles.Registration {
+	var settings struct {
+		numaNodes       int
+		cpusPerNUMANode int
+	}
+	return profiles.Registration{
+		Name: ProfileName,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:        "cpu-numa-nodes",
+				Usage:       "Number of fake NUMA-node devices to advertise. Only relevant for the " + ProfileName + " profile.",
+				Value:       8,
+				Destination: &settings.numaNodes,
+				EnvVars:     []string{"CPU_NUMA_NODES"},
+			},
+			&cli.IntFlag{
+				Name:        "cpus-per-numa-node",
+				Usage:       "Number of CPUs each fake NUMA-node device advertises as consumable capacity. Only relevant for the " + ProfileName + " profile.",
+				Value:       4,
+				Destination: &settings.cpusPerNUMANode,
+				EnvVars:     []string{"CPUS_PER_NUMA_NODE"},
+			},
+		},
+		ConfigHandler: Profile{},
+		New: func(opts profiles.Options) (profiles.Profile, error) {
+			var pinning *Pinning
+			if opts.StateDir != "" {
+				pinning = NewPinning(filepath.Join(opts.StateDir, PinningFile))
+			}
+			return NewProfile(opts.NodeName, opts.DriverName, settings.numaNodes, settings.cpusPerNUMANode, opts.DeviceStatus, pinning), nil
+		},
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cpu
+
+import (
+	"encoding/json"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/cpuset"
+	"k8s.io/utils/ptr"
+
+	configapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/cpu/v1alpha1"
+)
+
+// BuildDeviceStatus implements [profiles.DeviceStatusBuilder]. It returns an
+// [resourceapi.AllocatedDeviceStatus] with the NUMA node of the allocated
+// device and the CPUs the container is pinned to with the applied config, to
+// publish into ResourceClaim.status.devices[].data.
+func (p Profile) BuildDeviceStatus(allocatable map[string]resourceapi.Device, config runtime.Object, result *resourceapi.DeviceRequestAllocationResult) *resourceapi.AllocatedDeviceStatus {
+	if !p.enableDeviceStatus {
+		return nil
+	}
+	logger := klog.Background().WithValues("device", result.Device)
+
+	deviceInfo := make(map[string]resourceapi.DeviceAttribute)
+	if index, err := numaIndex(result.Device); err == nil {
+		deviceInfo["numaNodeID"] = resourceapi.DeviceAttribute{IntValue: ptr.To(int64(index))}
+	}
+
+	// The config was validated when the device was prepared. Work on a copy
+	// to leave the caller's config untouched by normalization.
+	cpuConfig := configapi.DefaultCpuConfig()
+	if config, ok := config.(*configapi.CpuConfig); ok {
+		cpuConfig = config.DeepCopy()
+	}
+	if err := cpuConfig.Normalize(); err != nil {
+		logger.Error(err, "Failed to normalize CPU config")
+	} else if reserved, err := cpuConfig.Reserved(); err != nil {
+		logger.Error(err, "Failed to get reserved CPUs")
+	} else if pinned, err := p.pinningState(cpuConfig); err != nil {
+		logger.Error(err, "Failed to read pinned CPUs")
+	} else if cpus, err := p.pinnedCPUs(cpuConfig, reserved, result, pinned, cpuset.New()); err != nil {
+		logger.Error(err, "Failed to assign CPUs")
+	} else {
+		deviceInfo["cpus"] = resourceapi.DeviceAttribute{StringValue: ptr.To(cpus.String())}
+	}
+
+	jsonBytes, err := json.Marshal(deviceInfo)
+	if err != nil {
+		logger.Error(err, "Failed to marshal device data")
+		jsonBytes = []byte("{}")
+	}
+
+	return &resourceapi.AllocatedDeviceStatus{
+		Device:  result.Device,
+		Driver:  result.Driver,
+		Pool:    result.Pool,
+		ShareID: (*string)(result.ShareID),
+		Data:    &runtime.RawExtension{Raw: jsonBytes},
+	}
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cpu
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	configapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/cpu/v1alpha1"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+func TestBuildDeviceStatus_Disabled(t *testing.T) {
+	var _ profiles.DeviceStatusBuilder = NewProfile("test-node", "cpu.example.com", 2, 8, true, nil)
+
+	profile := NewProfile("test-node", "cpu.example.com", 2, 8, false, nil)
+	result := &resourceapi.DeviceRequestAllocationResult{
+		Device: "numa-1",
+		Driver: "cpu.example.com",
+		Pool:   "test-node",
+	}
+
+	got := profile.BuildDeviceStatus(nil, nil, result)
+	assert.Nil(t, got, "BuildDeviceStatus must return nil when device status is disabled")
+}
+
+func TestBuildDeviceStatus_Enabled(t *testing.T) {
+	profile := NewProfile("test-node", "cpu.example.com", 2, 8, true, nil)
+	consumed := map[resourceapi.QualifiedName]resource.Quantity{
+		"cpu.example.com/cpu": resource.MustParse("4"),
+	}
+
+	tests := map[string]struct {
+		config   runtime.Object
+		expected map[string]resourceapi.DeviceAttribute
+	}{
+		"default config": {
+			expected: map[string]resourceapi.DeviceAttribute{
+				"numaNodeID": {IntValue: ptr.To(int64(1))},
+				"cpus":       {StringValue: ptr.To("8-15")},
+			},
+		},
+		"exclusive pinning with reserved cores": {
+			config: &configapi.CpuConfig{
+				PinningPolicy: configapi.ExclusivePinningPolicy,
+				ReservedCores: "8-9",
+			},
+			expected: map[string]resourceapi.DeviceAttribute{
+				"numaNodeID": {IntValue: ptr.To(int64(1))},
+				"cpus":       {StringValue: ptr.To("10-13")},
+			},
+		},
+		"unsatisfiable config": {
+			config: &configapi.CpuConfig{
+				PinningPolicy: configapi.ExclusivePinningPolicy,
+				ReservedCores: "8-13",
+			},
+			expected: map[string]resourceapi.DeviceAttribute{
+				"numaNodeID": {IntValue: ptr.To(int64(1))},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := &resourceapi.DeviceRequestAllocationResult{
+				Device:           "numa-1",
+				Driver:           "cpu.example.com",
+				Pool:             "test-node",
+				ShareID:          ptr.To(types.UID("share-1")),
+				ConsumedCapacity: consumed,
+			}
+			got := profile.BuildDeviceStatus(nil, test.config, result)
+			require.NotNil(t, got)
+			assert.Equal(t, "numa-1", got.Device)
+			assert.Equal(t, ptr.To("share-1"), got.ShareID)
+			require.NotNil(t, got.Data)
+
+			var data map[string]resourceapi.DeviceAttribute
+			require.NoError(t, json.Unmarshal(got.Data.Raw, &data))
+			assert.Equal(t, test.expected, data)
+		})
+	}
+}
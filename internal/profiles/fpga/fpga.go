@@ -0,0 +1,179 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fpga
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/dynamic-resource-allocation/resourceslice"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	cdiapi "tags.cncf.io/container-device-interface/pkg/cdi"
+	cdispec "tags.cncf.io/container-device-interface/specs-go"
+
+	configapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/fpga/v1alpha1"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles/helpers"
+)
+
+const ProfileName = "fpga"
+
+// Profile advertises reconfigurable accelerators whose opaque config selects
+// the bitstream they are programmed with. Unlike the other profiles,
+// preparing a device is slow and has a side-effect which outlives the claim:
+// loading a bitstream takes reprogramDuration, and the loaded bitstream stays
+// on the device until another one is loaded.
+type Profile struct {
+	nodeName          string
+	numDevices        int
+	reprogramDuration time.Duration
+	topology          profiles.Topology
+}
+
+func NewProfile(nodeName string, numDevices int, reprogramDuration time.Duration, topology profiles.Topology) Profile {
+	return Profile{
+		nodeName:          nodeName,
+		numDevices:        numDevices,
+		reprogramDuration: reprogramDuration,
+		topology:          topology,
+	}
+}
+
+func (p Profile) EnumerateDevices() (resourceslice.DriverResources, error) {
+	seed := p.nodeName
+	uuids := helpers.GenerateUUIDs(seed, "fpga", p.numDevices)
+
+	var devices []resourceapi.Device
+	for i, uuid := range uuids {
+		attrs := p.topology.Attributes(i, p.numDevices)
+		attrs["index"] = resourceapi.DeviceAttribute{IntValue: ptr.To(int64(i))}
+		attrs["uuid"] = resourceapi.DeviceAttribute{StringValue: ptr.To(uuid)}
+		attrs["model"] = resourceapi.DeviceAttribute{StringValue: ptr.To("LATEST-FPGA-MODEL")}
+		attrs["driverVersion"] = resourceapi.DeviceAttribute{VersionValue: ptr.To("1.0.0")}
+		devices = append(devices, resourceapi.Device{
+			Name:       fmt.Sprintf("fpga-%d", i),
+			Attributes: attrs,
+		})
+	}
+
+	return resourceslice.DriverResources{
+		Pools: map[string]resourceslice.Pool{
+			p.nodeName: {Slices: helpers.PoolSlices(nil, devices)},
+		},
+	}, nil
+}
+
+// SchemeBuilder implements [profiles.ConfigHandler].
+func (p Profile) SchemeBuilder() runtime.SchemeBuilder {
+	return runtime.NewSchemeBuilder(
+		configapi.AddToScheme,
+	)
+}
+
+// Validate implements [profiles.ConfigHandler].
+func (p Profile) Validate(config runtime.Object) error {
+	fpgaConfig, ok := config.(*configapi.FpgaConfig)
+	if !ok {
+		return fmt.Errorf("expected v1alpha1.FpgaConfig but got: %T", config)
+	}
+	return fpgaConfig.Validate()
+}
+
+// ApplyConfig implements [profiles.ConfigHandler].
+func (p Profile) ApplyConfig(config runtime.Object, results []*resourceapi.DeviceRequestAllocationResult) (profiles.PerDeviceCDIContainerEdits, error) {
+	if config == nil {
+		config = configapi.DefaultFpgaConfig()
+	}
+	if config, ok := config.(*configapi.FpgaConfig); ok {
+		return p.applyFpgaConfig(config, results)
+	}
+	return nil, fmt.Errorf("runtime object is not a recognized configuration")
+}
+
+// applyFpgaConfig injects env vars per allocated device so the demo container
+// can show which device was allocated and which bitstream it runs. The device
+// itself is programmed by [Profile.PrepareDeviceState].
+func (p Profile) applyFpgaConfig(config *configapi.FpgaConfig, results []*resourceapi.DeviceRequestAllocationResult) (profiles.PerDeviceCDIContainerEdits, error) {
+	// Normalize the config to set any implied defaults.
+	if err := config.Normalize(); err != nil {
+		return nil, fmt.Errorf("error normalizing FPGA config: %w", err)
+	}
+
+	// Validate the config to ensure its integrity.
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("error validating FPGA config: %w", err)
+	}
+
+	edits := make(profiles.PerDeviceCDIContainerEdits, len(results))
+	for _, result := range results {
+		// Device names are "fpga-<index>"; trim the prefix for the env var.
+		envID := result.Device[len("fpga-"):]
+		envs := []string{
+			fmt.Sprintf("FPGA_DEVICE_%s=%s", envID, result.Device),
+			fmt.Sprintf("FPGA_DEVICE_%s_BITSTREAM=%s", envID, config.Bitstream),
+		}
+		deviceID := helpers.GetCDIDeviceID(result.Device, (*string)(result.ShareID))
+		edits[deviceID] = &cdiapi.ContainerEdits{ContainerEdits: &cdispec.ContainerEdits{
+			Env: envs,
+		}}
+	}
+	return edits, nil
+}
+
+// PrepareDeviceState implements [profiles.DeviceStatePreparer]. The state of
+// a device is the bitstream it is programmed with. Loading another bitstream
+// is simulated by waiting for the reprogram duration; it is skipped when the
+// device already runs the requested bitstream, and for admin access, which
+// must not disturb the workload currently using the device.
+func (p Profile) PrepareDeviceState(ctx context.Context, config runtime.Object, result *resourceapi.DeviceRequestAllocationResult, current string) (string, error) {
+	if result.AdminAccess != nil && *result.AdminAccess {
+		return current, nil
+	}
+
+	// The config was validated when the device was prepared. Work on a copy
+	// to leave the caller's config untouched by normalization.
+	fpgaConfig := configapi.DefaultFpgaConfig()
+	if config, ok := config.(*configapi.FpgaConfig); ok {
+		fpgaConfig = config.DeepCopy()
+	}
+	if err := fpgaConfig.Normalize(); err != nil {
+		return current, fmt.Errorf("error normalizing FPGA config: %w", err)
+	}
+
+	logger := klog.FromContext(ctx).WithValues("device", result.Device, "bitstream", fpgaConfig.Bitstream)
+	if current == fpgaConfig.Bitstream {
+		logger.Info("Bitstream already loaded, skipping reprogramming")
+		return current, nil
+	}
+
+	logger.Info("Reprogramming device", "previousBitstream", current, "duration", p.reprogramDuration)
+	timer := time.NewTimer(p.reprogramDuration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		// The device was left half-programmed, so the bitstream must be
+		// loaded again by the next claim regardless of which it asks for.
+		return "", fmt.Errorf("reprogramming device %s interrupted: %w", result.Device, context.Cause(ctx))
+	}
+	logger.Info("Reprogrammed device")
+	return fpgaConfig.Bitstream, nil
+}
@@ -0,0 +1,165 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fpga
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+
+	configapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/fpga/v1alpha1"
+	gpuconfigapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/gpu/v1alpha1"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+func TestEnumerateDevices(t *testing.T) {
+	profile := NewProfile("test-node", 3, 0, profiles.Topology{NUMANodes: 1, PCIeRootsPerNUMANode: 1})
+
+	resources, err := profile.EnumerateDevices()
+	require.NoError(t, err)
+
+	require.Contains(t, resources.Pools, "test-node")
+	pool := resources.Pools["test-node"]
+	require.Len(t, pool.Slices, 1)
+	devices := pool.Slices[0].Devices
+	require.Len(t, devices, 3)
+	for i, device := range devices {
+		assert.Equal(t, fmt.Sprintf("fpga-%d", i), device.Name)
+		assert.Equal(t, int64(i), *device.Attributes["index"].IntValue)
+		assert.Equal(t, "LATEST-FPGA-MODEL", *device.Attributes["model"].StringValue)
+		assert.NotEmpty(t, *device.Attributes["uuid"].StringValue)
+		assert.Contains(t, device.Attributes, resourceapi.QualifiedName(profiles.NUMANodeAttribute))
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	profile := NewProfile("test-node", 2, 0, profiles.Topology{})
+	results := []*resourceapi.DeviceRequestAllocationResult{{Device: "fpga-1"}}
+
+	tests := map[string]struct {
+		config      runtime.Object
+		expectedEnv []string
+		expectedErr string
+	}{
+		"default config": {
+			expectedEnv: []string{"FPGA_DEVICE_1=fpga-1", "FPGA_DEVICE_1_BITSTREAM=base"},
+		},
+		"custom bitstream": {
+			config:      &configapi.FpgaConfig{Bitstream: "aes-256"},
+			expectedEnv: []string{"FPGA_DEVICE_1=fpga-1", "FPGA_DEVICE_1_BITSTREAM=aes-256"},
+		},
+		"invalid bitstream": {
+			config:      &configapi.FpgaConfig{Bitstream: "-"},
+			expectedErr: "error validating FPGA config: invalid bitstream",
+		},
+		"other config": {
+			config:      &gpuconfigapi.GpuConfig{},
+			expectedErr: "runtime object is not a recognized configuration",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			edits, err := profile.ApplyConfig(test.config, results)
+			if test.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Contains(t, edits, "fpga-1")
+			assert.Equal(t, test.expectedEnv, edits["fpga-1"].Env)
+		})
+	}
+}
+
+func TestPrepareDeviceState(t *testing.T) {
+	var _ profiles.DeviceStatePreparer = Profile{}
+
+	const reprogramDuration = 50 * time.Millisecond
+	profile := NewProfile("test-node", 1, reprogramDuration, profiles.Topology{})
+	aes := &configapi.FpgaConfig{Bitstream: "aes-256"}
+
+	tests := map[string]struct {
+		config       runtime.Object
+		adminAccess  bool
+		current      string
+		expected     string
+		expectedSlow bool
+	}{
+		"unprogrammed device": {
+			config:       aes,
+			expected:     "aes-256",
+			expectedSlow: true,
+		},
+		"other bitstream loaded": {
+			config:       aes,
+			current:      "base",
+			expected:     "aes-256",
+			expectedSlow: true,
+		},
+		"bitstream already loaded": {
+			config:   aes,
+			current:  "aes-256",
+			expected: "aes-256",
+		},
+		"default bitstream already loaded": {
+			current:  configapi.DefaultBitstream,
+			expected: configapi.DefaultBitstream,
+		},
+		"admin access": {
+			config:      aes,
+			adminAccess: true,
+			current:     "base",
+			expected:    "base",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := &resourceapi.DeviceRequestAllocationResult{
+				Device:      "fpga-0",
+				AdminAccess: ptr.To(test.adminAccess),
+			}
+			start := time.Now()
+			state, err := profile.PrepareDeviceState(context.Background(), test.config, result, test.current)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, state)
+			assert.Equal(t, test.expectedSlow, time.Since(start) >= reprogramDuration)
+		})
+	}
+
+	// The config must not be modified.
+	assert.Equal(t, &configapi.FpgaConfig{Bitstream: "aes-256"}, aes)
+}
+
+func TestPrepareDeviceState_Interrupted(t *testing.T) {
+	profile := NewProfile("test-node", 1, time.Hour, profiles.Topology{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	state, err := profile.PrepareDeviceState(ctx, nil, &resourceapi.DeviceRequestAllocationResult{Device: "fpga-0"}, "aes-256")
+	assert.EqualError(t, err, "reprogramming device fpga-0 interrupted: context canceled")
+	assert.Empty(t, state, "an interrupted device must be reprogrammed by the next claim")
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fpga
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+// Registration returns the registration of the fpga profile with its command
+// line flags.
+func Registration() profiles.Registration {
+	var settings struct {
+		reprogramDuration time.Duration
+	}
+	return profiles.Registration{
+		Name: ProfileName,
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:        "fpga-reprogram-duration",
+				Usage:       "How long loading a bitstream onto a device takes while its claim is prepared. Loading is skipped when the device already runs the requested bitstream. Only relevant for the " + ProfileName + " profile.",
+				Value:       10 * time.Second,
+				Destination: &settings.reprogramDuration,
+				EnvVars:     []string{"FPGA_REPROGRAM_DURATION"},
+				Action: func(_ *cli.Context, duration time.Duration) error {
+					if duration < 0 {
+						return fmt.Errorf("invalid --fpga-reprogram-duration: negative duration: %v", duration)
+					}
+					return nil
+				},
+			},
+		},
+		ConfigHandler: Profile{},
+		New: func(opts profiles.Options) (profiles.Profile, error) {
+			return NewProfile(opts.NodeName, opts.NumDevices, settings.reprogramDuration, opts.Topology), nil
+		},
+	}
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpu
+
+import (
+	"fmt"
+	"maps"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+)
+
+// PartitionCatalog describes MIG-style partitioning of a GPU. A GPU consists
+// of a number of compute slices and memory slices. Each partition profile
+// occupies a contiguous range of memory slices starting at one of its
+// placements and a number of compute slices.
+//
+// Every partition that may be created is advertised as a device. Devices
+// consume a counter for each memory slice they occupy, so only partitions with
+// non-overlapping placements can be allocated at the same time, as well as
+// their share of the GPU's memory and compute slices.
+type PartitionCatalog struct {
+	// ComputeSlices is the number of compute slices of a GPU.
+	ComputeSlices int `json:"computeSlices,omitempty"`
+	// MemorySlices is the number of memory slices of a GPU.
+	MemorySlices int `json:"memorySlices,omitempty"`
+	// Profiles lists the partitions a GPU can be divided into.
+	Profiles []PartitionProfile `json:"profiles,omitempty"`
+}
+
+// PartitionProfile describes one size of partition in a [PartitionCatalog].
+type PartitionProfile struct {
+	// Name of the profile, e.g. "1g". It is part of the device names and
+	// therefore must be a DNS label.
+	Name string `json:"name"`
+	// ComputeSlices is the number of compute slices of the partition.
+	ComputeSlices int `json:"computeSlices"`
+	// MemorySlices is the number of memory slices of the partition.
+	MemorySlices int `json:"memorySlices"`
+	// Placements lists the memory slices at which the partition may start.
+	Placements []int `json:"placements"`
+}
+
+// defaultPartitionCatalog returns a catalog modeled after the MIG profiles
+// of a GPU with 7 compute slices and 8 memory slices.
+func defaultPartitionCatalog() PartitionCatalog {
+	return PartitionCatalog{
+		ComputeSlices: 7,
+		MemorySlices:  8,
+		Profiles: []PartitionProfile{
+			{Name: "1g", ComputeSlices: 1, MemorySlices: 1, Placements: []int{0, 1, 2, 3, 4, 5, 6}},
+			{Name: "2g", ComputeSlices: 2, MemorySlices: 2, Placements: []int{0, 2, 4}},
+			{Name: "3g", ComputeSlices: 3, MemorySlices: 4, Placements: []int{0, 4}},
+			{Name: "4g", ComputeSlices: 4, MemorySlices: 4, Placements: []int{0}},
+			{Name: "7g", ComputeSlices: 7, MemorySlices: 8, Placements: []int{0}},
+		},
+	}
+}
+
+// Default sets the default catalog if no profiles are set.
+func (c *PartitionCatalog) Default() {
+	if len(c.Profiles) == 0 && c.ComputeSlices == 0 && c.MemorySlices == 0 {
+		*c = defaultPartitionCatalog()
+	}
+}
+
+// Validate ensures that a PartitionCatalog has a valid set of values.
+func (c *PartitionCatalog) Validate() error {
+	if c.ComputeSlices < 1 {
+		return fmt.Errorf("number of compute slices must be at least 1: %d", c.ComputeSlices)
+	}
+	// A counter set holds the memory and compute counters plus one counter
+	// per memory slice.
+	maxMemorySlices := resourceapi.ResourceSliceMaxCountersPerCounterSet - 2
+	if c.MemorySlices < 1 || c.MemorySlices > maxMemorySlices {
+		return fmt.Errorf("number of memory slices must be between 1 and %d: %d", maxMemorySlices, c.MemorySlices)
+	}
+	if len(c.Profiles) == 0 {
+		return fmt.Errorf("no partition profiles")
+	}
+	names := sets.New[string]()
+	for _, profile := range c.Profiles {
+		if errs := validation.IsDNS1123Label(profile.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid partition profile name %q: %v", profile.Name, errs)
+		}
+		if names.Has(profile.Name) {
+			return fmt.Errorf("duplicate partition profile %q", profile.Name)
+		}
+		names.Insert(profile.Name)
+		if profile.ComputeSlices < 1 || profile.ComputeSlices > c.ComputeSlices {
+			return fmt.Errorf("partition profile %s: number of compute slices must be between 1 and %d: %d", profile.Name, c.ComputeSlices, profile.ComputeSlices)
+		}
+		if profile.MemorySlices < 1 || profile.MemorySlices > c.MemorySlices {
+			return fmt.Errorf("partition profile %s: number of memory slices must be between 1 and %d: %d", profile.Name, c.MemorySlices, profile.MemorySlices)
+		}
+		if len(profile.Placements) == 0 {
+			return fmt.Errorf("partition profile %s: no placements", profile.Name)
+		}
+		placements := sets.New[int]()
+		for _, start := range profile.Placements {
+			if start < 0 || start+profile.MemorySlices > c.MemorySlices {
+				return fmt.Errorf("partition profile %s: placement %d exceeds the %d memory slices", profile.Name, start, c.MemorySlices)
+			}
+			if placements.Has(start) {
+				return fmt.Errorf("partition profile %s: duplicate placement %d", profile.Name, start)
+			}
+			placements.Insert(start)
+		}
+	}
+	return nil
+}
+
+// memorySliceCounter returns the name of the counter for the n-th memory
+// slice of a GPU.
+func memorySliceCounter(n int) string {
+	return fmt.Sprintf("memory-slice-%d", n)
+}
+
+// catalogDevices returns the counter set of the GPU with the given index and
+// the devices for all placements of all partition profiles in the catalog.
+// attrs are the attributes of the GPU, which each partition inherits.
+func (p Profile) catalogDevices(index int, attrs map[resourceapi.QualifiedName]resourceapi.DeviceAttribute, model Model) (resourceapi.CounterSet, []resourceapi.Device) {
+	catalog := model.PartitionCatalog
+	memoryPerSlice := model.Memory.Value() / int64(catalog.MemorySlices)
+
+	counterSet := resourceapi.CounterSet{
+		Name: fmt.Sprintf("gpu-%d-counters", index),
+		Counters: map[string]resourceapi.Counter{
+			"memory":         {Value: *resource.NewQuantity(memoryPerSlice*int64(catalog.MemorySlices), resource.BinarySI)},
+			"compute-slices": {Value: *resource.NewQuantity(int64(catalog.ComputeSlices), resource.DecimalSI)},
+		},
+	}
+	for n := range catalog.MemorySlices {
+		counterSet.Counters[memorySliceCounter(n)] = resourceapi.Counter{Value: resource.MustParse("1")}
+	}
+
+	var devices []resourceapi.Device
+	for _, profile := range catalog.Profiles {
+		memory := *resource.NewQuantity(memoryPerSlice*int64(profile.MemorySlices), resource.BinarySI)
+		compute := *resource.NewQuantity(model.Compute.Value()*int64(profile.ComputeSlices)/int64(catalog.ComputeSlices), resource.DecimalSI)
+		full := profile.ComputeSlices == catalog.ComputeSlices && profile.MemorySlices == catalog.MemorySlices
+
+		for _, start := range profile.Placements {
+			counters := map[string]resourceapi.Counter{
+				"memory":         {Value: memory},
+				"compute-slices": {Value: *resource.NewQuantity(int64(profile.ComputeSlices), resource.DecimalSI)},
+			}
+			for n := start; n < start+profile.MemorySlices; n++ {
+				counters[memorySliceCounter(n)] = resourceapi.Counter{Value: resource.MustParse("1")}
+			}
+
+			partitionAttrs := maps.Clone(attrs)
+			partitionAttrs["partitionable"] = resourceapi.DeviceAttribute{BoolValue: ptr.To(true)}
+			partitionAttrs["partitionProfile"] = resourceapi.DeviceAttribute{StringValue: ptr.To(profile.Name)}
+			partitionAttrs["placement"] = resourceapi.DeviceAttribute{IntValue: ptr.To(int64(start))}
+			if full {
+				partitionAttrs["full"] = resourceapi.DeviceAttribute{BoolValue: ptr.To(true)}
+			}
+
+			devices = append(devices, resourceapi.Device{
+				Name:                     fmt.Sprintf("gpu-%d-%s-%d", index, profile.Name, start),
+				Attributes:               partitionAttrs,
+				AllowMultipleAllocations: ptr.To(p.allowMultipleAllocations),
+				Capacity: map[resourceapi.QualifiedName]resourceapi.DeviceCapacity{
+					"memory":  p.memoryCapacity(memory),
+					"compute": p.computeCapacity(compute),
+				},
+				ConsumesCounters: []resourceapi.DeviceCounterConsumption{
+					{
+						CounterSet: counterSet.Name,
+						Counters:   counters,
+					},
+				},
+			})
+		}
+	}
+
+	return counterSet, devices
+}
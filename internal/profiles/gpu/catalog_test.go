@@ -0,0 +1,179 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+func TestPartitionCatalogValidate(t *testing.T) {
+	tests := map[string]struct {
+		catalog     PartitionCatalog
+		expectedErr string
+	}{
+		"default": {
+			catalog: defaultPartitionCatalog(),
+		},
+		"no compute slices": {
+			catalog:     PartitionCatalog{MemorySlices: 8},
+			expectedErr: "number of compute slices must be at least 1: 0",
+		},
+		"too many memory slices": {
+			catalog:     PartitionCatalog{ComputeSlices: 7, MemorySlices: 31},
+			expectedErr: "number of memory slices must be between 1 and 30: 31",
+		},
+		"no profiles": {
+			catalog:     PartitionCatalog{ComputeSlices: 7, MemorySlices: 8},
+			expectedErr: "no partition profiles",
+		},
+		"invalid name": {
+			catalog: PartitionCatalog{ComputeSlices: 7, MemorySlices: 8, Profiles: []PartitionProfile{
+				{Name: "1g.10gb", ComputeSlices: 1, MemorySlices: 1, Placements: []int{0}},
+			}},
+			expectedErr: `invalid partition profile name "1g.10gb"`,
+		},
+		"duplicate profile": {
+			catalog: PartitionCatalog{ComputeSlices: 7, MemorySlices: 8, Profiles: []PartitionProfile{
+				{Name: "1g", ComputeSlices: 1, MemorySlices: 1, Placements: []int{0}},
+				{Name: "1g", ComputeSlices: 1, MemorySlices: 1, Placements: []int{1}},
+			}},
+			expectedErr: `duplicate partition profile "1g"`,
+		},
+		"too many compute slices": {
+			catalog: PartitionCatalog{ComputeSlices: 7, MemorySlices: 8, Profiles: []PartitionProfile{
+				{Name: "8g", ComputeSlices: 8, MemorySlices: 8, Placements: []int{0}},
+			}},
+			expectedErr: "partition profile 8g: number of compute slices must be between 1 and 7: 8",
+		},
+		"no placements": {
+			catalog: PartitionCatalog{ComputeSlices: 7, MemorySlices: 8, Profiles: []PartitionProfile{
+				{Name: "1g", ComputeSlices: 1, MemorySlices: 1},
+			}},
+			expectedErr: "partition profile 1g: no placements",
+		},
+		"placement out of range": {
+			catalog: PartitionCatalog{ComputeSlices: 7, MemorySlices: 8, Profiles: []PartitionProfile{
+				{Name: "3g", ComputeSlices: 3, MemorySlices: 4, Placements: []int{0, 5}},
+			}},
+			expectedErr: "partition profile 3g: placement 5 exceeds the 8 memory slices",
+		},
+		"duplicate placement": {
+			catalog: PartitionCatalog{ComputeSlices: 7, MemorySlices: 8, Profiles: []PartitionProfile{
+				{Name: "1g", ComputeSlices: 1, MemorySlices: 1, Placements: []int{2, 2}},
+			}},
+			expectedErr: "partition profile 1g: duplicate placement 2",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.catalog.Validate()
+			if test.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestEnumerateDevices_PartitionCatalog(t *testing.T) {
+	models, err := ParseModels(`[{"name": "mig-80Gi", "count": 2, "partitionCatalog": {}}]`)
+	require.NoError(t, err)
+
+	profile := NewProfile("test-node", 0, 0, false, false, false, "", models, profiles.Topology{}, nil)
+	resources, err := profile.EnumerateDevices()
+	require.NoError(t, err)
+
+	counterSets := make(map[string]resourceapi.CounterSet)
+	devices := make(map[string]resourceapi.Device)
+	for _, slice := range resources.Pools["test-node"].Slices {
+		for _, counterSet := range slice.SharedCounters {
+			counterSets[counterSet.Name] = counterSet
+		}
+		for _, device := range slice.Devices {
+			devices[device.Name] = device
+		}
+	}
+	require.Len(t, counterSets, 2)
+	// 7 1g, 3 2g, 2 3g, 1 4g and 1 7g partition per GPU.
+	require.Len(t, devices, 2*14)
+
+	partition := devices["gpu-1-3g-4"]
+	assert.Equal(t, "3g", *partition.Attributes["partitionProfile"].StringValue)
+	assert.Equal(t, int64(4), *partition.Attributes["placement"].IntValue)
+	assert.Equal(t, "mig-80Gi", *partition.Attributes["model"].StringValue)
+	assert.Equal(t, resource.MustParse("40Gi"), partition.Capacity["memory"].Value)
+	compute := partition.Capacity["compute"].Value
+	assert.Equal(t, int64(300/7), compute.Value())
+	assert.True(t, *devices["gpu-0-7g-0"].Attributes["full"].BoolValue)
+
+	// fits simulates the allocation of the named devices of gpu-0 and
+	// reports whether the counters allow them to be allocated together.
+	fits := func(names ...string) bool {
+		consumed := make(map[string]int64)
+		for _, name := range names {
+			device, ok := devices["gpu-0-"+name]
+			require.True(t, ok, "device gpu-0-%s", name)
+			for _, consumption := range device.ConsumesCounters {
+				require.Equal(t, "gpu-0-counters", consumption.CounterSet)
+				for counter, value := range consumption.Counters {
+					consumed[counter] += value.Value.Value()
+				}
+			}
+		}
+		for counter, value := range consumed {
+			available := counterSets["gpu-0-counters"].Counters[counter].Value
+			if value > available.Value() {
+				return false
+			}
+		}
+		return true
+	}
+
+	legal := map[string][]string{
+		"full GPU":              {"7g-0"},
+		"two 3g":                {"3g-0", "3g-4"},
+		"4g and 3g":             {"4g-0", "3g-4"},
+		"3g, 2g and 1g":         {"3g-0", "2g-4", "1g-6"},
+		"seven 1g":              {"1g-0", "1g-1", "1g-2", "1g-3", "1g-4", "1g-5", "1g-6"},
+		"three 2g and one 1g":   {"2g-0", "2g-2", "2g-4", "1g-6"},
+		"1g next to 3g":         {"1g-0", "1g-1", "1g-2", "1g-3", "3g-4"},
+		"2g and 1g on one half": {"2g-0", "1g-2", "1g-3"},
+	}
+	for name, partitions := range legal {
+		assert.True(t, fits(partitions...), "%s: %v", name, partitions)
+	}
+
+	illegal := map[string][]string{
+		"full GPU and 1g":      {"7g-0", "1g-6"},
+		"overlapping 4g, 3g":   {"4g-0", "3g-0"},
+		"overlapping 2g, 1g":   {"2g-0", "1g-1"},
+		"two 3g and 1g":        {"3g-0", "3g-4", "1g-6"},
+		"same partition twice": {"1g-3", "1g-3"},
+	}
+	for name, partitions := range illegal {
+		assert.False(t, fits(partitions...), "%s: %v", name, partitions)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpu
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultFaultTaintKey is the taint key used for a [Fault] that does not
+// specify its own.
+const DefaultFaultTaintKey = "gpu.example.com/fault"
+
+// Faults is the content of the fault-injection file. Each entry taints the
+// devices of one simulated GPU, for example:
+//
+//	faults:
+//	- device: gpu-0
+//	  effect: NoExecute
+//	  value: xid-79
+//	- device: gpu-1-partition-0
+//	  key: gpu.example.com/ecc
+//	  effect: NoSchedule
+type Faults struct {
+	Faults []Fault `json:"faults,omitempty"`
+}
+
+// Fault simulates a hardware fault which the driver reports as a device taint.
+type Fault struct {
+	// Device is the name of the faulty device. A fault on a physical GPU
+	// (e.g. "gpu-0") also applies to all of its partitions.
+	Device string `json:"device"`
+	// Key is the taint key. Defaults to [DefaultFaultTaintKey].
+	Key string `json:"key,omitempty"`
+	// Value is the taint value, e.g. a description of the fault.
+	Value string `json:"value,omitempty"`
+	// Effect is the taint effect.
+	Effect resourceapi.DeviceTaintEffect `json:"effect"`
+}
+
+// Validate ensures that a Fault has a valid set of values.
+func (f Fault) Validate() error {
+	if f.Device == "" {
+		return fmt.Errorf("no device set")
+	}
+	if f.Key != "" {
+		if errs := validation.IsQualifiedName(f.Key); len(errs) > 0 {
+			return fmt.Errorf("invalid taint key for device %s: %q: %s", f.Device, f.Key, strings.Join(errs, ", "))
+		}
+	}
+	if errs := validation.IsValidLabelValue(f.Value); len(errs) > 0 {
+		return fmt.Errorf("invalid taint value for device %s: %q: %s", f.Device, f.Value, strings.Join(errs, ", "))
+	}
+	switch f.Effect {
+	case resourceapi.DeviceTaintEffectNone, resourceapi.DeviceTaintEffectNoSchedule, resourceapi.DeviceTaintEffectNoExecute:
+	default:
+		return fmt.Errorf("unknown taint effect for device %s: %q", f.Device, f.Effect)
+	}
+	return nil
+}
+
+// appliesTo returns true if the fault affects the device with the given name.
+func (f Fault) appliesTo(device string) bool {
+	return device == f.Device || strings.HasPrefix(device, f.Device+"-")
+}
+
+func (f Fault) taint() resourceapi.DeviceTaint {
+	key := f.Key
+	if key == "" {
+		key = DefaultFaultTaintKey
+	}
+	return resourceapi.DeviceTaint{
+		Key:    key,
+		Value:  f.Value,
+		Effect: f.Effect,
+	}
+}
+
+// readFaults reads the fault-injection file at path. A missing file means
+// there are no faults.
+func readFaults(path string) (*Faults, error) {
+	faults := new(Faults)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return faults, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read fault file: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(data, faults); err != nil {
+		return nil, fmt.Errorf("decode fault file %s: %w", path, err)
+	}
+	for _, fault := range faults.Faults {
+		if err := fault.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid fault file %s: %w", path, err)
+		}
+	}
+	return faults, nil
+}
+
+// applyFaults sets the taints of all devices affected by faults.
+func applyFaults(devices []resourceapi.Device, faults *Faults) {
+	for i := range devices {
+		for _, fault := range faults.Faults {
+			if fault.appliesTo(devices[i].Name) {
+				devices[i].Taints = append(devices[i].Taints, fault.taint())
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpu
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resourceapi "k8s.io/api/resource/v1"
+
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+func TestEnumerateDevices_Faults(t *testing.T) {
+	faultFile := filepath.Join(t.TempDir(), "faults.yaml")
+	require.NoError(t, os.WriteFile(faultFile, []byte(`
+faults:
+- device: gpu-0
+  effect: NoExecute
+  value: xid-79
+- device: gpu-1-partition-1
+  key: gpu.example.com/ecc
+  effect: NoSchedule
+`), 0600))
+
+	profile := NewProfile("test-node", 11, 2, false, false, false, faultFile, nil, profiles.Topology{}, nil)
+	resources, err := profile.EnumerateDevices()
+	require.NoError(t, err)
+
+	taints := make(map[string][]resourceapi.DeviceTaint)
+	for _, slice := range resources.Pools["test-node"].Slices {
+		for _, device := range slice.Devices {
+			if len(device.Taints) > 0 {
+				taints[device.Name] = device.Taints
+			}
+		}
+	}
+
+	xid := resourceapi.DeviceTaint{Key: DefaultFaultTaintKey, Value: "xid-79", Effect: resourceapi.DeviceTaintEffectNoExecute}
+	ecc := resourceapi.DeviceTaint{Key: "gpu.example.com/ecc", Effect: resourceapi.DeviceTaintEffectNoSchedule}
+	assert.Equal(t, map[string][]resourceapi.DeviceTaint{
+		"gpu-0-partition-0": {xid},
+		"gpu-0-partition-1": {xid},
+		"gpu-0-full":        {xid},
+		"gpu-1-partition-1": {ecc},
+	}, taints)
+}
+
+func TestEnumerateDevices_FaultFileMissing(t *testing.T) {
+	profile := NewProfile("test-node", 2, 0, false, false, false, filepath.Join(t.TempDir(), "faults.yaml"), nil, profiles.Topology{}, nil)
+	resources, err := profile.EnumerateDevices()
+	require.NoError(t, err)
+
+	for _, device := range resources.Pools["test-node"].Slices[0].Devices {
+		assert.Empty(t, device.Taints, device.Name)
+	}
+}
+
+func TestEnumerateDevices_FaultFileInvalid(t *testing.T) {
+	tests := map[string]struct {
+		content     string
+		expectedErr string
+	}{
+		"unknown field": {
+			content:     "faults:\n- device: gpu-0\n  effect: NoSchedule\n  bogus: true\n",
+			expectedErr: `unknown field "bogus"`,
+		},
+		"missing device": {
+			content:     "faults:\n- effect: NoSchedule\n",
+			expectedErr: "no device set",
+		},
+		"unknown effect": {
+			content:     "faults:\n- device: gpu-0\n  effect: Evict\n",
+			expectedErr: `unknown taint effect for device gpu-0: "Evict"`,
+		},
+		"invalid key": {
+			content:     "faults:\n- device: gpu-0\n  key: gpu.example.com/ecc error\n  effect: NoSchedule\n",
+			expectedErr: `invalid taint key for device gpu-0: "gpu.example.com/ecc error"`,
+		},
+		"invalid value": {
+			content:     "faults:\n- device: gpu-0\n  value: xid 79\n  effect: NoSchedule\n",
+			expectedErr: `invalid taint value for device gpu-0: "xid 79"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			faultFile := filepath.Join(t.TempDir(), "faults.yaml")
+			require.NoError(t, os.WriteFile(faultFile, []byte(test.content), 0600))
+
+			profile := NewProfile("test-node", 1, 0, false, false, false, faultFile, nil, profiles.Topology{}, nil)
+			_, err := profile.EnumerateDevices()
+			require.ErrorContains(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestWatchDevices_Faults(t *testing.T) {
+	var _ profiles.DeviceWatcher = Profile{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Without a fault file there is nothing to watch.
+	require.NoError(t, NewProfile("test-node", 1, 0, false, false, false, "", nil, profiles.Topology{}, nil).WatchDevices(ctx, func() {}))
+
+	// The fault file does not need to exist when the driver starts.
+	faultFile := filepath.Join(t.TempDir(), "faults.yaml")
+	profile := NewProfile("test-node", 1, 0, false, false, false, faultFile, nil, profiles.Topology{}, nil)
+
+	var changes atomic.Int32
+	done := make(chan error)
+	go func() {
+		done <- profile.WatchDevices(ctx, func() { changes.Add(1) })
+	}()
+
+	attempt := 0
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		attempt++
+		content := "faults:\n- device: gpu-0\n  effect: NoSchedule\n  value: attempt-" + strconv.Itoa(attempt) + "\n"
+		require.NoError(c, os.WriteFile(faultFile, []byte(content), 0600))
+		assert.Positive(c, changes.Load())
+	}, 5*time.Second, 50*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
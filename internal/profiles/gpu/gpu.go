@@ -28,6 +28,7 @@ import (
 	resourceapi "k8s.io/api/resource/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/dynamic-resource-allocation/resourceslice"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
@@ -35,8 +36,8 @@ import (
 	cdispec "tags.cncf.io/container-device-interface/specs-go"
 
 	configapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/gpu/v1alpha1"
-	"sigs.k8s.io/dra-example-driver/internal/profiles"
-	"sigs.k8s.io/dra-example-driver/internal/profiles/helpers"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles/helpers"
 )
 
 const (
@@ -52,9 +53,17 @@ type Profile struct {
 	enableDeviceStatus       bool
 	bindingConditions        bool
 	allowMultipleAllocations bool
+	faultFile                string
+	models                   []Model
+	topology                 profiles.Topology
+	hardware                 *Hardware
 }
 
-func NewProfile(nodeName string, numGPUs int, partitionsPerGPU int, enableDeviceStatus bool, bindingConditions bool, allowMultipleAllocations bool) Profile {
+// NewProfile returns a gpu profile. When models is empty, numGPUs GPUs of
+// the same model with partitionsPerGPU partitions each are advertised.
+// Otherwise numGPUs and partitionsPerGPU are ignored in favor of models.
+// When hardware is not nil, preparing a claim configures the simulated GPUs.
+func NewProfile(nodeName string, numGPUs int, partitionsPerGPU int, enableDeviceStatus bool, bindingConditions bool, allowMultipleAllocations bool, faultFile string, models []Model, topology profiles.Topology, hardware *Hardware) Profile {
 	return Profile{
 		nodeName:                 nodeName,
 		numGPUs:                  numGPUs,
@@ -62,121 +71,140 @@ func NewProfile(nodeName string, numGPUs int, partitionsPerGPU int, enableDevice
 		enableDeviceStatus:       enableDeviceStatus,
 		bindingConditions:        bindingConditions,
 		allowMultipleAllocations: allowMultipleAllocations,
+		faultFile:                faultFile,
+		models:                   models,
+		topology:                 topology,
+		hardware:                 hardware,
 	}
 }
 
+// gpuModels returns the models of the GPUs to advertise.
+func (p Profile) gpuModels() []Model {
+	if len(p.models) > 0 {
+		return p.models
+	}
+	return []Model{defaultModel(p.numGPUs, p.partitionsPerGPU)}
+}
+
 func (p Profile) EnumerateDevices() (resourceslice.DriverResources, error) {
-	seed := p.nodeName
-	uuids := generateUUIDs(seed, p.numGPUs)
+	models := p.gpuModels()
+	numGPUs := 0
+	for _, model := range models {
+		numGPUs += model.Count
+	}
 
-	memoryPerGPU := resource.MustParse("80Gi")
-	computePerGPU := resource.MustParse("100")
+	seed := p.nodeName
+	uuids := generateUUIDs(seed, numGPUs)
 
 	var devices []resourceapi.Device
 	var sharedCounters []resourceapi.CounterSet
 
-	var partitionMemory, partitionCompute resource.Quantity
-	if p.partitionsPerGPU > 0 {
-		partitionMemory = *resource.NewQuantity(memoryPerGPU.Value()/int64(p.partitionsPerGPU), resource.BinarySI)
-		partitionCompute = *resource.NewQuantity(computePerGPU.Value()/int64(p.partitionsPerGPU), resource.DecimalSI)
-	}
-
-	for i, uuid := range uuids {
-		attrs := map[resourceapi.QualifiedName]resourceapi.DeviceAttribute{
-			"index": {
-				IntValue: ptr.To(int64(i)),
-			},
-			"uuid": {
-				StringValue: ptr.To(uuid),
-			},
-			"model": {
-				StringValue: ptr.To("LATEST-GPU-MODEL"),
-			},
-			"driverVersion": {
-				VersionValue: ptr.To("1.0.0"),
-			},
+	// GPUs are numbered across all models, in the order in which the models
+	// are listed.
+	i := 0
+	for _, model := range models {
+		memoryPerGPU := *model.Memory
+		computePerGPU := *model.Compute
+
+		var partitionMemory, partitionCompute resource.Quantity
+		if model.Partitions > 0 {
+			partitionMemory = *resource.NewQuantity(memoryPerGPU.Value()/int64(model.Partitions), resource.BinarySI)
+			partitionCompute = *resource.NewQuantity(computePerGPU.Value()/int64(model.Partitions), resource.DecimalSI)
 		}
 
-		if p.partitionsPerGPU > 0 {
-			counterSetName := fmt.Sprintf("gpu-%d-counters", i)
-			sharedCounters = append(sharedCounters, resourceapi.CounterSet{
-				Name: counterSetName,
-				Counters: map[string]resourceapi.Counter{
-					"memory": {
-						Value: memoryPerGPU,
-					},
-					"compute": {
-						Value: computePerGPU,
+		for range model.Count {
+			attrs := p.topology.Attributes(i, numGPUs)
+			attrs["index"] = resourceapi.DeviceAttribute{IntValue: ptr.To(int64(i))}
+			attrs["uuid"] = resourceapi.DeviceAttribute{StringValue: ptr.To(uuids[i])}
+			attrs["model"] = resourceapi.DeviceAttribute{StringValue: ptr.To(model.Name)}
+			attrs["driverVersion"] = resourceapi.DeviceAttribute{VersionValue: ptr.To(model.DriverVersion)}
+
+			if model.PartitionCatalog != nil {
+				counterSet, partitions := p.catalogDevices(i, attrs, model)
+				sharedCounters = append(sharedCounters, counterSet)
+				devices = append(devices, partitions...)
+			} else if model.Partitions > 0 {
+				counterSetName := fmt.Sprintf("gpu-%d-counters", i)
+				sharedCounters = append(sharedCounters, resourceapi.CounterSet{
+					Name: counterSetName,
+					Counters: map[string]resourceapi.Counter{
+						"memory": {
+							Value: memoryPerGPU,
+						},
+						"compute": {
+							Value: computePerGPU,
+						},
 					},
-				},
-			})
+				})
 
-			for j := 0; j < p.partitionsPerGPU; j++ {
-				partitionAttrs := maps.Clone(attrs)
-				partitionAttrs["partition"] = resourceapi.DeviceAttribute{IntValue: ptr.To(int64(j))}
-				partitionAttrs["partitionable"] = resourceapi.DeviceAttribute{BoolValue: ptr.To(true)}
+				for j := 0; j < model.Partitions; j++ {
+					partitionAttrs := maps.Clone(attrs)
+					partitionAttrs["partition"] = resourceapi.DeviceAttribute{IntValue: ptr.To(int64(j))}
+					partitionAttrs["partitionable"] = resourceapi.DeviceAttribute{BoolValue: ptr.To(true)}
+
+					devices = append(devices, resourceapi.Device{
+						Name:                     fmt.Sprintf("gpu-%d-partition-%d", i, j),
+						Attributes:               partitionAttrs,
+						AllowMultipleAllocations: ptr.To(p.allowMultipleAllocations),
+						Capacity: map[resourceapi.QualifiedName]resourceapi.DeviceCapacity{
+							"memory":  p.memoryCapacity(partitionMemory),
+							"compute": p.computeCapacity(partitionCompute),
+						},
+						ConsumesCounters: []resourceapi.DeviceCounterConsumption{
+							{
+								CounterSet: counterSetName,
+								Counters: map[string]resourceapi.Counter{
+									"memory": {
+										Value: partitionMemory,
+									},
+									"compute": {
+										Value: partitionCompute,
+									},
+								},
+							},
+						},
+					})
+				}
+
+				// Full GPU device that consumes all resources from the counter set
+				fullAttrs := maps.Clone(attrs)
+				fullAttrs["partitionable"] = resourceapi.DeviceAttribute{BoolValue: ptr.To(true)}
+				fullAttrs["full"] = resourceapi.DeviceAttribute{BoolValue: ptr.To(true)}
 
 				devices = append(devices, resourceapi.Device{
-					Name:                     fmt.Sprintf("gpu-%d-partition-%d", i, j),
-					Attributes:               partitionAttrs,
+					Name:                     fmt.Sprintf("gpu-%d-full", i),
+					Attributes:               fullAttrs,
 					AllowMultipleAllocations: ptr.To(p.allowMultipleAllocations),
 					Capacity: map[resourceapi.QualifiedName]resourceapi.DeviceCapacity{
-						"memory":  p.memoryCapacity(partitionMemory),
-						"compute": p.computeCapacity(partitionCompute),
+						"memory":  p.memoryCapacity(memoryPerGPU),
+						"compute": p.computeCapacity(computePerGPU),
 					},
 					ConsumesCounters: []resourceapi.DeviceCounterConsumption{
 						{
 							CounterSet: counterSetName,
 							Counters: map[string]resourceapi.Counter{
 								"memory": {
-									Value: partitionMemory,
+									Value: memoryPerGPU,
 								},
 								"compute": {
-									Value: partitionCompute,
+									Value: computePerGPU,
 								},
 							},
 						},
 					},
 				})
-			}
-
-			// Full GPU device that consumes all resources from the counter set
-			fullAttrs := maps.Clone(attrs)
-			fullAttrs["partitionable"] = resourceapi.DeviceAttribute{BoolValue: ptr.To(true)}
-			fullAttrs["full"] = resourceapi.DeviceAttribute{BoolValue: ptr.To(true)}
-
-			devices = append(devices, resourceapi.Device{
-				Name:                     fmt.Sprintf("gpu-%d-full", i),
-				Attributes:               fullAttrs,
-				AllowMultipleAllocations: ptr.To(p.allowMultipleAllocations),
-				Capacity: map[resourceapi.QualifiedName]resourceapi.DeviceCapacity{
-					"memory":  p.memoryCapacity(memoryPerGPU),
-					"compute": p.computeCapacity(computePerGPU),
-				},
-				ConsumesCounters: []resourceapi.DeviceCounterConsumption{
-					{
-						CounterSet: counterSetName,
-						Counters: map[string]resourceapi.Counter{
-							"memory": {
-								Value: memoryPerGPU,
-							},
-							"compute": {
-								Value: computePerGPU,
-							},
-						},
+			} else {
+				devices = append(devices, resourceapi.Device{
+					Name:                     fmt.Sprintf("gpu-%d", i),
+					Attributes:               attrs,
+					AllowMultipleAllocations: ptr.To(p.allowMultipleAllocations),
+					Capacity: map[resourceapi.QualifiedName]resourceapi.DeviceCapacity{
+						"memory":  p.memoryCapacity(memoryPerGPU),
+						"compute": p.computeCapacity(computePerGPU),
 					},
-				},
-			})
-		} else {
-			devices = append(devices, resourceapi.Device{
-				Name:                     fmt.Sprintf("gpu-%d", i),
-				Attributes:               attrs,
-				AllowMultipleAllocations: ptr.To(p.allowMultipleAllocations),
-				Capacity: map[resourceapi.QualifiedName]resourceapi.DeviceCapacity{
-					"memory":  p.memoryCapacity(memoryPerGPU),
-					"compute": p.computeCapacity(computePerGPU),
-				},
-			})
+				})
+			}
+			i++
 		}
 	}
 
@@ -187,18 +215,18 @@ func (p Profile) EnumerateDevices() (resourceslice.DriverResources, error) {
 		}
 	}
 
-	slices := []resourceslice.Slice{{Devices: devices}}
-	if len(sharedCounters) > 0 {
-		slices = []resourceslice.Slice{
-			{SharedCounters: sharedCounters},
-			{Devices: devices},
+	if p.faultFile != "" {
+		faults, err := readFaults(p.faultFile)
+		if err != nil {
+			return resourceslice.DriverResources{}, err
 		}
+		applyFaults(devices, faults)
 	}
 
 	resources := resourceslice.DriverResources{
 		Pools: map[string]resourceslice.Pool{
 			p.nodeName: {
-				Slices: slices,
+				Slices: helpers.PoolSlices(sharedCounters, devices),
 			},
 		},
 	}
@@ -206,6 +234,16 @@ func (p Profile) EnumerateDevices() (resourceslice.DriverResources, error) {
 	return resources, nil
 }
 
+// WatchDevices implements [profiles.DeviceWatcher]. When a fault file is
+// configured, onChange is called whenever its content changes so that the
+// resulting device taints get published.
+func (p Profile) WatchDevices(ctx context.Context, onChange func()) error {
+	if p.faultFile == "" {
+		return nil
+	}
+	return helpers.WatchFile(ctx, p.faultFile, onChange)
+}
+
 // memoryCapacity builds a DeviceCapacity for a memory quantity.
 // When allowMultipleAllocations is enabled it attaches a RequestPolicy with
 // ValidRange{Min: 1Gi, Step: 1Gi, Max: value} so consumers can request any
@@ -313,10 +351,10 @@ func envVarSafeID(id string) string {
 	return strings.ToUpper(strings.ReplaceAll(id, "-", "_"))
 }
 
-// In this example driver there is no actual configuration applied. We simply
-// define a set of environment variables to be injected into the containers
-// that include a given device. A real driver would likely need to do some sort
-// of hardware configuration as well, based on the config passed in.
+// In this example driver the config is only applied to the containers. We
+// simply define a set of environment variables to be injected into the
+// containers that include a given device. The hardware configuration a real
+// driver would do is simulated by [Profile.ConfigureDevice].
 func applyGpuConfig(config *configapi.GpuConfig, results []*resourceapi.DeviceRequestAllocationResult) (profiles.PerDeviceCDIContainerEdits, error) {
 	perDeviceEdits := make(profiles.PerDeviceCDIContainerEdits)
 
@@ -331,7 +369,7 @@ func applyGpuConfig(config *configapi.GpuConfig, results []*resourceapi.DeviceRe
 	}
 
 	for _, result := range results {
-		// Device names are prefixed with "gpu-" (e.g. "gpu-0", "gpu-0-partition-1", "gpu-0-full").
+		// Device names are prefixed with "gpu-" (e.g. "gpu-0", "gpu-0-partition-1", "gpu-0-full", "gpu-0-3g-4").
 		envID := envVarSafeID(result.Device[4:])
 		envs := []string{
 			fmt.Sprintf("GPU_DEVICE_%s=%s", envID, result.Device),
@@ -376,11 +414,62 @@ func applyGpuConfig(config *configapi.GpuConfig, results []*resourceapi.DeviceRe
 	return perDeviceEdits, nil
 }
 
+// SharedConfig implements [profiles.SharedConfigHandler]. Claims using the
+// same GPU must agree on its sharing mode.
+func (p Profile) SharedConfig(config runtime.Object) (string, error) {
+	if config == nil {
+		config = configapi.DefaultGpuConfig()
+	}
+	gpuConfig, ok := config.(*configapi.GpuConfig)
+	if !ok {
+		return "", fmt.Errorf("runtime object is not a recognized configuration")
+	}
+	gpuConfig = gpuConfig.DeepCopy()
+	if err := gpuConfig.Normalize(); err != nil {
+		return "", fmt.Errorf("error normalizing GPU config: %w", err)
+	}
+	return sharingMode(gpuConfig.Sharing), nil
+}
+
+// ConfigureDevice implements [profiles.DeviceConfigurer]. It configures the
+// simulated GPU into the sharing mode of config. GPUs allocated with admin
+// access are only observed and keep their mode.
+func (p Profile) ConfigureDevice(ctx context.Context, claimUID types.UID, config runtime.Object, result *resourceapi.DeviceRequestAllocationResult) error {
+	if p.hardware == nil || ptr.Deref(result.AdminAccess, false) {
+		return nil
+	}
+	if config == nil {
+		config = configapi.DefaultGpuConfig()
+	}
+	gpuConfig, ok := config.(*configapi.GpuConfig)
+	if !ok {
+		return fmt.Errorf("runtime object is not a recognized configuration")
+	}
+	gpuConfig = gpuConfig.DeepCopy()
+	if err := gpuConfig.Normalize(); err != nil {
+		return fmt.Errorf("error normalizing GPU config: %w", err)
+	}
+
+	if err := p.hardware.Configure(result.Pool, result.Device, claimUID, gpuConfig.Sharing); err != nil {
+		return err
+	}
+	klog.FromContext(ctx).V(4).Info("Configured GPU", "device", result.Device, "claim", claimUID, "mode", sharingMode(gpuConfig.Sharing))
+	return nil
+}
+
+// ReleaseDevices implements [profiles.DeviceConfigurer].
+func (p Profile) ReleaseDevices(ctx context.Context, claimUID types.UID) error {
+	if p.hardware == nil {
+		return nil
+	}
+	return p.hardware.Release(claimUID)
+}
+
 // BuildDeviceStatus implements [profiles.DeviceStatusBuilder]. It returns an
 // [resourceapi.AllocatedDeviceStatus] populated with a subset of the device's
 // attributes (uuid, model, driverVersion) to publish into
 // ResourceClaim.status.devices[].data.
-func (p Profile) BuildDeviceStatus(allocatable map[string]resourceapi.Device, result *resourceapi.DeviceRequestAllocationResult) *resourceapi.AllocatedDeviceStatus {
+func (p Profile) BuildDeviceStatus(allocatable map[string]resourceapi.Device, config runtime.Object, result *resourceapi.DeviceRequestAllocationResult) *resourceapi.AllocatedDeviceStatus {
 	if !p.enableDeviceStatus {
 		return nil
 	}
@@ -410,9 +499,10 @@ func (p Profile) BuildDeviceStatus(allocatable map[string]resourceapi.Device, re
 	//     example, because it becomes unhealthy), so past allocations can
 	//     still be correlated with later health or scheduling issues.
 	return &resourceapi.AllocatedDeviceStatus{
-		Device: result.Device,
-		Driver: result.Driver,
-		Pool:   result.Pool,
-		Data:   &runtime.RawExtension{Raw: jsonBytes},
+		Device:  result.Device,
+		Driver:  result.Driver,
+		Pool:    result.Pool,
+		ShareID: (*string)(result.ShareID),
+		Data:    &runtime.RawExtension{Raw: jsonBytes},
 	}
 }
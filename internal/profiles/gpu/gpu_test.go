@@ -29,11 +29,11 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
 
-	"sigs.k8s.io/dra-example-driver/internal/profiles"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
 )
 
 func TestNewProfile(t *testing.T) {
-	profile := NewProfile("test-node", 4, 0, false, false, false)
+	profile := NewProfile("test-node", 4, 0, false, false, false, "", nil, profiles.Topology{}, nil)
 
 	assert.Equal(t, "test-node", profile.nodeName)
 	assert.Equal(t, 4, profile.numGPUs)
@@ -44,7 +44,7 @@ func TestNewProfile(t *testing.T) {
 }
 
 func TestNewProfile_WithAllOptions(t *testing.T) {
-	profile := NewProfile("test-node", 2, 4, false, true, true)
+	profile := NewProfile("test-node", 2, 4, false, true, true, "", nil, profiles.Topology{}, nil)
 
 	assert.Equal(t, "test-node", profile.nodeName)
 	assert.Equal(t, 2, profile.numGPUs)
@@ -54,7 +54,7 @@ func TestNewProfile_WithAllOptions(t *testing.T) {
 }
 
 func TestEnumerateDevices_Standard(t *testing.T) {
-	profile := NewProfile("test-node", 2, 0, false, false, false)
+	profile := NewProfile("test-node", 2, 0, false, false, false, "", nil, profiles.Topology{}, nil)
 
 	resources, err := profile.EnumerateDevices()
 	require.NoError(t, err)
@@ -107,7 +107,7 @@ func TestEnumerateDevices_Standard(t *testing.T) {
 }
 
 func TestEnumerateDevices_AllowMultipleAllocations(t *testing.T) {
-	profile := NewProfile("test-node", 1, 0, false, false, true)
+	profile := NewProfile("test-node", 1, 0, false, false, true, "", nil, profiles.Topology{}, nil)
 
 	resources, err := profile.EnumerateDevices()
 	require.NoError(t, err)
@@ -139,7 +139,7 @@ func TestEnumerateDevices_AllowMultipleAllocations(t *testing.T) {
 }
 
 func TestEnumerateDevices_Partitionable(t *testing.T) {
-	profile := NewProfile("test-node", 2, 4, false, false, false)
+	profile := NewProfile("test-node", 2, 4, false, false, false, "", nil, profiles.Topology{}, nil)
 
 	resources, err := profile.EnumerateDevices()
 	require.NoError(t, err)
@@ -185,7 +185,7 @@ func TestEnumerateDevices_Partitionable(t *testing.T) {
 }
 
 func TestEnumerateDevices_PartitionableDeviceAttributes(t *testing.T) {
-	profile := NewProfile("test-node", 1, 2, false, false, false)
+	profile := NewProfile("test-node", 1, 2, false, false, false, "", nil, profiles.Topology{}, nil)
 
 	resources, err := profile.EnumerateDevices()
 	require.NoError(t, err)
@@ -236,8 +236,50 @@ func TestEnumerateDevices_PartitionableDeviceAttributes(t *testing.T) {
 	assert.Equal(t, resource.MustParse("100"), fullDevice.ConsumesCounters[0].Counters["compute"].Value)
 }
 
+func TestEnumerateDevices_LargePartitionedNode(t *testing.T) {
+	profile := NewProfile("test-node", 64, 4, false, false, false, "", nil, profiles.Topology{}, nil)
+
+	resources, err := profile.EnumerateDevices()
+	require.NoError(t, err)
+
+	counterSets := make(map[string]int)
+	devices := make(map[string]int)
+	for i, slice := range resources.Pools["test-node"].Slices {
+		// Only one of Devices and SharedCounters may be set in a slice.
+		if len(slice.SharedCounters) > 0 {
+			assert.Empty(t, slice.Devices)
+		}
+		assert.LessOrEqual(t, len(slice.SharedCounters), resourceapi.ResourceSliceMaxCounterSets)
+		assert.LessOrEqual(t, len(slice.Devices), resourceapi.ResourceSliceMaxDevicesWithAdvancedFeatures)
+		for _, counterSet := range slice.SharedCounters {
+			counterSets[counterSet.Name] = i
+		}
+		for _, device := range slice.Devices {
+			devices[device.Name] = i
+		}
+	}
+	assert.Len(t, counterSets, 64)
+	assert.Len(t, devices, 64*5)
+
+	// Every referenced counter set is published, and the devices of one GPU
+	// end up in the same slice.
+	for _, slice := range resources.Pools["test-node"].Slices {
+		for _, device := range slice.Devices {
+			for _, consumption := range device.ConsumesCounters {
+				assert.Contains(t, counterSets, consumption.CounterSet)
+			}
+		}
+	}
+	for i := range 64 {
+		full := devices[fmt.Sprintf("gpu-%d-full", i)]
+		for j := range 4 {
+			assert.Equal(t, full, devices[fmt.Sprintf("gpu-%d-partition-%d", i, j)])
+		}
+	}
+}
+
 func TestEnumerateDevices_AllowMultipleAllocations_AndPartitions(t *testing.T) {
-	profile := NewProfile("test-node", 1, 2, false, false, true)
+	profile := NewProfile("test-node", 1, 2, false, false, true, "", nil, profiles.Topology{}, nil)
 
 	resources, err := profile.EnumerateDevices()
 	require.NoError(t, err)
@@ -317,10 +359,28 @@ func TestEnumerateDevices_AllowMultipleAllocations_AndPartitions(t *testing.T) {
 	assert.Equal(t, "gpu-0-counters", fullDevice.ConsumesCounters[0].CounterSet)
 }
 
+func TestEnumerateDevices_Topology(t *testing.T) {
+	topology := profiles.Topology{NUMANodes: 2, PCIeRootsPerNUMANode: 1, IslandSize: 2}
+	profile := NewProfile("test-node", 4, 2, false, false, false, "", nil, topology, nil)
+
+	resources, err := profile.EnumerateDevices()
+	require.NoError(t, err)
+
+	// Partitions and full devices inherit the topology of their GPU.
+	for _, slice := range resources.Pools["test-node"].Slices {
+		for _, device := range slice.Devices {
+			index := int(*device.Attributes["index"].IntValue)
+			for name, value := range topology.Attributes(index, 4) {
+				assert.Equal(t, value, device.Attributes[name], "%s: %s", device.Name, name)
+			}
+		}
+	}
+}
+
 func TestEnumerateDevices_ConsistentUUIDs(t *testing.T) {
 	// UUIDs should be consistent for the same node name
-	profile1 := NewProfile("test-node", 2, 0, false, false, false)
-	profile2 := NewProfile("test-node", 2, 0, false, false, false)
+	profile1 := NewProfile("test-node", 2, 0, false, false, false, "", nil, profiles.Topology{}, nil)
+	profile2 := NewProfile("test-node", 2, 0, false, false, false, "", nil, profiles.Topology{}, nil)
 
 	resources1, err := profile1.EnumerateDevices()
 	require.NoError(t, err)
@@ -338,8 +398,8 @@ func TestEnumerateDevices_ConsistentUUIDs(t *testing.T) {
 }
 
 func TestEnumerateDevices_DifferentNodesHaveDifferentUUIDs(t *testing.T) {
-	profile1 := NewProfile("node-1", 1, 0, false, false, false)
-	profile2 := NewProfile("node-2", 1, 0, false, false, false)
+	profile1 := NewProfile("node-1", 1, 0, false, false, false, "", nil, profiles.Topology{}, nil)
+	profile2 := NewProfile("node-2", 1, 0, false, false, false, "", nil, profiles.Topology{}, nil)
 
 	resources1, err := profile1.EnumerateDevices()
 	require.NoError(t, err)
@@ -353,9 +413,9 @@ func TestEnumerateDevices_DifferentNodesHaveDifferentUUIDs(t *testing.T) {
 }
 
 func TestBuildDeviceStatus_Disabled(t *testing.T) {
-	var _ profiles.DeviceStatusBuilder = NewProfile("test-node", 1, 0, true, false, false)
+	var _ profiles.DeviceStatusBuilder = NewProfile("test-node", 1, 0, true, false, false, "", nil, profiles.Topology{}, nil)
 
-	profile := NewProfile("test-node", 1, 0, false, false, false)
+	profile := NewProfile("test-node", 1, 0, false, false, false, "", nil, profiles.Topology{}, nil)
 	allocatable := map[string]resourceapi.Device{
 		"gpu-0": {Name: "gpu-0"},
 	}
@@ -365,12 +425,12 @@ func TestBuildDeviceStatus_Disabled(t *testing.T) {
 		Pool:   "test-node",
 	}
 
-	got := profile.BuildDeviceStatus(allocatable, result)
+	got := profile.BuildDeviceStatus(allocatable, nil, result)
 	assert.Nil(t, got, "BuildDeviceStatus must return nil when device status is disabled")
 }
 
 func TestBuildDeviceStatus_Enabled(t *testing.T) {
-	profile := NewProfile("test-node", 1, 0, true, false, false)
+	profile := NewProfile("test-node", 1, 0, true, false, false, "", nil, profiles.Topology{}, nil)
 	allocatable := map[string]resourceapi.Device{
 		"gpu-0": {
 			Name: "gpu-0",
@@ -389,7 +449,7 @@ func TestBuildDeviceStatus_Enabled(t *testing.T) {
 		Pool:   "test-node",
 	}
 
-	got := profile.BuildDeviceStatus(allocatable, result)
+	got := profile.BuildDeviceStatus(allocatable, nil, result)
 	require.NotNil(t, got)
 	assert.Equal(t, "gpu-0", got.Device)
 	assert.Equal(t, "gpu.example.com", got.Driver)
@@ -406,7 +466,7 @@ func TestBuildDeviceStatus_Enabled(t *testing.T) {
 }
 
 func TestBuildDeviceStatus_UnknownDevice(t *testing.T) {
-	profile := NewProfile("test-node", 1, 0, true, false, false)
+	profile := NewProfile("test-node", 1, 0, true, false, false, "", nil, profiles.Topology{}, nil)
 	result := &resourceapi.DeviceRequestAllocationResult{
 		Device: "gpu-0",
 		Driver: "gpu.example.com",
@@ -415,7 +475,7 @@ func TestBuildDeviceStatus_UnknownDevice(t *testing.T) {
 
 	// No matching entry in allocatable; we should still return a status object
 	// (Driver/Pool/Device are stamped) with an empty data map.
-	got := profile.BuildDeviceStatus(map[string]resourceapi.Device{}, result)
+	got := profile.BuildDeviceStatus(map[string]resourceapi.Device{}, nil, result)
 	require.NotNil(t, got)
 	require.NotNil(t, got.Data)
 
@@ -425,7 +485,7 @@ func TestBuildDeviceStatus_UnknownDevice(t *testing.T) {
 }
 
 func TestApplyConfig(t *testing.T) {
-	profile := NewProfile("test-node", 2, 0, false, false, false)
+	profile := NewProfile("test-node", 2, 0, false, false, false, "", nil, profiles.Topology{}, nil)
 
 	tests := []struct {
 		name     string
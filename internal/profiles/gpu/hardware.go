@@ -0,0 +1,210 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpu
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	configapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/gpu/v1alpha1"
+)
+
+// HardwareFile is the name of the file in the driver's state directory in
+// which the simulated GPU hardware is persisted.
+const HardwareFile = "gpu-hardware.json"
+
+// Hardware simulates the GPUs of a node. Preparing a claim configures its
+// GPUs into the sharing mode required by the claim's config. The mode of each
+// GPU and the claims using it are persisted in a JSON file, so that they
+// survive restarts of the driver like the state of real hardware would and
+// can be inspected on the node, for example:
+//
+//	{
+//	  "devices": {
+//	    "test-node/gpu-0": {
+//	      "sharing": {
+//	        "strategy": "TimeSlicing",
+//	        "timeSlicingConfig": {"interval": "Long"}
+//	      },
+//	      "claims": ["6c3f4f0e-..."]
+//	    }
+//	  }
+//	}
+type Hardware struct {
+	mu   sync.Mutex
+	path string
+}
+
+// HardwareState is the content of the hardware file.
+type HardwareState struct {
+	// Devices holds the state of each GPU which was ever configured, keyed
+	// by "<pool>/<device>" since GPUs of different pools, like the node's
+	// own pool and a pool of network-attached GPUs, may have the same name.
+	Devices map[string]DeviceHardwareState `json:"devices,omitempty"`
+}
+
+// DeviceHardwareState is the simulated state of a GPU.
+type DeviceHardwareState struct {
+	// Sharing is the sharing mode the GPU is currently configured in. The
+	// mode is kept when the GPU is no longer in use.
+	Sharing *configapi.GpuSharing `json:"sharing,omitempty"`
+	// Claims lists the claims using the GPU.
+	Claims []types.UID `json:"claims,omitempty"`
+}
+
+// NewHardware returns simulated GPUs persisted in the file at path. The file
+// is created on the first change.
+func NewHardware(path string) *Hardware {
+	return &Hardware{path: path}
+}
+
+// State reads back the current state of the GPUs.
+func (h *Hardware) State() (*HardwareState, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.read()
+}
+
+// Configure configures a GPU of a pool into the given sharing mode for a
+// claim and records that the claim uses it. Claims sharing a GPU must agree
+// on its mode, which the driver checks before configuring the GPU.
+// Configuring a GPU again for the same claim and mode has no effect.
+func (h *Hardware) Configure(pool, device string, claimUID types.UID, sharing *configapi.GpuSharing) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state, err := h.read()
+	if err != nil {
+		return err
+	}
+	key := HardwareKey(pool, device)
+	current := state.Devices[key]
+	inUse := slices.Contains(current.Claims, claimUID)
+	if inUse && equality.Semantic.DeepEqual(current.Sharing, sharing) {
+		return nil
+	}
+
+	claims := current.Claims
+	if !inUse {
+		claims = append(slices.Clone(claims), claimUID)
+	}
+	if state.Devices == nil {
+		state.Devices = make(map[string]DeviceHardwareState)
+	}
+	state.Devices[key] = DeviceHardwareState{
+		Sharing: sharing.DeepCopy(),
+		Claims:  claims,
+	}
+	return h.write(state)
+}
+
+// Release removes a claim from all GPUs it is using. The GPUs stay in their
+// current mode.
+func (h *Hardware) Release(claimUID types.UID) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state, err := h.read()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for name, device := range state.Devices {
+		if !slices.Contains(device.Claims, claimUID) {
+			continue
+		}
+		device.Claims = slices.DeleteFunc(device.Claims, func(uid types.UID) bool { return uid == claimUID })
+		state.Devices[name] = device
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return h.write(state)
+}
+
+// HardwareKey returns the key of a GPU of a pool in [HardwareState].
+func HardwareKey(pool, device string) string {
+	return pool + "/" + device
+}
+
+// read reads the hardware file. A missing file means that no GPU was
+// configured yet.
+func (h *Hardware) read() (*HardwareState, error) {
+	state := new(HardwareState)
+	data, err := os.ReadFile(h.path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read hardware file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("decode hardware file %s: %w", h.path, err)
+	}
+	return state, nil
+}
+
+// write atomically replaces the hardware file with state.
+func (h *Hardware) write(state *HardwareState) (err error) {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode hardware state: %w", err)
+	}
+	dir := filepath.Dir(h.path)
+	tmp, err := os.CreateTemp(dir, "tmp-hardware-*")
+	if err != nil {
+		return fmt.Errorf("create temp file in %s: %w", dir, err)
+	}
+	defer func() {
+		if err1 := tmp.Close(); err1 != nil && err == nil {
+			err = fmt.Errorf("close temp file: %w", err1)
+		}
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("write temp file %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), h.path); err != nil {
+		return fmt.Errorf("rename %s to %s: %w", tmp.Name(), h.path, err)
+	}
+	return nil
+}
+
+// sharingMode describes a sharing mode in a human-readable form.
+func sharingMode(sharing *configapi.GpuSharing) string {
+	switch {
+	case sharing.IsTimeSlicing() && sharing.TimeSlicingConfig != nil:
+		return fmt.Sprintf("%s with %s interval", sharing.Strategy, sharing.TimeSlicingConfig.Interval)
+	case sharing.IsSpacePartitioning() && sharing.SpacePartitioningConfig != nil:
+		return fmt.Sprintf("%s into %d partitions", sharing.Strategy, sharing.SpacePartitioningConfig.PartitionCount)
+	case sharing != nil:
+		return string(sharing.Strategy)
+	default:
+		return "unconfigured"
+	}
+}
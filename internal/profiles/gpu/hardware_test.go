@@ -0,0 +1,158 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpu
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	configapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/gpu/v1alpha1"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+func timeSlicing(interval configapi.TimeSliceInterval) *configapi.GpuSharing {
+	return &configapi.GpuSharing{
+		Strategy:          configapi.TimeSlicingStrategy,
+		TimeSlicingConfig: &configapi.TimeSlicingConfig{Interval: interval},
+	}
+}
+
+func spacePartitioning(partitions int) *configapi.GpuSharing {
+	return &configapi.GpuSharing{
+		Strategy:                configapi.SpacePartitioningStrategy,
+		SpacePartitioningConfig: &configapi.SpacePartitioningConfig{PartitionCount: partitions},
+	}
+}
+
+func TestHardware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HardwareFile)
+	hardware := NewHardware(path)
+
+	state, err := hardware.State()
+	require.NoError(t, err)
+	assert.Empty(t, state.Devices, "no GPU is configured before the first change")
+
+	require.NoError(t, hardware.Configure("test-node", "gpu-0", "claim-a", timeSlicing(configapi.LongTimeSlice)))
+	require.NoError(t, hardware.Configure("test-node", "gpu-0", "claim-a", timeSlicing(configapi.LongTimeSlice)), "configuring again must succeed")
+	require.NoError(t, hardware.Configure("test-node", "gpu-0", "claim-b", timeSlicing(configapi.LongTimeSlice)), "a GPU can be shared in the same mode")
+	require.NoError(t, hardware.Configure("test-node", "gpu-1", "claim-c", spacePartitioning(4)))
+
+	require.NoError(t, hardware.Configure("fabric-a", "gpu-0", "claim-d", spacePartitioning(2)), "a GPU of another pool with the same name is a different GPU")
+
+	// The state is read back from disk.
+	state, err = NewHardware(path).State()
+	require.NoError(t, err)
+	assert.Equal(t, &HardwareState{Devices: map[string]DeviceHardwareState{
+		"test-node/gpu-0": {Sharing: timeSlicing(configapi.LongTimeSlice), Claims: []types.UID{"claim-a", "claim-b"}},
+		"test-node/gpu-1": {Sharing: spacePartitioning(4), Claims: []types.UID{"claim-c"}},
+		"fabric-a/gpu-0":  {Sharing: spacePartitioning(2), Claims: []types.UID{"claim-d"}},
+	}}, state)
+
+	require.NoError(t, hardware.Release("claim-a"))
+	require.NoError(t, hardware.Configure("test-node", "gpu-0", "claim-b", spacePartitioning(2)), "a claim using a GPU can change its mode")
+	require.NoError(t, hardware.Release("claim-b"))
+	require.NoError(t, hardware.Release("claim-b"), "releasing again must succeed")
+
+	state, err = hardware.State()
+	require.NoError(t, err)
+	assert.Equal(t, DeviceHardwareState{Sharing: spacePartitioning(2)}, state.Devices["test-node/gpu-0"], "an unused GPU keeps its mode")
+}
+
+func TestConfigureDevice(t *testing.T) {
+	hardware := NewHardware(filepath.Join(t.TempDir(), HardwareFile))
+	profile := NewProfile("test-node", 2, 0, false, false, false, "", nil, profiles.Topology{}, hardware)
+	var _ profiles.DeviceConfigurer = profile
+	ctx := context.Background()
+
+	devices := func() map[string]DeviceHardwareState {
+		state, err := hardware.State()
+		require.NoError(t, err)
+		return state.Devices
+	}
+
+	// Without config, the GPU is configured into the default mode.
+	require.NoError(t, profile.ConfigureDevice(ctx, "claim-a", nil, &resourceapi.DeviceRequestAllocationResult{Pool: "test-node", Device: "gpu-0"}))
+	// The config is normalized before being applied.
+	config := &configapi.GpuConfig{Sharing: &configapi.GpuSharing{Strategy: configapi.SpacePartitioningStrategy}}
+	require.NoError(t, profile.ConfigureDevice(ctx, "claim-b", config, &resourceapi.DeviceRequestAllocationResult{Pool: "test-node", Device: "gpu-1"}))
+	assert.Nil(t, config.Sharing.SpacePartitioningConfig, "the config of the claim must not be modified")
+	// Admin access does not change the mode of a GPU in use.
+	config = &configapi.GpuConfig{Sharing: spacePartitioning(2)}
+	require.NoError(t, profile.ConfigureDevice(ctx, "claim-c", config, &resourceapi.DeviceRequestAllocationResult{Pool: "test-node", Device: "gpu-0", AdminAccess: ptr.To(true)}))
+	assert.Equal(t, map[string]DeviceHardwareState{
+		"test-node/gpu-0": {Sharing: timeSlicing(configapi.DefaultTimeSlice), Claims: []types.UID{"claim-a"}},
+		"test-node/gpu-1": {Sharing: spacePartitioning(1), Claims: []types.UID{"claim-b"}},
+	}, devices())
+
+	require.NoError(t, profile.ReleaseDevices(ctx, "claim-a"))
+	require.NoError(t, profile.ReleaseDevices(ctx, "claim-b"))
+	assert.Equal(t, map[string]DeviceHardwareState{
+		"test-node/gpu-0": {Sharing: timeSlicing(configapi.DefaultTimeSlice)},
+		"test-node/gpu-1": {Sharing: spacePartitioning(1)},
+	}, devices())
+}
+
+func TestSharedConfig(t *testing.T) {
+	var _ profiles.SharedConfigHandler = Profile{}
+
+	tests := map[string]struct {
+		config      runtime.Object
+		expected    string
+		expectedErr string
+	}{
+		"default config": {
+			config:   nil,
+			expected: "TimeSlicing with Default interval",
+		},
+		"implied interval": {
+			config:   &configapi.GpuConfig{Sharing: &configapi.GpuSharing{Strategy: configapi.TimeSlicingStrategy}},
+			expected: "TimeSlicing with Default interval",
+		},
+		"time slicing": {
+			config:   &configapi.GpuConfig{Sharing: timeSlicing(configapi.LongTimeSlice)},
+			expected: "TimeSlicing with Long interval",
+		},
+		"space partitioning": {
+			config:   &configapi.GpuConfig{Sharing: spacePartitioning(10)},
+			expected: "SpacePartitioning into 10 partitions",
+		},
+		"unrecognized config": {
+			config:      &runtime.Unknown{},
+			expectedErr: "runtime object is not a recognized configuration",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sharedConfig, err := Profile{}.SharedConfig(test.config)
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, sharedConfig)
+		})
+	}
+}
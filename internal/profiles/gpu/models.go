@@ -0,0 +1,165 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpu
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultModelName is the model of the GPUs advertised when no models
+	// are configured.
+	DefaultModelName     = "LATEST-GPU-MODEL"
+	DefaultDriverVersion = "1.0.0"
+)
+
+var (
+	defaultMemory  = resource.MustParse("80Gi")
+	defaultCompute = resource.MustParse("100")
+)
+
+// Model describes one kind of GPU installed on a node. Nodes with a mix of
+// GPU models are described by a list of models, e.g. in YAML:
+//
+//   - name: big-80Gi
+//     count: 2
+//     partitions: 4
+//   - name: mig-80Gi
+//     count: 2
+//     partitionCatalog: {}
+//   - name: small-24Gi
+//     count: 4
+//     memory: 24Gi
+//     compute: "40"
+//     driverVersion: 2.1.0
+type Model struct {
+	// Name is published as the "model" attribute of the devices.
+	Name string `json:"name"`
+	// Count is the number of GPUs of this model.
+	Count int `json:"count"`
+	// Memory of each GPU. Defaults to 80Gi.
+	Memory *resource.Quantity `json:"memory,omitempty"`
+	// Compute capacity of each GPU. Defaults to 100.
+	Compute *resource.Quantity `json:"compute,omitempty"`
+	// DriverVersion is published as the "driverVersion" attribute of the
+	// devices. Defaults to [DefaultDriverVersion].
+	DriverVersion string `json:"driverVersion,omitempty"`
+	// Partitions is the number of partitions of each GPU. 0 disables
+	// partitioning.
+	Partitions int `json:"partitions,omitempty"`
+	// PartitionCatalog enables MIG-style partitioning of each GPU into
+	// partitions of different sizes. An empty catalog selects the default
+	// 1g/2g/3g/4g/7g catalog. Mutually exclusive with Partitions.
+	PartitionCatalog *PartitionCatalog `json:"partitionCatalog,omitempty"`
+}
+
+// ParseModels parses a YAML or JSON list of [Model]s, applies defaults and
+// validates the result. An empty spec yields no models.
+func ParseModels(spec string) ([]Model, error) {
+	var models []Model
+	if err := yaml.UnmarshalStrict([]byte(spec), &models); err != nil {
+		return nil, fmt.Errorf("decode GPU models: %w", err)
+	}
+	names := make(map[string]bool)
+	for i := range models {
+		models[i].Default()
+		if err := models[i].Validate(); err != nil {
+			return nil, fmt.Errorf("invalid GPU model: %w", err)
+		}
+		if names[models[i].Name] {
+			return nil, fmt.Errorf("duplicate GPU model %q", models[i].Name)
+		}
+		names[models[i].Name] = true
+	}
+	return models, nil
+}
+
+// Default sets the default values of unset fields.
+func (m *Model) Default() {
+	if m.Memory == nil {
+		m.Memory = ptr.To(defaultMemory.DeepCopy())
+	}
+	if m.Compute == nil {
+		m.Compute = ptr.To(defaultCompute.DeepCopy())
+	}
+	if m.DriverVersion == "" {
+		m.DriverVersion = DefaultDriverVersion
+	}
+	if m.PartitionCatalog != nil {
+		m.PartitionCatalog.Default()
+	}
+}
+
+// Validate ensures that a Model has a valid set of values.
+func (m *Model) Validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("no name set")
+	}
+	if m.Count < 0 {
+		return fmt.Errorf("negative count for model %s: %d", m.Name, m.Count)
+	}
+	if m.Partitions < 0 {
+		return fmt.Errorf("negative number of partitions for model %s: %d", m.Name, m.Partitions)
+	}
+	minMemory := resource.MustParse("1Gi")
+	if m.Memory.Cmp(minMemory) < 0 {
+		return fmt.Errorf("memory for model %s must be at least %s: %s", m.Name, minMemory.String(), m.Memory.String())
+	}
+	if m.Compute.Sign() <= 0 {
+		return fmt.Errorf("compute for model %s must be positive: %s", m.Name, m.Compute.String())
+	}
+	if m.Partitions > 0 {
+		// Each partition needs at least 1Gi of memory and 1 compute, see
+		// memoryCapacity and computeCapacity.
+		if m.Memory.Value()/int64(m.Partitions) < minMemory.Value() {
+			return fmt.Errorf("memory for model %s too small for %d partitions: %s", m.Name, m.Partitions, m.Memory.String())
+		}
+		if m.Compute.Value()/int64(m.Partitions) < 1 {
+			return fmt.Errorf("compute for model %s too small for %d partitions: %s", m.Name, m.Partitions, m.Compute.String())
+		}
+	}
+	if m.PartitionCatalog != nil {
+		if m.Partitions > 0 {
+			return fmt.Errorf("partitions and partition catalog are mutually exclusive for model %s", m.Name)
+		}
+		if err := m.PartitionCatalog.Validate(); err != nil {
+			return fmt.Errorf("invalid partition catalog for model %s: %w", m.Name, err)
+		}
+		if m.Memory.Value()/int64(m.PartitionCatalog.MemorySlices) < minMemory.Value() {
+			return fmt.Errorf("memory for model %s too small for %d memory slices: %s", m.Name, m.PartitionCatalog.MemorySlices, m.Memory.String())
+		}
+		if m.Compute.Value()/int64(m.PartitionCatalog.ComputeSlices) < 1 {
+			return fmt.Errorf("compute for model %s too small for %d compute slices: %s", m.Name, m.PartitionCatalog.ComputeSlices, m.Compute.String())
+		}
+	}
+	return nil
+}
+
+// defaultModel returns the model advertised when no models are configured.
+func defaultModel(count, partitions int) Model {
+	model := Model{
+		Name:       DefaultModelName,
+		Count:      count,
+		Partitions: partitions,
+	}
+	model.Default()
+	return model
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpu
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+func TestParseModels(t *testing.T) {
+	tests := map[string]struct {
+		spec        string
+		expected    []Model
+		expectedErr string
+	}{
+		"empty": {
+			spec: "",
+		},
+		"defaults": {
+			spec: `[{"name": "a", "count": 2}]`,
+			expected: []Model{{
+				Name:          "a",
+				Count:         2,
+				Memory:        ptr.To(resource.MustParse("80Gi")),
+				Compute:       ptr.To(resource.MustParse("100")),
+				DriverVersion: "1.0.0",
+			}},
+		},
+		"yaml": {
+			spec: "- name: small-24Gi\n  count: 4\n  memory: 24Gi\n  compute: \"40\"\n  driverVersion: 2.1.0\n  partitions: 2\n",
+			expected: []Model{{
+				Name:          "small-24Gi",
+				Count:         4,
+				Memory:        ptr.To(resource.MustParse("24Gi")),
+				Compute:       ptr.To(resource.MustParse("40")),
+				DriverVersion: "2.1.0",
+				Partitions:    2,
+			}},
+		},
+		"default partition catalog": {
+			spec: `[{"name": "a", "count": 1, "partitionCatalog": {}}]`,
+			expected: []Model{{
+				Name:             "a",
+				Count:            1,
+				Memory:           ptr.To(resource.MustParse("80Gi")),
+				Compute:          ptr.To(resource.MustParse("100")),
+				DriverVersion:    "1.0.0",
+				PartitionCatalog: ptr.To(defaultPartitionCatalog()),
+			}},
+		},
+		"partitions and partition catalog": {
+			spec:        `[{"name": "a", "count": 1, "partitions": 2, "partitionCatalog": {}}]`,
+			expectedErr: "partitions and partition catalog are mutually exclusive for model a",
+		},
+		"invalid partition catalog": {
+			spec:        `[{"name": "a", "count": 1, "partitionCatalog": {"computeSlices": 2, "memorySlices": 2}}]`,
+			expectedErr: "invalid partition catalog for model a: no partition profiles",
+		},
+		"memory too small for partition catalog": {
+			spec:        `[{"name": "a", "count": 1, "memory": "4Gi", "partitionCatalog": {}}]`,
+			expectedErr: "memory for model a too small for 8 memory slices: 4Gi",
+		},
+		"unknown field": {
+			spec:        `[{"name": "a", "count": 2, "bogus": true}]`,
+			expectedErr: `unknown field "bogus"`,
+		},
+		"missing name": {
+			spec:        `[{"count": 2}]`,
+			expectedErr: "no name set",
+		},
+		"duplicate name": {
+			spec:        `[{"name": "a", "count": 2}, {"name": "a", "count": 1}]`,
+			expectedErr: `duplicate GPU model "a"`,
+		},
+		"negative count": {
+			spec:        `[{"name": "a", "count": -1}]`,
+			expectedErr: "negative count for model a: -1",
+		},
+		"too many partitions": {
+			spec:        `[{"name": "a", "count": 1, "memory": "2Gi", "partitions": 4}]`,
+			expectedErr: "memory for model a too small for 4 partitions: 2Gi",
+		},
+		"no compute": {
+			spec:        `[{"name": "a", "count": 1, "compute": "0"}]`,
+			expectedErr: "compute for model a must be positive: 0",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			models, err := ParseModels(test.spec)
+			if test.expectedErr != "" {
+				require.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, models)
+		})
+	}
+}
+
+func TestEnumerateDevices_MixedModels(t *testing.T) {
+	models, err := ParseModels(`
+- name: big-80Gi
+  count: 2
+  partitions: 4
+- name: small-24Gi
+  count: 3
+  memory: 24Gi
+  compute: "40"
+  driverVersion: 2.1.0
+`)
+	require.NoError(t, err)
+
+	// numGPUs and partitionsPerGPU are ignored when models are set.
+	profile := NewProfile("test-node", 8, 2, false, false, false, "", models, profiles.Topology{}, nil)
+	resources, err := profile.EnumerateDevices()
+	require.NoError(t, err)
+
+	var counterSets []string
+	devices := make(map[string]resourceapi.Device)
+	for _, slice := range resources.Pools["test-node"].Slices {
+		for _, counterSet := range slice.SharedCounters {
+			counterSets = append(counterSets, counterSet.Name)
+		}
+		for _, device := range slice.Devices {
+			devices[device.Name] = device
+		}
+	}
+	assert.Equal(t, []string{"gpu-0-counters", "gpu-1-counters"}, counterSets)
+	// 2 GPUs with 4 partitions and a full device each, plus 3 whole GPUs.
+	assert.Len(t, devices, 2*5+3)
+
+	big := devices["gpu-1-partition-3"]
+	assert.Equal(t, "big-80Gi", *big.Attributes["model"].StringValue)
+	assert.Equal(t, "1.0.0", *big.Attributes["driverVersion"].VersionValue)
+	memory, compute := big.Capacity["memory"].Value, big.Capacity["compute"].Value
+	assert.Equal(t, int64(20<<30), memory.Value())
+	assert.Equal(t, int64(25), compute.Value())
+
+	for i := 2; i < 5; i++ {
+		small, ok := devices[fmt.Sprintf("gpu-%d", i)]
+		require.True(t, ok)
+		assert.Equal(t, int64(i), *small.Attributes["index"].IntValue)
+		assert.Equal(t, "small-24Gi", *small.Attributes["model"].StringValue)
+		assert.Equal(t, "2.1.0", *small.Attributes["driverVersion"].VersionValue)
+		assert.Equal(t, resource.MustParse("24Gi"), small.Capacity["memory"].Value)
+		assert.Equal(t, resource.MustParse("40"), small.Capacity["compute"].Value)
+		assert.Empty(t, small.ConsumesCounters)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gpu
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/urfave/cli/v2"
+
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+// Registration returns the registration of the gpu profile with its command
+// line flags.
+func Registration() profiles.Registration {
+	var settings struct {
+		partitions               int
+		deviceStatus             bool
+		bindingConditions        bool
+		allowMultipleAllocations bool
+		models                   []Model
+		faultFile                string
+	}
+	return profiles.Registration{
+		Name: ProfileName,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:        "gpu-partitions",
+				Usage:       "Number of partitions per GPU. When set to a value greater than 0, GPUs are exposed with shared counters allowing flexible partitioning (DRAPartitionableDevices feature).",
+				Value:       0,
+				Destination: &settings.partitions,
+				EnvVars:     []string{"GPU_PARTITIONS"},
+			},
+			&cli.BoolFlag{
+				Name:        "gpu-device-status",
+				Usage:       "Deprecated: use --device-status instead. Enable adding allocated device attributes (e.g., model, uuid, driverVersion) into ResourceClaim.status.devices[].data. Disabled by default. Only relevant for the " + ProfileName + " profile.",
+				Destination: &settings.deviceStatus,
+				EnvVars:     []string{"GPU_DEVICE_STATUS"},
+			},
+			&cli.BoolFlag{
+				Name:        "binding-conditions",
+				Usage:       "Enable or disable binding conditions processing in the DRA driver.",
+				Value:       false,
+				Destination: &settings.bindingConditions,
+				EnvVars:     []string{"BINDING_CONDITIONS"},
+			},
+			&cli.BoolFlag{
+				Name:        "gpu-allow-multiple-allocations",
+				Usage:       "Allow GPU devices to be allocated to multiple DeviceRequests. Disabled by default.",
+				Destination: &settings.allowMultipleAllocations,
+				EnvVars:     []string{"GPU_ALLOW_MULTIPLE_ALLOCATIONS"},
+			},
+			&cli.StringFlag{
+				Name:    "gpu-models",
+				Usage:   "YAML or JSON list of GPU models to advertise on a node with a mix of GPUs. Each model has a name, count, memory, compute, driverVersion and either a number of partitions or a MIG-style partition catalog. When set, --num-devices and --gpu-partitions are ignored. Only relevant for the " + ProfileName + " profile.",
+				EnvVars: []string{"GPU_MODELS"},
+				Action: func(_ *cli.Context, spec string) error {
+					models, err := ParseModels(spec)
+					if err != nil {
+						return fmt.Errorf("invalid --gpu-models: %w", err)
+					}
+					settings.models = models
+					return nil
+				},
+			},
+			&cli.StringFlag{
+				Name:        "gpu-fault-file",
+				Usage:       "Path to a YAML or JSON file listing simulated GPU faults which are published as device taints. The file is watched and taints are republished when it changes. Only relevant for the " + ProfileName + " profile.",
+				Destination: &settings.faultFile,
+				EnvVars:     []string{"GPU_FAULT_FILE"},
+			},
+		},
+		ConfigHandler: Profile{},
+		New: func(opts profiles.Options) (profiles.Profile, error) {
+			if settings.deviceStatus {
+				klog.Warning("--gpu-device-status is deprecated and will be removed in a future release, use --device-status instead")
+			}
+			var hardware *Hardware
+			if opts.StateDir != "" {
+				hardware = NewHardware(filepath.Join(opts.StateDir, HardwareFile))
+			}
+			return NewProfile(opts.NodeName, opts.NumDevices, settings.partitions, opts.DeviceStatus || settings.deviceStatus, settings.bindingConditions, settings.allowMultipleAllocations, settings.faultFile, settings.models, opts.Topology, hardware), nil
+		},
+	}
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/dynamic-resource-allocation/resourceslice"
+	"sigs.k8s.io/yaml"
+	cdiapi "tags.cncf.io/container-device-interface/pkg/cdi"
+	cdispec "tags.cncf.io/container-device-interface/specs-go"
+
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles/helpers"
+)
+
+const ProfileName = "inventory"
+
+// Inventory is the on-disk description of the devices advertised by the
+// inventory profile. It may be written as YAML or JSON and uses the same field
+// names as the ResourceSlice API, for example:
+//
+//	sharedCounters:
+//	- name: gpu-0-counters
+//	  counters:
+//	    memory:
+//	      value: 80Gi
+//	devices:
+//	- name: gpu-0
+//	  attributes:
+//	    model:
+//	      string: BIG-GPU
+//	  capacity:
+//	    memory:
+//	      value: 80Gi
+type Inventory struct {
+	SharedCounters []resourceapi.CounterSet `json:"sharedCounters,omitempty"`
+	Devices        []resourceapi.Device     `json:"devices,omitempty"`
+}
+
+// Profile advertises the devices described by an [Inventory] file instead of
+// generating them from flags.
+type Profile struct {
+	nodeName string
+	path     string
+}
+
+func NewProfile(nodeName string, path string) Profile {
+	return Profile{
+		nodeName: nodeName,
+		path:     path,
+	}
+}
+
+func (p Profile) EnumerateDevices() (resourceslice.DriverResources, error) {
+	inventory, err := readInventory(p.path)
+	if err != nil {
+		return resourceslice.DriverResources{}, err
+	}
+
+	resources := resourceslice.DriverResources{
+		Pools: map[string]resourceslice.Pool{
+			p.nodeName: {
+				Slices: helpers.PoolSlices(inventory.SharedCounters, inventory.Devices),
+			},
+		},
+	}
+
+	return resources, nil
+}
+
+// WatchDevices implements [profiles.DeviceWatcher]. onChange is called
+// whenever the content of the inventory file changes.
+func (p Profile) WatchDevices(ctx context.Context, onChange func()) error {
+	return helpers.WatchFile(ctx, p.path, onChange)
+}
+
+func readInventory(path string) (*Inventory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read inventory file: %w", err)
+	}
+	inventory := new(Inventory)
+	if err := yaml.UnmarshalStrict(data, inventory); err != nil {
+		return nil, fmt.Errorf("decode inventory file %s: %w", path, err)
+	}
+	if err := inventory.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid inventory file %s: %w", path, err)
+	}
+	return inventory, nil
+}
+
+// Validate catches mistakes in an [Inventory] that would otherwise only be
+// reported when the ResourceSlices are rejected by the API server.
+func (inv *Inventory) Validate() error {
+	counterSets := make(map[string]resourceapi.CounterSet)
+	for _, counterSet := range inv.SharedCounters {
+		if counterSet.Name == "" {
+			return errors.New("counter set without a name")
+		}
+		if _, exists := counterSets[counterSet.Name]; exists {
+			return fmt.Errorf("duplicate counter set %q", counterSet.Name)
+		}
+		counterSets[counterSet.Name] = counterSet
+	}
+
+	devices := make(map[string]struct{})
+	for _, device := range inv.Devices {
+		if device.Name == "" {
+			return errors.New("device without a name")
+		}
+		if _, exists := devices[device.Name]; exists {
+			return fmt.Errorf("duplicate device %q", device.Name)
+		}
+		devices[device.Name] = struct{}{}
+
+		for _, consumption := range device.ConsumesCounters {
+			counterSet, exists := counterSets[consumption.CounterSet]
+			if !exists {
+				return fmt.Errorf("device %q consumes counters from unknown counter set %q", device.Name, consumption.CounterSet)
+			}
+			for name := range consumption.Counters {
+				if _, exists := counterSet.Counters[name]; !exists {
+					return fmt.Errorf("device %q consumes unknown counter %q from counter set %q", device.Name, name, consumption.CounterSet)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// SchemeBuilder implements [profiles.ConfigHandler]. The inventory profile
+// does not accept opaque configuration.
+func (p Profile) SchemeBuilder() runtime.SchemeBuilder {
+	return runtime.NewSchemeBuilder()
+}
+
+// Validate implements [profiles.ConfigHandler].
+func (p Profile) Validate(config runtime.Object) error {
+	if config != nil {
+		return errors.New("configuration not allowed")
+	}
+	return nil
+}
+
+// ApplyConfig implements [profiles.ConfigHandler]. It rejects any non-nil
+// configuration and otherwise injects one env var per allocated device so the
+// demo container can show which devices were allocated.
+func (p Profile) ApplyConfig(config runtime.Object, results []*resourceapi.DeviceRequestAllocationResult) (profiles.PerDeviceCDIContainerEdits, error) {
+	if config != nil {
+		return nil, errors.New("configuration not allowed")
+	}
+
+	edits := make(profiles.PerDeviceCDIContainerEdits, len(results))
+	for _, result := range results {
+		deviceID := helpers.GetCDIDeviceID(result.Device, (*string)(result.ShareID))
+		edits[deviceID] = &cdiapi.ContainerEdits{ContainerEdits: &cdispec.ContainerEdits{
+			Env: []string{
+				fmt.Sprintf("INVENTORY_DEVICE_%s=%s", envVarSafeID(result.Device), result.Device),
+			},
+		}}
+	}
+	return edits, nil
+}
+
+func envVarSafeID(id string) string {
+	return strings.ToUpper(strings.ReplaceAll(id, "-", "_"))
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const testInventory = `
+sharedCounters:
+- name: gpu-0-counters
+  counters:
+    memory:
+      value: 80Gi
+devices:
+- name: gpu-0-half-0
+  attributes:
+    model:
+      string: BIG-GPU
+  capacity:
+    memory:
+      value: 40Gi
+  consumesCounters:
+  - counterSet: gpu-0-counters
+    counters:
+      memory:
+        value: 40Gi
+- name: gpu-1
+  attributes:
+    model:
+      string: SMALL-GPU
+    index:
+      int: 1
+`
+
+func writeInventory(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func TestEnumerateDevices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.yaml")
+	writeInventory(t, path, testInventory)
+
+	resources, err := NewProfile("test-node", path).EnumerateDevices()
+	require.NoError(t, err)
+
+	require.Contains(t, resources.Pools, "test-node")
+	pool := resources.Pools["test-node"]
+	require.Len(t, pool.Slices, 2)
+
+	require.Len(t, pool.Slices[0].SharedCounters, 1)
+	counterSet := pool.Slices[0].SharedCounters[0]
+	assert.Equal(t, "gpu-0-counters", counterSet.Name)
+	assert.Equal(t, resource.MustParse("80Gi"), counterSet.Counters["memory"].Value)
+
+	devices := pool.Slices[1].Devices
+	require.Len(t, devices, 2)
+	assert.Equal(t, "gpu-0-half-0", devices[0].Name)
+	assert.Equal(t, "BIG-GPU", *devices[0].Attributes["model"].StringValue)
+	assert.Equal(t, resource.MustParse("40Gi"), devices[0].Capacity["memory"].Value)
+	require.Len(t, devices[0].ConsumesCounters, 1)
+	assert.Equal(t, "gpu-0-counters", devices[0].ConsumesCounters[0].CounterSet)
+	assert.Equal(t, "gpu-1", devices[1].Name)
+	assert.Equal(t, int64(1), *devices[1].Attributes["index"].IntValue)
+}
+
+func TestEnumerateDevicesWithoutCounters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	writeInventory(t, path, `{"devices": [{"name": "dev-0"}]}`)
+
+	resources, err := NewProfile("test-node", path).EnumerateDevices()
+	require.NoError(t, err)
+
+	pool := resources.Pools["test-node"]
+	require.Len(t, pool.Slices, 1)
+	require.Len(t, pool.Slices[0].Devices, 1)
+	assert.Equal(t, "dev-0", pool.Slices[0].Devices[0].Name)
+}
+
+func TestEnumerateDevicesInvalid(t *testing.T) {
+	tests := map[string]struct {
+		content     string
+		expectedErr string
+	}{
+		"unknown field": {
+			content:     `devices: [{name: dev-0, bogus: true}]`,
+			expectedErr: `unknown field "bogus"`,
+		},
+		"device without a name": {
+			content:     `devices: [{attributes: {}}]`,
+			expectedErr: "device without a name",
+		},
+		"duplicate device": {
+			content:     `devices: [{name: dev-0}, {name: dev-0}]`,
+			expectedErr: `duplicate device "dev-0"`,
+		},
+		"duplicate counter set": {
+			content:     `sharedCounters: [{name: c}, {name: c}]`,
+			expectedErr: `duplicate counter set "c"`,
+		},
+		"unknown counter set": {
+			content:     `devices: [{name: dev-0, consumesCounters: [{counterSet: missing}]}]`,
+			expectedErr: `device "dev-0" consumes counters from unknown counter set "missing"`,
+		},
+		"unknown counter": {
+			content: `
+sharedCounters: [{name: c, counters: {memory: {value: 1Gi}}}]
+devices: [{name: dev-0, consumesCounters: [{counterSet: c, counters: {compute: {value: "1"}}}]}]
+`,
+			expectedErr: `device "dev-0" consumes unknown counter "compute" from counter set "c"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "inventory.yaml")
+			writeInventory(t, path, test.content)
+
+			_, err := NewProfile("test-node", path).EnumerateDevices()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectedErr)
+		})
+	}
+}
+
+func TestEnumerateDevicesMissingFile(t *testing.T) {
+	_, err := NewProfile("test-node", filepath.Join(t.TempDir(), "missing.yaml")).EnumerateDevices()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestWatchDevices(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "inventory.yaml")
+	writeInventory(t, path, `devices: [{name: dev-0}]`)
+	profile := NewProfile("test-node", path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changed := make(chan struct{}, 10)
+	done := make(chan error)
+	go func() {
+		done <- profile.WatchDevices(ctx, func() { changed <- struct{}{} })
+	}()
+
+	// Replace the file atomically like a ConfigMap update would. The content
+	// differs on every attempt because the watch may not be established yet
+	// when the first attempt is made.
+	attempt := 0
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		attempt++
+		tmp := filepath.Join(dir, "tmp")
+		require.NoError(c, os.WriteFile(tmp, []byte(fmt.Sprintf(`devices: [{name: dev-0}, {name: dev-%d}]`, attempt)), 0600))
+		require.NoError(c, os.Rename(tmp, path))
+		select {
+		case <-changed:
+		case <-time.After(100 * time.Millisecond):
+			assert.Fail(c, "no change notification")
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	resources, err := profile.EnumerateDevices()
+	require.NoError(t, err)
+	assert.Equal(t, []resourceapi.Device{{Name: "dev-0"}, {Name: fmt.Sprintf("dev-%d", attempt)}}, resources.Pools["test-node"].Slices[0].Devices)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestApplyConfig(t *testing.T) {
+	profile := NewProfile("test-node", "")
+
+	edits, err := profile.ApplyConfig(nil, []*resourceapi.DeviceRequestAllocationResult{{Device: "gpu-0-half-0"}})
+	require.NoError(t, err)
+	require.Contains(t, edits, "gpu-0-half-0")
+	assert.Equal(t, []string{"INVENTORY_DEVICE_GPU_0_HALF_0=gpu-0-half-0"}, edits["gpu-0-half-0"].Env)
+
+	_, err = profile.ApplyConfig(&resourceapi.ResourceClaim{}, nil)
+	assert.EqualError(t, err, "configuration not allowed")
+}
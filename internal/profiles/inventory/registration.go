@@ -0,0 +1,47 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package inventory
+
+import (
+	"github.com/urfave/cli/v2"
+
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+// Registration returns the registration of the inventory profile with its
+// command line flags.
+func Registration() profiles.Registration {
+	var settings struct {
+		file string
+	}
+	return profiles.Registration{
+		Name: ProfileName,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "inventory-file",
+				Usage:       "Path to a YAML or JSON file describing the devices to advertise. The file is watched and devices are republished when it changes. Only relevant for the " + ProfileName + " profile.",
+				Value:       "/etc/dra-example-driver/inventory.yaml",
+				Destination: &settings.file,
+				EnvVars:     []string{"INVENTORY_FILE"},
+			},
+		},
+		ConfigHandler: Profile{},
+		New: func(opts profiles.Options) (profiles.Profile, error) {
+			return NewProfile(opts.NodeName, settings.file), nil
+		},
+	}
+}
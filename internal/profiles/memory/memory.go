@@ -0,0 +1,236 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/dynamic-resource-allocation/resourceslice"
+	"k8s.io/utils/ptr"
+	cdiapi "tags.cncf.io/container-device-interface/pkg/cdi"
+	cdispec "tags.cncf.io/container-device-interface/specs-go"
+
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles/helpers"
+)
+
+const ProfileName = "memory"
+
+// Capacity suffixes are appended to the driver name (e.g. "memory.example.com")
+// to form the per-device capacity keys (e.g. "memory.example.com/memory"), like
+// the capacity key of the cpu profile.
+const (
+	MemoryCapacitySuffix       = "memory"
+	Hugepages2MiCapacitySuffix = "hugepages-2Mi"
+	Hugepages1GiCapacitySuffix = "hugepages-1Gi"
+)
+
+var (
+	pageSize2Mi = resource.MustParse("2Mi")
+	pageSize1Gi = resource.MustParse("1Gi")
+)
+
+// NUMANodeMemory is the memory each simulated NUMA node advertises as
+// consumable capacity.
+type NUMANodeMemory struct {
+	Memory       resource.Quantity
+	Hugepages2Mi resource.Quantity
+	Hugepages1Gi resource.Quantity
+}
+
+// DefaultNUMANodeMemory returns the default memory of a NUMA node.
+func DefaultNUMANodeMemory() NUMANodeMemory {
+	return NUMANodeMemory{
+		Memory:       resource.MustParse("16Gi"),
+		Hugepages2Mi: resource.MustParse("1Gi"),
+		Hugepages1Gi: resource.MustParse("2Gi"),
+	}
+}
+
+// Validate ensures that NUMANodeMemory has a valid set of values.
+func (m NUMANodeMemory) Validate() error {
+	if m.Memory.Sign() <= 0 {
+		return fmt.Errorf("memory must be positive: %s", m.Memory.String())
+	}
+	if err := validateHugepages(m.Hugepages2Mi, pageSize2Mi); err != nil {
+		return fmt.Errorf("invalid hugepages-2Mi: %w", err)
+	}
+	if err := validateHugepages(m.Hugepages1Gi, pageSize1Gi); err != nil {
+		return fmt.Errorf("invalid hugepages-1Gi: %w", err)
+	}
+	return nil
+}
+
+func validateHugepages(size, pageSize resource.Quantity) error {
+	if size.Sign() < 0 {
+		return fmt.Errorf("negative size: %s", size.String())
+	}
+	if size.Value()%pageSize.Value() != 0 {
+		return fmt.Errorf("%s is not a multiple of the page size %s", size.String(), pageSize.String())
+	}
+	return nil
+}
+
+// Profile advertises one device per simulated NUMA node whose memory and
+// hugepages are consumable capacity mapped to the node-allocatable "memory",
+// "hugepages-2Mi" and "hugepages-1Gi" resources. The devices have the same
+// NUMA node attribute as the devices of the cpu profile, so that claims can
+// align CPUs and memory with a matchAttribute constraint.
+type Profile struct {
+	nodeName       string
+	driverName     string
+	numNUMANodes   int
+	numaNodeMemory NUMANodeMemory
+}
+
+func NewProfile(nodeName, driverName string, numNUMANodes int, numaNodeMemory NUMANodeMemory) Profile {
+	return Profile{
+		nodeName:       nodeName,
+		driverName:     driverName,
+		numNUMANodes:   numNUMANodes,
+		numaNodeMemory: numaNodeMemory,
+	}
+}
+
+// CapacityKey returns the device-capacity key of the given capacity suffix.
+// It is also the key user-facing ResourceClaims must reference in
+// `capacity.requests`.
+func (p Profile) CapacityKey(suffix string) resourceapi.QualifiedName {
+	return resourceapi.QualifiedName(p.driverName + "/" + suffix)
+}
+
+func (p Profile) EnumerateDevices() (resourceslice.DriverResources, error) {
+	capacities := []struct {
+		suffix   string
+		resource corev1.ResourceName
+		size     resource.Quantity
+		step     resource.Quantity
+	}{
+		{MemoryCapacitySuffix, corev1.ResourceMemory, p.numaNodeMemory.Memory, resource.MustParse("1Mi")},
+		{Hugepages2MiCapacitySuffix, corev1.ResourceHugePagesPrefix + "2Mi", p.numaNodeMemory.Hugepages2Mi, pageSize2Mi},
+		{Hugepages1GiCapacitySuffix, corev1.ResourceHugePagesPrefix + "1Gi", p.numaNodeMemory.Hugepages1Gi, pageSize1Gi},
+	}
+
+	devices := make([]resourceapi.Device, p.numNUMANodes)
+	for i := range p.numNUMANodes {
+		device := resourceapi.Device{
+			Name:                     fmt.Sprintf("numa-%d", i),
+			AllowMultipleAllocations: ptr.To(true),
+			Attributes: map[resourceapi.QualifiedName]resourceapi.DeviceAttribute{
+				"numaNodeID": {IntValue: ptr.To(int64(i))},
+				// The same attribute as the NUMA node of cpu, gpu and
+				// net devices, see [profiles.Topology].
+				profiles.NUMANodeAttribute: {IntValue: ptr.To(int64(i))},
+			},
+			Capacity:                        make(map[resourceapi.QualifiedName]resourceapi.DeviceCapacity),
+			NodeAllocatableResourceMappings: make(map[corev1.ResourceName]resourceapi.NodeAllocatableResourceMapping),
+		}
+		for _, capacity := range capacities {
+			// Hugepages of a size may be disabled.
+			if capacity.size.IsZero() {
+				continue
+			}
+			key := p.CapacityKey(capacity.suffix)
+			device.Capacity[key] = consumableCapacity(capacity.size, capacity.step)
+			device.NodeAllocatableResourceMappings[capacity.resource] = resourceapi.NodeAllocatableResourceMapping{CapacityKey: &key}
+		}
+		devices[i] = device
+	}
+
+	return resourceslice.DriverResources{
+		Pools: map[string]resourceslice.Pool{
+			p.nodeName: {Slices: []resourceslice.Slice{{Devices: devices}}},
+		},
+	}, nil
+}
+
+// consumableCapacity returns a capacity of the given size. Requests only
+// consume the kinds of memory they ask for, and hugepages in whole pages, so
+// they consume nothing by default and otherwise multiples of step.
+func consumableCapacity(size, step resource.Quantity) resourceapi.DeviceCapacity {
+	return resourceapi.DeviceCapacity{
+		Value: size,
+		RequestPolicy: &resourceapi.CapacityRequestPolicy{
+			Default: ptr.To(resource.MustParse("0")),
+			ValidRange: &resourceapi.CapacityRequestPolicyRange{
+				Min:  ptr.To(resource.MustParse("0")),
+				Step: &step,
+			},
+		},
+	}
+}
+
+// SchemeBuilder implements [profiles.ConfigHandler]. The memory profile does
+// not accept opaque configuration.
+func (p Profile) SchemeBuilder() runtime.SchemeBuilder {
+	return runtime.NewSchemeBuilder()
+}
+
+// Validate implements [profiles.ConfigHandler].
+func (p Profile) Validate(config runtime.Object) error {
+	if config != nil {
+		return errors.New("configuration not allowed")
+	}
+	return nil
+}
+
+// ApplyConfig implements [profiles.ConfigHandler]. It rejects any non-nil
+// configuration and otherwise injects env vars per allocated NUMA device so the
+// demo container can show which device was allocated and how much memory and
+// hugepages were consumed. A real driver would bind the container's memory to
+// the NUMA node instead.
+func (p Profile) ApplyConfig(config runtime.Object, results []*resourceapi.DeviceRequestAllocationResult) (profiles.PerDeviceCDIContainerEdits, error) {
+	if config != nil {
+		return nil, errors.New("configuration not allowed")
+	}
+
+	capacities := []struct {
+		suffix string
+		envVar string
+	}{
+		{MemoryCapacitySuffix, "MEMORY"},
+		{Hugepages2MiCapacitySuffix, "HUGEPAGES_2MI"},
+		{Hugepages1GiCapacitySuffix, "HUGEPAGES_1GI"},
+	}
+
+	edits := make(profiles.PerDeviceCDIContainerEdits, len(results))
+	for _, result := range results {
+		// Device names are "numa-<index>"; trim the prefix for the env var.
+		envID := result.Device[len("numa-"):]
+		envs := []string{
+			fmt.Sprintf("MEMORY_DEVICE_%s=%s", envID, result.Device),
+		}
+		for _, capacity := range capacities {
+			if consumed, ok := result.ConsumedCapacity[p.CapacityKey(capacity.suffix)]; ok && !consumed.IsZero() {
+				envs = append(envs, fmt.Sprintf("MEMORY_DEVICE_%s_%s=%d", envID, capacity.envVar, consumed.Value()))
+			}
+		}
+
+		// Key edits by the share-aware device id so that multiple shares of one
+		// NUMA device keep their own edits instead of overwriting each other.
+		deviceID := helpers.GetCDIDeviceID(result.Device, (*string)(result.ShareID))
+		edits[deviceID] = &cdiapi.ContainerEdits{ContainerEdits: &cdispec.ContainerEdits{
+			Env: envs,
+		}}
+	}
+	return edits, nil
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	configapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/gpu/v1alpha1"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles/helpers"
+)
+
+func TestNUMANodeMemoryValidate(t *testing.T) {
+	tests := map[string]struct {
+		memory      NUMANodeMemory
+		expectedErr string
+	}{
+		"default": {
+			memory: DefaultNUMANodeMemory(),
+		},
+		"no hugepages": {
+			memory: NUMANodeMemory{Memory: resource.MustParse("8Gi")},
+		},
+		"no memory": {
+			memory:      NUMANodeMemory{Hugepages2Mi: resource.MustParse("1Gi")},
+			expectedErr: "memory must be positive: 0",
+		},
+		"partial 2Mi page": {
+			memory: NUMANodeMemory{
+				Memory:       resource.MustParse("8Gi"),
+				Hugepages2Mi: resource.MustParse("3Mi"),
+			},
+			expectedErr: "invalid hugepages-2Mi: 3Mi is not a multiple of the page size 2Mi",
+		},
+		"partial 1Gi page": {
+			memory: NUMANodeMemory{
+				Memory:       resource.MustParse("8Gi"),
+				Hugepages1Gi: resource.MustParse("1536Mi"),
+			},
+			expectedErr: "invalid hugepages-1Gi: 1536Mi is not a multiple of the page size 1Gi",
+		},
+		"negative hugepages": {
+			memory: NUMANodeMemory{
+				Memory:       resource.MustParse("8Gi"),
+				Hugepages1Gi: resource.MustParse("-1Gi"),
+			},
+			expectedErr: "invalid hugepages-1Gi: negative size: -1Gi",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.memory.Validate()
+			if test.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestEnumerateDevices(t *testing.T) {
+	profile := NewProfile("test-node", "memory.example.com", 2, DefaultNUMANodeMemory())
+
+	resources, err := profile.EnumerateDevices()
+	require.NoError(t, err)
+
+	require.Contains(t, resources.Pools, "test-node")
+	pool := resources.Pools["test-node"]
+	require.Len(t, pool.Slices, 1)
+	devices := pool.Slices[0].Devices
+	require.Len(t, devices, 2)
+
+	expectedCapacities := map[resourceapi.QualifiedName]string{
+		"memory.example.com/memory":        "16Gi",
+		"memory.example.com/hugepages-2Mi": "1Gi",
+		"memory.example.com/hugepages-1Gi": "2Gi",
+	}
+	expectedMappings := map[corev1.ResourceName]resourceapi.QualifiedName{
+		corev1.ResourceMemory: "memory.example.com/memory",
+		"hugepages-2Mi":       "memory.example.com/hugepages-2Mi",
+		"hugepages-1Gi":       "memory.example.com/hugepages-1Gi",
+	}
+	for i, device := range devices {
+		assert.Equal(t, fmt.Sprintf("numa-%d", i), device.Name)
+		require.NotNil(t, device.AllowMultipleAllocations)
+		assert.True(t, *device.AllowMultipleAllocations)
+
+		numaID := device.Attributes["numaNodeID"]
+		require.NotNil(t, numaID.IntValue)
+		assert.Equal(t, int64(i), *numaID.IntValue)
+		assert.Equal(t, numaID, device.Attributes[profiles.NUMANodeAttribute])
+
+		require.Len(t, device.Capacity, len(expectedCapacities))
+		for key, value := range expectedCapacities {
+			capacity, ok := device.Capacity[key]
+			require.True(t, ok, "device %q missing %q capacity entry", device.Name, key)
+			assert.Equal(t, 0, capacity.Value.Cmp(resource.MustParse(value)), "capacity %q", key)
+			require.NotNil(t, capacity.RequestPolicy)
+			assert.True(t, capacity.RequestPolicy.Default.IsZero(), "capacity %q must not be consumed by default", key)
+		}
+		assert.Equal(t, "2Mi", device.Capacity["memory.example.com/hugepages-2Mi"].RequestPolicy.ValidRange.Step.String())
+		assert.Equal(t, "1Gi", device.Capacity["memory.example.com/hugepages-1Gi"].RequestPolicy.ValidRange.Step.String())
+
+		require.Len(t, device.NodeAllocatableResourceMappings, len(expectedMappings))
+		for name, key := range expectedMappings {
+			mapping, ok := device.NodeAllocatableResourceMappings[name]
+			require.True(t, ok, "device %q missing %q mapping", device.Name, name)
+			require.NotNil(t, mapping.CapacityKey)
+			assert.Equal(t, key, *mapping.CapacityKey)
+		}
+	}
+}
+
+func TestEnumerateDevices_NoHugepages(t *testing.T) {
+	profile := NewProfile("test-node", "memory.example.com", 1, NUMANodeMemory{Memory: resource.MustParse("8Gi")})
+
+	resources, err := profile.EnumerateDevices()
+	require.NoError(t, err)
+
+	device := resources.Pools["test-node"].Slices[0].Devices[0]
+	assert.Len(t, device.Capacity, 1)
+	assert.Contains(t, device.Capacity, resourceapi.QualifiedName("memory.example.com/memory"))
+	assert.Len(t, device.NodeAllocatableResourceMappings, 1)
+	assert.Contains(t, device.NodeAllocatableResourceMappings, corev1.ResourceMemory)
+}
+
+func TestApplyConfig(t *testing.T) {
+	profile := NewProfile("test-node", "memory.example.com", 2, DefaultNUMANodeMemory())
+	results := []*resourceapi.DeviceRequestAllocationResult{
+		{
+			Device:  "numa-1",
+			ShareID: ptr.To(types.UID("share-1")),
+			ConsumedCapacity: map[resourceapi.QualifiedName]resource.Quantity{
+				"memory.example.com/memory":        resource.MustParse("1Gi"),
+				"memory.example.com/hugepages-2Mi": resource.MustParse("0"),
+				"memory.example.com/hugepages-1Gi": resource.MustParse("2Gi"),
+			},
+		},
+	}
+
+	edits, err := profile.ApplyConfig(nil, results)
+	require.NoError(t, err)
+	deviceID := helpers.GetCDIDeviceID("numa-1", ptr.To("share-1"))
+	require.Contains(t, edits, deviceID)
+	assert.Equal(t, []string{
+		"MEMORY_DEVICE_1=numa-1",
+		"MEMORY_DEVICE_1_MEMORY=1073741824",
+		"MEMORY_DEVICE_1_HUGEPAGES_1GI=2147483648",
+	}, edits[deviceID].Env)
+
+	_, err = profile.ApplyConfig(&configapi.GpuConfig{}, results)
+	assert.EqualError(t, err, "configuration not allowed")
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+// Registration returns the registration of the memory profile with its
+// command line flags.
+func Registration() profiles.Registration {
+	var settings struct {
+		numaNodes      int
+		numaNodeMemory NUMANodeMemory
+	}
+	settings.numaNodeMemory = DefaultNUMANodeMemory()
+	return profiles.Registration{
+		Name: ProfileName,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:        "memory-numa-nodes",
+				Usage:       "Number of fake NUMA-node devices to advertise. Only relevant for the " + ProfileName + " profile.",
+				Value:       8,
+				Destination: &settings.numaNodes,
+				EnvVars:     []string{"MEMORY_NUMA_NODES"},
+			},
+			&cli.StringFlag{
+				Name:    "memory-per-numa-node",
+				Usage:   "Amount of memory each fake NUMA-node device advertises as consumable capacity. Only relevant for the " + ProfileName + " profile.",
+				Value:   settings.numaNodeMemory.Memory.String(),
+				EnvVars: []string{"MEMORY_PER_NUMA_NODE"},
+				Action: func(_ *cli.Context, value string) error {
+					return parseQuantity("memory-per-numa-node", value, &settings.numaNodeMemory.Memory)
+				},
+			},
+			&cli.StringFlag{
+				Name:    "hugepages-2mi-per-numa-node",
+				Usage:   "Amount of 2Mi hugepages each fake NUMA-node device advertises as consumable capacity. 0 disables them. Only relevant for the " + ProfileName + " profile.",
+				Value:   settings.numaNodeMemory.Hugepages2Mi.String(),
+				EnvVars: []string{"HUGEPAGES_2MI_PER_NUMA_NODE"},
+				Action: func(_ *cli.Context, value string) error {
+					return parseQuantity("hugepages-2mi-per-numa-node", value, &settings.numaNodeMemory.Hugepages2Mi)
+				},
+			},
+			&cli.StringFlag{
+				Name:    "hugepages-1gi-per-numa-node",
+				Usage:   "Amount of 1Gi hugepages each fake NUMA-node device advertises as consumable capacity. 0 disables them. Only relevant for the " + ProfileName + " profile.",
+				Value:   settings.numaNodeMemory.Hugepages1Gi.String(),
+				EnvVars: []string{"HUGEPAGES_1GI_PER_NUMA_NODE"},
+				Action: func(_ *cli.Context, value string) error {
+					return parseQuantity("hugepages-1gi-per-numa-node", value, &settings.numaNodeMemory.Hugepages1Gi)
+				},
+			},
+		},
+		ConfigHandler: Profile{},
+		New: func(opts profiles.Options) (profiles.Profile, error) {
+			if err := settings.numaNodeMemory.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid NUMA node memory: %w", err)
+			}
+			return NewProfile(opts.NodeName, opts.DriverName, settings.numaNodes, settings.numaNodeMemory), nil
+		},
+	}
+}
+
+// parseQuantity parses the value of the named flag into q.
+func parseQuantity(name, value string, q *resource.Quantity) error {
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return fmt.Errorf("invalid --%s: %w", name, err)
+	}
+	*q = quantity
+	return nil
+}
@@ -18,6 +18,8 @@ package net
 
 import (
 	"fmt"
+	"maps"
+	"strings"
 
 	resourceapi "k8s.io/api/resource/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -28,21 +30,33 @@ import (
 	cdispec "tags.cncf.io/container-device-interface/specs-go"
 
 	configapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/net/v1alpha1"
-	"sigs.k8s.io/dra-example-driver/internal/profiles"
-	"sigs.k8s.io/dra-example-driver/internal/profiles/helpers"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles/helpers"
 )
 
 const ProfileName = "net"
 
 type Profile struct {
-	nodeName string
-	numNets  int
+	nodeName           string
+	numNets            int
+	vfsPerNIC          int
+	enableDeviceStatus bool
+	topology           profiles.Topology
 }
 
-func NewProfile(nodeName string, numNets int) Profile {
+// NewProfile returns a profile advertising numNets NICs. When vfsPerNIC is
+// greater than 0, each NIC is an SR-IOV physical function (PF) and each of its
+// virtual functions (VFs) is advertised as a separate device consuming the
+// PF's bandwidth and VF slots from a per-PF counter set. With
+// enableDeviceStatus, simulated network data of each allocated device is
+// published in the ResourceClaim status.
+func NewProfile(nodeName string, numNets int, vfsPerNIC int, enableDeviceStatus bool, topology profiles.Topology) Profile {
 	return Profile{
-		nodeName: nodeName,
-		numNets:  numNets,
+		nodeName:           nodeName,
+		numNets:            numNets,
+		vfsPerNIC:          vfsPerNIC,
+		enableDeviceStatus: enableDeviceStatus,
+		topology:           topology,
 	}
 }
 
@@ -64,24 +78,25 @@ func (p Profile) EnumerateDevices() (resourceslice.DriverResources, error) {
 	}
 
 	var devices []resourceapi.Device
+	var sharedCounters []resourceapi.CounterSet
 	for i, uuid := range uuids {
+		attrs := p.topology.Attributes(i, p.numNets)
+		attrs["index"] = resourceapi.DeviceAttribute{IntValue: ptr.To(int64(i))}
+		attrs["uuid"] = resourceapi.DeviceAttribute{StringValue: ptr.To(uuid)}
+		attrs["model"] = resourceapi.DeviceAttribute{StringValue: ptr.To("LATEST-NET-MODEL")}
+		attrs["driverVersion"] = resourceapi.DeviceAttribute{VersionValue: ptr.To("1.0.0")}
+
+		if p.vfsPerNIC > 0 {
+			counterSet, vfs := p.vfDevices(i, attrs, bandwidthCapacity.Value)
+			sharedCounters = append(sharedCounters, counterSet)
+			devices = append(devices, vfs...)
+			continue
+		}
+
 		device := resourceapi.Device{
 			Name:                     fmt.Sprintf("nic-%d", i),
 			AllowMultipleAllocations: ptr.To(true),
-			Attributes: map[resourceapi.QualifiedName]resourceapi.DeviceAttribute{
-				"index": {
-					IntValue: ptr.To(int64(i)),
-				},
-				"uuid": {
-					StringValue: ptr.To(uuid),
-				},
-				"model": {
-					StringValue: ptr.To("LATEST-NET-MODEL"),
-				},
-				"driverVersion": {
-					VersionValue: ptr.To("1.0.0"),
-				},
-			},
+			Attributes:               attrs,
 			Capacity: map[resourceapi.QualifiedName]resourceapi.DeviceCapacity{
 				"vfs": {
 					Value: resource.MustParse("100"),
@@ -102,11 +117,7 @@ func (p Profile) EnumerateDevices() (resourceslice.DriverResources, error) {
 	resources := resourceslice.DriverResources{
 		Pools: map[string]resourceslice.Pool{
 			p.nodeName: {
-				Slices: []resourceslice.Slice{
-					{
-						Devices: devices,
-					},
-				},
+				Slices: helpers.PoolSlices(sharedCounters, devices),
 			},
 		},
 	}
@@ -114,6 +125,67 @@ func (p Profile) EnumerateDevices() (resourceslice.DriverResources, error) {
 	return resources, nil
 }
 
+// vfDevices returns the counter set of the PF with the given index and its
+// devices: the PF itself, which consumes all counters for exclusive use of the
+// whole NIC, and one device per VF, which consumes one VF slot and an equal
+// share of the bandwidth. attrs are the attributes of the PF.
+func (p Profile) vfDevices(index int, attrs map[resourceapi.QualifiedName]resourceapi.DeviceAttribute, bandwidth resource.Quantity) (resourceapi.CounterSet, []resourceapi.Device) {
+	pfName := fmt.Sprintf("nic-%d", index)
+	pfCounters := map[string]resourceapi.Counter{
+		"ingressBandwidth": {Value: bandwidth},
+		"egressBandwidth":  {Value: bandwidth},
+		"vfs":              {Value: *resource.NewQuantity(int64(p.vfsPerNIC), resource.DecimalSI)},
+	}
+	counterSet := resourceapi.CounterSet{
+		Name:     pfName + "-counters",
+		Counters: pfCounters,
+	}
+
+	pfAttrs := maps.Clone(attrs)
+	pfAttrs["physicalFunction"] = resourceapi.DeviceAttribute{BoolValue: ptr.To(true)}
+	devices := []resourceapi.Device{{
+		Name:       pfName,
+		Attributes: pfAttrs,
+		Capacity: map[resourceapi.QualifiedName]resourceapi.DeviceCapacity{
+			"ingressBandwidth": {Value: bandwidth},
+			"egressBandwidth":  {Value: bandwidth},
+		},
+		ConsumesCounters: []resourceapi.DeviceCounterConsumption{{
+			CounterSet: counterSet.Name,
+			Counters:   pfCounters,
+		}},
+	}}
+
+	vfBandwidth := *resource.NewQuantity(bandwidth.Value()/int64(p.vfsPerNIC), resource.DecimalSI)
+	pfUUID := *attrs["uuid"].StringValue
+	vfUUIDs := helpers.GenerateUUIDs(pfUUID, "vf", p.vfsPerNIC)
+	for j, vfUUID := range vfUUIDs {
+		vfAttrs := maps.Clone(attrs)
+		vfAttrs["uuid"] = resourceapi.DeviceAttribute{StringValue: ptr.To(vfUUID)}
+		vfAttrs["physicalFunction"] = resourceapi.DeviceAttribute{BoolValue: ptr.To(false)}
+		vfAttrs["vfIndex"] = resourceapi.DeviceAttribute{IntValue: ptr.To(int64(j))}
+		vfAttrs["pfUUID"] = resourceapi.DeviceAttribute{StringValue: ptr.To(pfUUID)}
+		devices = append(devices, resourceapi.Device{
+			Name:       fmt.Sprintf("%s-vf-%d", pfName, j),
+			Attributes: vfAttrs,
+			Capacity: map[resourceapi.QualifiedName]resourceapi.DeviceCapacity{
+				"ingressBandwidth": {Value: vfBandwidth},
+				"egressBandwidth":  {Value: vfBandwidth},
+			},
+			ConsumesCounters: []resourceapi.DeviceCounterConsumption{{
+				CounterSet: counterSet.Name,
+				Counters: map[string]resourceapi.Counter{
+					"ingressBandwidth": {Value: vfBandwidth},
+					"egressBandwidth":  {Value: vfBandwidth},
+					"vfs":              {Value: resource.MustParse("1")},
+				},
+			}},
+		})
+	}
+
+	return counterSet, devices
+}
+
 // SchemeBuilder implements [profiles.ConfigHandler].
 func (p Profile) SchemeBuilder() runtime.SchemeBuilder {
 	return runtime.NewSchemeBuilder(
@@ -161,20 +233,46 @@ func applyNetConfig(config *configapi.NetConfig, results []*resourceapi.DeviceRe
 	for _, result := range results {
 		shareId := (*string)(result.ShareID)
 		deviceId := helpers.GetCDIDeviceID(result.Device, shareId)
+		// Device names are prefixed with "nic-" (e.g. "nic-0", "nic-0-vf-1").
+		envID := envVarSafeID(result.Device[4:])
 		envs := []string{}
+		if pf, _, isVF := strings.Cut(result.Device, "-vf-"); isVF {
+			envs = append(envs, fmt.Sprintf("NET_DEVICE_%s_PF=%s", envID, pf))
+		}
 		if config.BandwidthBurst != nil {
 			if config.BandwidthBurst.IngressBurst > 0 {
-				envs = append(envs, fmt.Sprintf("NET_DEVICE_%s_INGRESS_BURST=%d", result.Device[4:], config.BandwidthBurst.IngressBurst))
+				envs = append(envs, fmt.Sprintf("NET_DEVICE_%s_INGRESS_BURST=%d", envID, config.BandwidthBurst.IngressBurst))
 			}
 			if config.BandwidthBurst.EgressBurst > 0 {
-				envs = append(envs, fmt.Sprintf("NET_DEVICE_%s_EGRESS_BURST=%d", result.Device[4:], config.BandwidthBurst.EgressBurst))
+				envs = append(envs, fmt.Sprintf("NET_DEVICE_%s_EGRESS_BURST=%d", envID, config.BandwidthBurst.EgressBurst))
 			}
 		}
-		if ingressRate, found := result.ConsumedCapacity["ingressBandwidth"]; found {
-			envs = append(envs, fmt.Sprintf("NET_DEVICE_%s_INGRESS_RATE=%d", result.Device[4:], ingressRate.AsDec()))
+		var ingressRate, egressRate *resource.Quantity
+		if rate, found := result.ConsumedCapacity["ingressBandwidth"]; found {
+			ingressRate = &rate
+			envs = append(envs, fmt.Sprintf("NET_DEVICE_%s_INGRESS_RATE=%d", envID, ingressRate.AsDec()))
+		}
+		if rate, found := result.ConsumedCapacity["egressBandwidth"]; found {
+			egressRate = &rate
+			envs = append(envs, fmt.Sprintf("NET_DEVICE_%s_EGRESS_RATE=%d", envID, egressRate.AsDec()))
 		}
-		if egressRate, found := result.ConsumedCapacity["egressBandwidth"]; found {
-			envs = append(envs, fmt.Sprintf("NET_DEVICE_%s_EGRESS_RATE=%d", result.Device[4:], egressRate.AsDec()))
+		if err := config.ValidateConsumedBandwidth(ingressRate, egressRate); err != nil {
+			return nil, fmt.Errorf("error validating Net config for device %v: %w", result.Device, err)
+		}
+		if qos := config.QoS; qos != nil {
+			envs = append(envs, fmt.Sprintf("NET_DEVICE_%s_TRAFFIC_CLASS=%s", envID, qos.TrafficClass))
+			if qos.Priority != nil {
+				envs = append(envs, fmt.Sprintf("NET_DEVICE_%s_PRIORITY=%d", envID, *qos.Priority))
+			}
+			if qos.MaxRate != nil && qos.MaxRate.Ingress != nil {
+				envs = append(envs, fmt.Sprintf("NET_DEVICE_%s_INGRESS_MAX_RATE=%d", envID, qos.MaxRate.Ingress.AsDec()))
+			}
+			if qos.MaxRate != nil && qos.MaxRate.Egress != nil {
+				envs = append(envs, fmt.Sprintf("NET_DEVICE_%s_EGRESS_MAX_RATE=%d", envID, qos.MaxRate.Egress.AsDec()))
+			}
+			if qos.DSCP != nil {
+				envs = append(envs, fmt.Sprintf("NET_DEVICE_%s_DSCP=%d", envID, *qos.DSCP))
+			}
 		}
 
 		edits := &cdispec.ContainerEdits{
@@ -186,3 +284,7 @@ func applyNetConfig(config *configapi.NetConfig, results []*resourceapi.DeviceRe
 
 	return perDeviceEdits, nil
 }
+
+func envVarSafeID(id string) string {
+	return strings.ToUpper(strings.ReplaceAll(id, "-", "_"))
+}
@@ -17,6 +17,7 @@
 package net
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -24,20 +25,22 @@ import (
 	resourceapi "k8s.io/api/resource/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/dynamic-resource-allocation/deviceattribute"
 	"k8s.io/utils/ptr"
 
 	configapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/net/v1alpha1"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
 )
 
 func TestNewProfile(t *testing.T) {
-	profile := NewProfile("test-node", 4)
+	profile := NewProfile("test-node", 4, 0, false, profiles.Topology{})
 
 	assert.Equal(t, "test-node", profile.nodeName)
 	assert.Equal(t, 4, profile.numNets)
 }
 
 func TestEnumerateDevices(t *testing.T) {
-	profile := NewProfile("test-node", 3)
+	profile := NewProfile("test-node", 3, 0, false, profiles.Topology{})
 
 	resources, err := profile.EnumerateDevices()
 	require.NoError(t, err)
@@ -94,7 +97,7 @@ func TestEnumerateDevices(t *testing.T) {
 }
 
 func TestEnumerateDevices_CapacityRequestPolicy(t *testing.T) {
-	profile := NewProfile("test-node", 1)
+	profile := NewProfile("test-node", 1, 0, false, profiles.Topology{})
 
 	resources, err := profile.EnumerateDevices()
 	require.NoError(t, err)
@@ -128,10 +131,26 @@ func TestEnumerateDevices_CapacityRequestPolicy(t *testing.T) {
 	assert.Equal(t, resource.MustParse("1M"), *egressCapacity.RequestPolicy.ValidRange.Step)
 }
 
+func TestEnumerateDevices_Topology(t *testing.T) {
+	topology := profiles.Topology{NUMANodes: 2, PCIeRootsPerNUMANode: 2}
+	profile := NewProfile("test-node", 4, 0, false, topology)
+
+	resources, err := profile.EnumerateDevices()
+	require.NoError(t, err)
+
+	devices := resources.Pools["test-node"].Slices[0].Devices
+	require.Len(t, devices, 4)
+	for i, device := range devices {
+		assert.Equal(t, int64(i/2), *device.Attributes[profiles.NUMANodeAttribute].IntValue)
+		assert.Equal(t, profiles.PCIeRoot(i), *device.Attributes[deviceattribute.StandardDeviceAttributePCIeRoot].StringValue)
+		assert.NotContains(t, device.Attributes, profiles.InterconnectIslandAttribute)
+	}
+}
+
 func TestEnumerateDevices_ConsistentUUIDs(t *testing.T) {
 	// UUIDs should be consistent for the same node name
-	profile1 := NewProfile("test-node", 2)
-	profile2 := NewProfile("test-node", 2)
+	profile1 := NewProfile("test-node", 2, 0, false, profiles.Topology{})
+	profile2 := NewProfile("test-node", 2, 0, false, profiles.Topology{})
 
 	resources1, err := profile1.EnumerateDevices()
 	require.NoError(t, err)
@@ -149,8 +168,8 @@ func TestEnumerateDevices_ConsistentUUIDs(t *testing.T) {
 }
 
 func TestEnumerateDevices_DifferentNodesHaveDifferentUUIDs(t *testing.T) {
-	profile1 := NewProfile("node-1", 1)
-	profile2 := NewProfile("node-2", 1)
+	profile1 := NewProfile("node-1", 1, 0, false, profiles.Topology{})
+	profile2 := NewProfile("node-2", 1, 0, false, profiles.Topology{})
 
 	resources1, err := profile1.EnumerateDevices()
 	require.NoError(t, err)
@@ -163,8 +182,71 @@ func TestEnumerateDevices_DifferentNodesHaveDifferentUUIDs(t *testing.T) {
 	assert.NotEqual(t, uuid1, uuid2, "Different nodes should have different UUIDs")
 }
 
+func TestEnumerateDevices_VFs(t *testing.T) {
+	profile := NewProfile("test-node", 2, 4, false, profiles.Topology{})
+
+	resources, err := profile.EnumerateDevices()
+	require.NoError(t, err)
+
+	counterSets := make(map[string]resourceapi.CounterSet)
+	devices := make(map[string]resourceapi.Device)
+	for _, slice := range resources.Pools["test-node"].Slices {
+		for _, counterSet := range slice.SharedCounters {
+			counterSets[counterSet.Name] = counterSet
+		}
+		for _, device := range slice.Devices {
+			devices[device.Name] = device
+		}
+	}
+	require.Len(t, counterSets, 2)
+	// Each PF plus its 4 VFs.
+	require.Len(t, devices, 2*5)
+
+	counters := counterSets["nic-1-counters"].Counters
+	assert.Equal(t, resource.MustParse("100G"), counters["ingressBandwidth"].Value)
+	assert.Equal(t, resource.MustParse("100G"), counters["egressBandwidth"].Value)
+	vfs := counters["vfs"].Value
+	assert.Equal(t, int64(4), vfs.Value())
+
+	pf := devices["nic-1"]
+	assert.True(t, *pf.Attributes["physicalFunction"].BoolValue)
+	require.Len(t, pf.ConsumesCounters, 1)
+	assert.Equal(t, counters, pf.ConsumesCounters[0].Counters)
+
+	pfUUID := *pf.Attributes["uuid"].StringValue
+	vfUUIDs := make(map[string]bool)
+	for j := range 4 {
+		vf, ok := devices[fmt.Sprintf("nic-1-vf-%d", j)]
+		require.True(t, ok)
+		assert.False(t, *vf.Attributes["physicalFunction"].BoolValue)
+		assert.Equal(t, int64(j), *vf.Attributes["vfIndex"].IntValue)
+		assert.Equal(t, int64(1), *vf.Attributes["index"].IntValue)
+		assert.Equal(t, pfUUID, *vf.Attributes["pfUUID"].StringValue)
+		vfUUIDs[*vf.Attributes["uuid"].StringValue] = true
+		assert.Nil(t, vf.AllowMultipleAllocations)
+
+		ingress := vf.Capacity["ingressBandwidth"].Value
+		assert.Equal(t, int64(25_000_000_000), ingress.Value())
+		require.Len(t, vf.ConsumesCounters, 1)
+		assert.Equal(t, "nic-1-counters", vf.ConsumesCounters[0].CounterSet)
+		assert.Equal(t, resource.MustParse("1"), vf.ConsumesCounters[0].Counters["vfs"].Value)
+	}
+	assert.Len(t, vfUUIDs, 4)
+	assert.NotContains(t, vfUUIDs, pfUUID)
+}
+
+func TestApplyConfig_VF(t *testing.T) {
+	profile := NewProfile("test-node", 2, 4, false, profiles.Topology{})
+	results := []*resourceapi.DeviceRequestAllocationResult{{Device: "nic-1-vf-3"}}
+
+	edits, err := profile.ApplyConfig(nil, results)
+	require.NoError(t, err)
+	require.Contains(t, edits, "nic-1-vf-3")
+	assert.Equal(t, []string{"NET_DEVICE_1_VF_3_PF=nic-1"}, edits["nic-1-vf-3"].Env)
+}
+
 func TestApplyConfig_Default(t *testing.T) {
-	profile := NewProfile("test-node", 2)
+	profile := NewProfile("test-node", 2, 0, false, profiles.Topology{})
 	results := []*resourceapi.DeviceRequestAllocationResult{
 		{
 			Device: "nic-0",
@@ -186,7 +268,7 @@ func TestApplyConfig_Default(t *testing.T) {
 }
 
 func TestApplyConfig_WithBurstConfig(t *testing.T) {
-	profile := NewProfile("test-node", 2)
+	profile := NewProfile("test-node", 2, 0, false, profiles.Topology{})
 	config := &configapi.NetConfig{
 		BandwidthBurst: &configapi.BandwidthBurstEntry{
 			// Burst values in bits - maximum amount of bits available instantaneously
@@ -220,8 +302,65 @@ func TestApplyConfig_WithBurstConfig(t *testing.T) {
 	assert.Contains(t, edits["nic-1"].Env, "NET_DEVICE_1_EGRESS_RATE=5000000000")
 }
 
+func TestApplyConfig_WithQoSConfig(t *testing.T) {
+	profile := NewProfile("test-node", 2, 0, false, profiles.Topology{})
+	config := &configapi.NetConfig{
+		QoS: &configapi.QoSConfig{
+			TrafficClass: configapi.VideoTrafficClass,
+			MaxRate: &configapi.MaxRateEntry{
+				Ingress: ptr.To(resource.MustParse("20G")),
+				Egress:  ptr.To(resource.MustParse("5G")),
+			},
+			DSCP: ptr.To(int32(34)),
+		},
+	}
+	results := []*resourceapi.DeviceRequestAllocationResult{
+		{
+			Device: "nic-1",
+			ConsumedCapacity: map[resourceapi.QualifiedName]resource.Quantity{
+				"ingressBandwidth": resource.MustParse("10G"),
+				"egressBandwidth":  resource.MustParse("5G"),
+			},
+		},
+	}
+
+	edits, err := profile.ApplyConfig(config, results)
+	require.NoError(t, err)
+	require.Contains(t, edits, "nic-1")
+
+	// The priority defaults to the one of the traffic class.
+	assert.Contains(t, edits["nic-1"].Env, "NET_DEVICE_1_TRAFFIC_CLASS=Video")
+	assert.Contains(t, edits["nic-1"].Env, "NET_DEVICE_1_PRIORITY=5")
+	assert.Contains(t, edits["nic-1"].Env, "NET_DEVICE_1_INGRESS_MAX_RATE=20000000000")
+	assert.Contains(t, edits["nic-1"].Env, "NET_DEVICE_1_EGRESS_MAX_RATE=5000000000")
+	assert.Contains(t, edits["nic-1"].Env, "NET_DEVICE_1_DSCP=34")
+}
+
+func TestApplyConfig_MaxRateBelowConsumedBandwidth(t *testing.T) {
+	profile := NewProfile("test-node", 2, 0, false, profiles.Topology{})
+	config := &configapi.NetConfig{
+		QoS: &configapi.QoSConfig{
+			MaxRate: &configapi.MaxRateEntry{
+				Egress: ptr.To(resource.MustParse("1G")),
+			},
+		},
+	}
+	results := []*resourceapi.DeviceRequestAllocationResult{
+		{
+			Device: "nic-0",
+			ConsumedCapacity: map[resourceapi.QualifiedName]resource.Quantity{
+				"ingressBandwidth": resource.MustParse("10G"),
+				"egressBandwidth":  resource.MustParse("5G"),
+			},
+		},
+	}
+
+	_, err := profile.ApplyConfig(config, results)
+	assert.ErrorContains(t, err, "invalid maxRate.egress: rate 1G is below the consumed bandwidth 5G")
+}
+
 func TestApplyConfig_MultipleDevices(t *testing.T) {
-	profile := NewProfile("test-node", 3)
+	profile := NewProfile("test-node", 3, 0, false, profiles.Topology{})
 	results := []*resourceapi.DeviceRequestAllocationResult{
 		{
 			Device: "nic-0",
@@ -251,7 +390,7 @@ func TestApplyConfig_MultipleDevices(t *testing.T) {
 }
 
 func TestApplyConfig_WithShareID(t *testing.T) {
-	profile := NewProfile("test-node", 1)
+	profile := NewProfile("test-node", 1, 0, false, profiles.Topology{})
 	results := []*resourceapi.DeviceRequestAllocationResult{
 		{
 			Device:  "nic-0",
@@ -273,7 +412,7 @@ func TestApplyConfig_WithShareID(t *testing.T) {
 }
 
 func TestValidate_ValidConfig(t *testing.T) {
-	profile := NewProfile("test-node", 1)
+	profile := NewProfile("test-node", 1, 0, false, profiles.Topology{})
 	config := &configapi.NetConfig{
 		BandwidthBurst: &configapi.BandwidthBurstEntry{
 			IngressBurst: 10000000, // 10Mb in bits
@@ -286,7 +425,7 @@ func TestValidate_ValidConfig(t *testing.T) {
 }
 
 func TestValidate_InvalidConfigType(t *testing.T) {
-	profile := NewProfile("test-node", 1)
+	profile := NewProfile("test-node", 1, 0, false, profiles.Topology{})
 
 	// Test with invalid config - BandwidthBurst should not be nil after normalization
 	config := &configapi.NetConfig{}
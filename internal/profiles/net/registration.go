@@ -0,0 +1,66 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package net
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+// Registration returns the registration of the net profile with its command
+// line flags.
+func Registration() profiles.Registration {
+	var settings struct {
+		vfsPerNIC    int
+		deviceStatus bool
+	}
+	return profiles.Registration{
+		Name: ProfileName,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:        "net-vfs-per-nic",
+				Usage:       "Number of SR-IOV virtual functions per NIC. When set to a value greater than 0, each virtual function is exposed as a separate device consuming shared counters of its NIC. Only relevant for the " + ProfileName + " profile.",
+				Destination: &settings.vfsPerNIC,
+				EnvVars:     []string{"NET_VFS_PER_NIC"},
+				Action: func(_ *cli.Context, vfs int) error {
+					if vfs < 0 {
+						return fmt.Errorf("invalid --net-vfs-per-nic: negative number of virtual functions: %d", vfs)
+					}
+					return nil
+				},
+			},
+			&cli.BoolFlag{
+				Name:        "net-device-status",
+				Usage:       "Deprecated: use --device-status instead. Enable publishing simulated network data (interface name, IPs, hardware address) of allocated devices into ResourceClaim.status.devices[].networkData. Disabled by default. Only relevant for the " + ProfileName + " profile.",
+				Destination: &settings.deviceStatus,
+				EnvVars:     []string{"NET_DEVICE_STATUS"},
+			},
+		},
+		ConfigHandler: Profile{},
+		New: func(opts profiles.Options) (profiles.Profile, error) {
+			if settings.deviceStatus {
+				klog.Warning("--net-device-status is deprecated and will be removed in a future release, use --device-status instead")
+			}
+			return NewProfile(opts.NodeName, opts.NumDevices, settings.vfsPerNIC, opts.DeviceStatus || settings.deviceStatus, opts.Topology), nil
+		},
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package net
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+)
+
+// BuildDeviceStatus implements [profiles.DeviceStatusBuilder]. It returns an
+// [resourceapi.AllocatedDeviceStatus] with simulated network data for the
+// allocated device to publish into ResourceClaim.status.devices[].networkData,
+// and the device's uuid and the bandwidth consumed by the allocation to publish
+// into ResourceClaim.status.devices[].data.
+func (p Profile) BuildDeviceStatus(allocatable map[string]resourceapi.Device, config runtime.Object, result *resourceapi.DeviceRequestAllocationResult) *resourceapi.AllocatedDeviceStatus {
+	if !p.enableDeviceStatus {
+		return nil
+	}
+	logger := klog.Background().WithValues("device", result.Device)
+
+	deviceInfo := make(map[string]resourceapi.DeviceAttribute)
+	device := allocatable[result.Device]
+	if uuid, ok := device.Attributes["uuid"]; ok {
+		deviceInfo["uuid"] = uuid
+	}
+	for _, name := range []resourceapi.QualifiedName{"ingressBandwidth", "egressBandwidth"} {
+		// Shares consume part of the bandwidth, exclusive allocations all
+		// of it.
+		bandwidth, consumed := result.ConsumedCapacity[name]
+		if !consumed {
+			capacity, ok := device.Capacity[name]
+			if !ok {
+				continue
+			}
+			bandwidth = capacity.Value
+		}
+		deviceInfo[string(name)] = resourceapi.DeviceAttribute{IntValue: ptr.To(bandwidth.Value())}
+	}
+
+	jsonBytes, err := json.Marshal(deviceInfo)
+	if err != nil {
+		logger.Error(err, "Failed to marshal device data")
+		jsonBytes = []byte("{}")
+	}
+
+	shareID := (*string)(result.ShareID)
+	return &resourceapi.AllocatedDeviceStatus{
+		Device:      result.Device,
+		Driver:      result.Driver,
+		Pool:        result.Pool,
+		ShareID:     shareID,
+		Data:        &runtime.RawExtension{Raw: jsonBytes},
+		NetworkData: networkData(result.Pool, result.Device, shareID),
+	}
+}
+
+// networkData returns the simulated network configuration of one allocation
+// of a device. The interface name, addresses and hardware address are derived
+// from the pool, device and share ID, so they stay the same each time the
+// claim is prepared and differ between shares of the same device.
+func networkData(pool, device string, shareID *string) *resourceapi.NetworkDeviceData {
+	id := pool + "/" + device
+	if shareID != nil {
+		id += "/" + *shareID
+	}
+	sum := sha256.Sum256([]byte(id))
+
+	// Avoid the network and broadcast addresses of the /16 subnet.
+	ipv4 := net.IPv4(10, sum[0], sum[1], 1+sum[2]%254)
+	ipv6 := net.IP{0xfd, 0x00, sum[3], sum[4], sum[5], sum[6], sum[7], sum[8], 0, 0, 0, 0, sum[9], sum[10], sum[11], 1 + sum[12]%254}
+	// A locally administered unicast address.
+	mac := net.HardwareAddr{0x02, sum[13], sum[14], sum[15], sum[16], sum[17]}
+
+	return &resourceapi.NetworkDeviceData{
+		// Interface names are limited to 15 characters on Linux.
+		InterfaceName: fmt.Sprintf("net%x", sum[18:22]),
+		IPs: []string{
+			fmt.Sprintf("%s/16", ipv4),
+			fmt.Sprintf("%s/64", ipv6),
+		},
+		HardwareAddress: mac.String(),
+	}
+}
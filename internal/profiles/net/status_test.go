@@ -0,0 +1,163 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package net
+
+import (
+	"encoding/json"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+func TestBuildDeviceStatus_Disabled(t *testing.T) {
+	var _ profiles.DeviceStatusBuilder = NewProfile("test-node", 1, 0, true, profiles.Topology{})
+
+	profile := NewProfile("test-node", 1, 0, false, profiles.Topology{})
+	result := &resourceapi.DeviceRequestAllocationResult{
+		Device: "nic-0",
+		Driver: "net.example.com",
+		Pool:   "test-node",
+	}
+
+	got := profile.BuildDeviceStatus(nil, nil, result)
+	assert.Nil(t, got, "BuildDeviceStatus must return nil when device status is disabled")
+}
+
+func TestBuildDeviceStatus_Enabled(t *testing.T) {
+	profile := NewProfile("test-node", 2, 0, true, profiles.Topology{})
+	build := func(device string, shareID *types.UID) *resourceapi.AllocatedDeviceStatus {
+		status := profile.BuildDeviceStatus(nil, nil, &resourceapi.DeviceRequestAllocationResult{
+			Device:  device,
+			Driver:  "net.example.com",
+			Pool:    "test-node",
+			ShareID: shareID,
+		})
+		require.NotNil(t, status)
+		require.NotNil(t, status.NetworkData)
+		return status
+	}
+
+	share := types.UID("4c2c6c1c-54b4-4b4e-a2b3-6d1c0d4d8a51")
+	got := build("nic-0", &share)
+	assert.Equal(t, "nic-0", got.Device)
+	assert.Equal(t, "net.example.com", got.Driver)
+	assert.Equal(t, "test-node", got.Pool)
+	assert.Equal(t, ptr.To(string(share)), got.ShareID)
+
+	data := got.NetworkData
+	assert.Regexp(t, `^net[0-9a-f]{8}$`, data.InterfaceName)
+	require.Len(t, data.IPs, 2)
+	ipv4, err := netip.ParsePrefix(data.IPs[0])
+	require.NoError(t, err)
+	assert.True(t, ipv4.Addr().Is4())
+	assert.Equal(t, 16, ipv4.Bits())
+	ipv6, err := netip.ParsePrefix(data.IPs[1])
+	require.NoError(t, err)
+	assert.True(t, ipv6.Addr().Is6())
+	assert.Equal(t, 64, ipv6.Bits())
+	mac, err := net.ParseMAC(data.HardwareAddress)
+	require.NoError(t, err)
+	assert.Len(t, mac, 6)
+	assert.Equal(t, byte(0x02), mac[0]&0x03, "hardware address must be locally administered unicast")
+
+	// The same share of the same device always gets the same data.
+	assert.Equal(t, data, build("nic-0", &share).NetworkData)
+
+	// Other shares and devices get different data.
+	otherShare := types.UID("9f0e1a47-2f0a-4f4c-8d9b-0c3f8e7d6b12")
+	for name, other := range map[string]*resourceapi.AllocatedDeviceStatus{
+		"other share":  build("nic-0", &otherShare),
+		"no share":     build("nic-0", nil),
+		"other device": build("nic-1", &share),
+	} {
+		assert.NotEqual(t, data.InterfaceName, other.NetworkData.InterfaceName, name)
+		assert.NotEqual(t, data.IPs, other.NetworkData.IPs, name)
+		assert.NotEqual(t, data.HardwareAddress, other.NetworkData.HardwareAddress, name)
+	}
+}
+
+func TestBuildDeviceStatus_Data(t *testing.T) {
+	profile := NewProfile("test-node", 1, 2, true, profiles.Topology{})
+	resources, err := profile.EnumerateDevices()
+	require.NoError(t, err)
+	allocatable := make(map[string]resourceapi.Device)
+	for _, slice := range resources.Pools["test-node"].Slices {
+		for _, device := range slice.Devices {
+			allocatable[device.Name] = device
+		}
+	}
+
+	tests := map[string]struct {
+		result   resourceapi.DeviceRequestAllocationResult
+		expected map[string]resourceapi.DeviceAttribute
+	}{
+		"share": {
+			result: resourceapi.DeviceRequestAllocationResult{
+				Device:  "nic-0",
+				ShareID: ptr.To(types.UID("share-1")),
+				ConsumedCapacity: map[resourceapi.QualifiedName]resource.Quantity{
+					"ingressBandwidth": resource.MustParse("10G"),
+					"egressBandwidth":  resource.MustParse("5G"),
+				},
+			},
+			expected: map[string]resourceapi.DeviceAttribute{
+				"uuid":             allocatable["nic-0"].Attributes["uuid"],
+				"ingressBandwidth": {IntValue: ptr.To(int64(10_000_000_000))},
+				"egressBandwidth":  {IntValue: ptr.To(int64(5_000_000_000))},
+			},
+		},
+		"virtual function": {
+			result: resourceapi.DeviceRequestAllocationResult{
+				Device: "nic-0-vf-1",
+			},
+			expected: map[string]resourceapi.DeviceAttribute{
+				"uuid":             allocatable["nic-0-vf-1"].Attributes["uuid"],
+				"ingressBandwidth": {IntValue: ptr.To(int64(50_000_000_000))},
+				"egressBandwidth":  {IntValue: ptr.To(int64(50_000_000_000))},
+			},
+		},
+		"unknown device": {
+			result: resourceapi.DeviceRequestAllocationResult{
+				Device: "nic-9",
+			},
+			expected: map[string]resourceapi.DeviceAttribute{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.result.Driver = "net.example.com"
+			test.result.Pool = "test-node"
+			got := profile.BuildDeviceStatus(allocatable, nil, &test.result)
+			require.NotNil(t, got)
+			require.NotNil(t, got.Data)
+
+			var data map[string]resourceapi.DeviceAttribute
+			require.NoError(t, json.Unmarshal(got.Data.Raw, &data))
+			assert.Equal(t, test.expected, data)
+		})
+	}
+}
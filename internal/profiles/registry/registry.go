@@ -0,0 +1,45 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package registry registers the device profiles of this repository. The
+// kubelet plugin and the admission webhook both serve the profiles of the
+// registry it returns.
+package registry
+
+import (
+	"sigs.k8s.io/dra-example-driver/internal/profiles/cpu"
+	"sigs.k8s.io/dra-example-driver/internal/profiles/fpga"
+	"sigs.k8s.io/dra-example-driver/internal/profiles/gpu"
+	"sigs.k8s.io/dra-example-driver/internal/profiles/inventory"
+	"sigs.k8s.io/dra-example-driver/internal/profiles/memory"
+	"sigs.k8s.io/dra-example-driver/internal/profiles/net"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+// New returns a registry of the device profiles of this repository. The gpu
+// profile is the default. Each call returns a registry with its own flags.
+func New() *profiles.Registry {
+	registry := profiles.NewRegistry()
+	registry.MustRegister(
+		gpu.Registration(),
+		cpu.Registration(),
+		net.Registration(),
+		memory.Registration(),
+		fpga.Registration(),
+		inventory.Registration(),
+	)
+	return registry
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+func TestNames(t *testing.T) {
+	registry := New()
+	assert.Equal(t, []string{"cpu", "fpga", "gpu", "inventory", "memory", "net"}, registry.Names())
+	assert.Equal(t, "gpu", registry.Default())
+}
+
+func TestNew(t *testing.T) {
+	registry := New()
+	for _, name := range registry.Names() {
+		profile, err := registry.New(name, profiles.Options{NodeName: "test-node"})
+		require.NoError(t, err, name)
+		assert.NotNil(t, profile, name)
+	}
+
+	_, err := registry.New("tpu", profiles.Options{})
+	assert.EqualError(t, err, `invalid device profile "tpu", valid profiles are ["cpu" "fpga" "gpu" "inventory" "memory" "net"]`)
+}
@@ -0,0 +1,233 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// adminServer serves an HTTP API to simulate hardware changes while the
+// driver is running:
+//
+//	GET    /devices         lists advertised devices and orphaned claims
+//	POST   /devices/{name}  hot-plugs a device, optionally described by a
+//	                        JSON-encoded resource.k8s.io/v1 Device body
+//	DELETE /devices/{name}  hot-unplugs a device
+//
+// Every change republishes the driver's ResourceSlices. When the process
+// serves several drivers, /devices refers to the first one and the devices
+// of each driver are available under /drivers/{driver}/devices.
+type adminServer struct {
+	httpServer *http.Server
+	addr       string
+	wg         sync.WaitGroup
+}
+
+type unplugResponse struct {
+	// PreparedClaims lists the claims which are still prepared on the
+	// removed device.
+	PreparedClaims []types.UID `json:"preparedClaims,omitempty"`
+}
+
+// startAdminServer starts the admin HTTP server listening on address. When
+// port is negative, the server is not started and (nil, nil) is returned.
+func startAdminServer(ctx context.Context, address string, port int, drivers []*Driver) (*adminServer, error) {
+	log := klog.FromContext(ctx)
+
+	if port < 0 {
+		return nil, nil
+	}
+
+	addr := net.JoinHostPort(address, strconv.Itoa(port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen for admin server at %s: %w", addr, err)
+	}
+
+	server := &adminServer{
+		httpServer: &http.Server{
+			Handler: newAdminMux(drivers),
+			BaseContext: func(net.Listener) context.Context {
+				return ctx
+			},
+		},
+		addr: listener.Addr().String(),
+	}
+
+	server.wg.Add(1)
+	go func() {
+		defer server.wg.Done()
+		log.Info("starting admin server", "addr", listener.Addr().String())
+		if err := server.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Error(err, "failed to serve admin API", "addr", addr)
+		}
+	}()
+
+	return server, nil
+}
+
+func newAdminMux(drivers []*Driver) *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, prefix := range []string{"", "/drivers/{driver}"} {
+		mux.HandleFunc("GET "+prefix+"/devices", func(w http.ResponseWriter, r *http.Request) {
+			d, ok := lookupAdminDriver(w, r, drivers)
+			if !ok {
+				return
+			}
+			report, err := d.state.Devices()
+			if err != nil {
+				writeAdminError(w, r, err)
+				return
+			}
+			writeAdminResponse(w, r, http.StatusOK, report)
+		})
+		mux.HandleFunc("POST "+prefix+"/devices/{name}", func(w http.ResponseWriter, r *http.Request) {
+			d, ok := lookupAdminDriver(w, r, drivers)
+			if !ok {
+				return
+			}
+			name := r.PathValue("name")
+			device, err := decodeDevice(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := d.state.PlugDevice(name, device); err != nil {
+				writeAdminError(w, r, err)
+				return
+			}
+			klog.FromContext(r.Context()).Info("Hot-plugged device", "driver", d.state.driverName, "device", name)
+			if err := d.publishResources(r.Context()); err != nil {
+				writeAdminError(w, r, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+		mux.HandleFunc("DELETE "+prefix+"/devices/{name}", func(w http.ResponseWriter, r *http.Request) {
+			d, ok := lookupAdminDriver(w, r, drivers)
+			if !ok {
+				return
+			}
+			name := r.PathValue("name")
+			claims, err := d.state.UnplugDevice(name)
+			if err != nil {
+				writeAdminError(w, r, err)
+				return
+			}
+			klog.FromContext(r.Context()).Info("Hot-unplugged device", "driver", d.state.driverName, "device", name, "preparedClaims", claims)
+			if err := d.publishResources(r.Context()); err != nil {
+				writeAdminError(w, r, err)
+				return
+			}
+			writeAdminResponse(w, r, http.StatusOK, unplugResponse{PreparedClaims: claims})
+		})
+	}
+	return mux
+}
+
+// lookupAdminDriver returns the driver named in the request path, or the
+// first driver for requests without a driver. It responds with an error and
+// returns false when the driver is unknown.
+func lookupAdminDriver(w http.ResponseWriter, r *http.Request, drivers []*Driver) (*Driver, bool) {
+	name := r.PathValue("driver")
+	if name == "" {
+		return drivers[0], true
+	}
+	for _, d := range drivers {
+		if d.state.driverName == name {
+			return d, true
+		}
+	}
+	http.Error(w, fmt.Sprintf("driver %q not found", name), http.StatusNotFound)
+	return nil, false
+}
+
+// decodeDevice decodes an optional device from a request body. An empty body
+// yields a nil device.
+func decodeDevice(body io.Reader) (*resourceapi.Device, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	device := new(resourceapi.Device)
+	if err := json.Unmarshal(data, device); err != nil {
+		return nil, fmt.Errorf("decode device: %w", err)
+	}
+	return device, nil
+}
+
+func writeAdminResponse(w http.ResponseWriter, r *http.Request, status int, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		writeAdminError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(data); err != nil {
+		klog.FromContext(r.Context()).Error(err, "failed to write admin response")
+	}
+}
+
+func writeAdminError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, errDeviceNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, errDeviceExists):
+		status = http.StatusConflict
+	default:
+		klog.FromContext(r.Context()).Error(err, "admin request failed", "method", r.Method, "path", r.URL.Path)
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// Addr returns the address the admin server is listening on.
+func (s *adminServer) Addr() string {
+	if s == nil {
+		return ""
+	}
+	return s.addr
+}
+
+// Stop gracefully shuts down the admin server.
+func (s *adminServer) Stop(ctx context.Context) error {
+	if s == nil || s.httpServer == nil {
+		return nil
+	}
+
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		return fmt.Errorf("shutdown admin server: %w", err)
+	}
+	s.wg.Wait()
+	return nil
+}
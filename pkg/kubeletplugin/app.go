@@ -0,0 +1,402 @@
+/*
+ * Copyright 2023 The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	coreclientset "k8s.io/client-go/kubernetes"
+	"k8s.io/dynamic-resource-allocation/kubeletplugin"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/dra-example-driver/pkg/flags"
+	"sigs.k8s.io/dra-example-driver/pkg/metrics"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+const (
+	DriverPluginCheckpointFile = "checkpoint.json"
+)
+
+type Flags struct {
+	kubeClientConfig flags.KubeClientConfig
+	loggingConfig    *flags.LoggingConfig
+
+	nodeName                      string
+	cdiRoot                       string
+	numDevices                    int
+	kubeletRegistrarDirectoryPath string
+	kubeletPluginsDirectoryPath   string
+	healthcheckPort               int
+	metricsPort                   int
+	adminPort                     int
+	adminAddress                  string
+	profile                       string
+	driverName                    string
+	deviceStatus                  bool
+	podUID                        string
+	topology                      profiles.Topology
+	prepareWorkers                int
+	reconcileInterval             time.Duration
+}
+
+type Config struct {
+	flags         *Flags
+	coreclient    coreclientset.Interface
+	cancelMainCtx func(error)
+
+	profile profiles.Profile
+}
+
+// profileOptions returns the options common to all device profiles set by
+// flags.
+func (f Flags) profileOptions() profiles.Options {
+	return profiles.Options{
+		NodeName:     f.nodeName,
+		DriverName:   f.driverName,
+		DeviceStatus: f.deviceStatus,
+		NumDevices:   f.numDevices,
+		Topology:     f.topology,
+		StateDir:     f.driverPluginPath(),
+	}
+}
+
+// newConfigs returns the configuration of each driver served by the
+// process, one per device profile.
+func newConfigs(flags *Flags, registry *profiles.Registry, coreclient coreclientset.Interface) ([]*Config, error) {
+	profileNames := strings.Split(flags.profile, ",")
+	if len(profileNames) > 1 && flags.driverName != "" {
+		return nil, fmt.Errorf("a driver name cannot be set when serving several device profiles %q", profileNames)
+	}
+
+	var configs []*Config
+	seen := sets.New[string]()
+	for _, profileName := range profileNames {
+		if seen.Has(profileName) {
+			return nil, fmt.Errorf("duplicate device profile %q", profileName)
+		}
+		seen.Insert(profileName)
+
+		profileFlags := *flags
+		profileFlags.profile = profileName
+		if profileFlags.driverName == "" {
+			profileFlags.driverName = profileName + ".example.com"
+		}
+		profile, err := registry.New(profileName, profileFlags.profileOptions())
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, &Config{
+			flags:      &profileFlags,
+			coreclient: coreclient,
+			profile:    profile,
+		})
+	}
+	return configs, nil
+}
+
+func (c Config) DriverPluginPath() string {
+	return c.flags.driverPluginPath()
+}
+
+// driverPluginPath returns the directory in which the driver keeps its
+// node-local state.
+func (f Flags) driverPluginPath() string {
+	return filepath.Join(f.kubeletPluginsDirectoryPath, f.driverName)
+}
+
+// NewApp returns the kubelet plugin serving the device profiles of registry.
+// The flags of the registered profiles are added to the plugin's own flags,
+// and the profile registered first is served by default.
+func NewApp(registry *profiles.Registry) *cli.App {
+	flags := &Flags{
+		loggingConfig: flags.NewLoggingConfig(),
+	}
+	cliFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "node-name",
+			Usage:       "The name of the node to be worked on.",
+			Required:    true,
+			Destination: &flags.nodeName,
+			EnvVars:     []string{"NODE_NAME"},
+		},
+		&cli.StringFlag{
+			Name:        "cdi-root",
+			Usage:       "Absolute path to the directory where CDI files will be generated.",
+			Value:       "/etc/cdi",
+			Destination: &flags.cdiRoot,
+			EnvVars:     []string{"CDI_ROOT"},
+		},
+		&cli.IntFlag{
+			Name:        "num-devices",
+			Usage:       "The number of devices to be generated by device profiles which do not set it with their own flags.",
+			Value:       8,
+			Destination: &flags.numDevices,
+			EnvVars:     []string{"NUM_DEVICES"},
+		},
+		&cli.StringFlag{
+			Name:        "kubelet-registrar-directory-path",
+			Usage:       "Absolute path to the directory where kubelet stores plugin registrations.",
+			Value:       kubeletplugin.KubeletRegistryDir,
+			Destination: &flags.kubeletRegistrarDirectoryPath,
+			EnvVars:     []string{"KUBELET_REGISTRAR_DIRECTORY_PATH"},
+		},
+		&cli.StringFlag{
+			Name:        "kubelet-plugins-directory-path",
+			Usage:       "Absolute path to the directory where kubelet stores plugin data.",
+			Value:       kubeletplugin.KubeletPluginsDir,
+			Destination: &flags.kubeletPluginsDirectoryPath,
+			EnvVars:     []string{"KUBELET_PLUGINS_DIRECTORY_PATH"},
+		},
+		&cli.IntFlag{
+			Name:        "healthcheck-port",
+			Usage:       "Port to start a gRPC healthcheck service. When positive, a literal port number. When zero, a random port is allocated. When negative, the healthcheck service is disabled.",
+			Value:       -1,
+			Destination: &flags.healthcheckPort,
+			EnvVars:     []string{"HEALTHCHECK_PORT"},
+		},
+		&cli.IntFlag{
+			Name:        "metrics-port",
+			Usage:       "Port to expose Prometheus metrics at /metrics. When positive, a literal port number. When zero, a random port is allocated. When negative, metrics are disabled.",
+			Value:       -1,
+			Destination: &flags.metricsPort,
+			EnvVars:     []string{"METRICS_PORT"},
+		},
+		&cli.IntFlag{
+			Name:        "admin-port",
+			Usage:       "Port to expose an HTTP API at /devices for hot-plugging and hot-unplugging simulated devices. When positive, a literal port number. When zero, a random port is allocated. When negative, the admin API is disabled.",
+			Value:       -1,
+			Destination: &flags.adminPort,
+			EnvVars:     []string{"ADMIN_PORT"},
+		},
+		&cli.StringFlag{
+			Name:        "admin-address",
+			Usage:       "IP address the admin API listens on. The API is not authenticated, so it only listens on the loopback interface by default, which is reachable through `kubectl port-forward`. An empty address listens on all interfaces.",
+			Value:       "127.0.0.1",
+			Destination: &flags.adminAddress,
+			EnvVars:     []string{"ADMIN_ADDRESS"},
+		},
+		&cli.IntFlag{
+			Name:        "prepare-workers",
+			Usage:       "Maximum number of ResourceClaims prepared or unprepared concurrently by each driver.",
+			Value:       8,
+			Destination: &flags.prepareWorkers,
+			EnvVars:     []string{"PREPARE_WORKERS"},
+			Action: func(_ *cli.Context, workers int) error {
+				if workers < 1 {
+					return fmt.Errorf("invalid --prepare-workers %d: must be at least 1", workers)
+				}
+				return nil
+			},
+		},
+		&cli.DurationFlag{
+			Name:        "reconcile-interval",
+			Usage:       "Interval at which prepared ResourceClaims are checked against the API server. Claims which were deleted or deallocated without being unprepared are unprepared. Claims are always checked once at startup, a non-positive interval disables the periodic check.",
+			Value:       5 * time.Minute,
+			Destination: &flags.reconcileInterval,
+			EnvVars:     []string{"RECONCILE_INTERVAL"},
+		},
+		&cli.StringFlag{
+			Name:        "device-profile",
+			Usage:       fmt.Sprintf("Comma-separated list of device profiles. Each profile is served as a separate DRA driver with its own driver name, CDI class and checkpoint. Valid values are %q.", registry.Names()),
+			Value:       registry.Default(),
+			Destination: &flags.profile,
+			EnvVars:     []string{"DEVICE_PROFILE"},
+		},
+		&cli.StringFlag{
+			Name:        "driver-name",
+			Usage:       "Name of the DRA driver. Its default is derived from the device profile. Must not be set when serving several device profiles, whose driver names are always derived from the profiles.",
+			Destination: &flags.driverName,
+			EnvVars:     []string{"DRIVER_NAME"},
+		},
+		&cli.StringFlag{
+			Name:        "pod-uid",
+			Usage:       "UID of the pod (used for seamless upgrades to create unique socket names).",
+			Destination: &flags.podUID,
+			EnvVars:     []string{"POD_UID"},
+		},
+		&cli.BoolFlag{
+			Name:        "device-status",
+			Usage:       "Enable publishing the status of allocated devices into ResourceClaim.status.devices[] for all device profiles supporting it, e.g. model, uuid and driverVersion of GPUs. Disabled by default.",
+			Destination: &flags.deviceStatus,
+			EnvVars:     []string{"DEVICE_STATUS"},
+		},
+		&cli.IntFlag{
+			Name:        "topology-numa-nodes",
+			Usage:       "Number of NUMA nodes the simulated devices are attached to. When greater than 0, devices advertise their NUMA node and PCIe root. Only relevant for device profiles with a topology.",
+			Destination: &flags.topology.NUMANodes,
+			EnvVars:     []string{"TOPOLOGY_NUMA_NODES"},
+		},
+		&cli.IntFlag{
+			Name:        "topology-pcie-roots-per-numa-node",
+			Usage:       "Number of PCIe roots per NUMA node the simulated devices are distributed across. Only relevant for device profiles with a topology.",
+			Value:       1,
+			Destination: &flags.topology.PCIeRootsPerNUMANode,
+			EnvVars:     []string{"TOPOLOGY_PCIE_ROOTS_PER_NUMA_NODE"},
+		},
+		&cli.IntFlag{
+			Name:        "topology-island-size",
+			Usage:       "Number of devices per interconnect island. When greater than 0, devices advertise the island they belong to. Only relevant for device profiles with a topology.",
+			Destination: &flags.topology.IslandSize,
+			EnvVars:     []string{"TOPOLOGY_ISLAND_SIZE"},
+		},
+	}
+	cliFlags = append(cliFlags, registry.Flags()...)
+	cliFlags = append(cliFlags, flags.kubeClientConfig.Flags()...)
+	cliFlags = append(cliFlags, flags.loggingConfig.Flags()...)
+
+	app := &cli.App{
+		Name:            "dra-example-kubeletplugin",
+		Usage:           "dra-example-kubeletplugin implements a DRA driver plugin.",
+		ArgsUsage:       " ",
+		HideHelpCommand: true,
+		Flags:           cliFlags,
+		Before: func(c *cli.Context) error {
+			if c.Args().Len() > 0 {
+				return fmt.Errorf("arguments not supported: %v", c.Args().Slice())
+			}
+			return flags.loggingConfig.Apply()
+		},
+		Action: func(c *cli.Context) error {
+			ctx := c.Context
+			clientSets, err := flags.kubeClientConfig.NewClientSets()
+			if err != nil {
+				return fmt.Errorf("create client: %w", err)
+			}
+
+			if err := flags.topology.Validate(); err != nil {
+				return fmt.Errorf("invalid topology: %w", err)
+			}
+			configs, err := newConfigs(flags, registry, clientSets.Core)
+			if err != nil {
+				return err
+			}
+
+			return RunPlugin(ctx, configs)
+		},
+	}
+
+	return app
+}
+
+// RunPlugin runs one driver per configuration until the context is canceled
+// or the process receives a signal. The drivers share the healthcheck service,
+// the metrics server and the admin server, which are configured by the flags
+// of the first configuration.
+func RunPlugin(ctx context.Context, configs []*Config) error {
+	logger := klog.FromContext(ctx)
+	flags := configs[0].flags
+
+	for _, config := range configs {
+		err := os.MkdirAll(config.DriverPluginPath(), 0750)
+		if err != nil {
+			return err
+		}
+	}
+
+	info, err := os.Stat(flags.cdiRoot)
+	switch {
+	case err != nil && os.IsNotExist(err):
+		err := os.MkdirAll(flags.cdiRoot, 0750)
+		if err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case !info.IsDir():
+		return fmt.Errorf("path for cdi file generation is not a directory: '%v'", err)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer stop()
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	metricsServer, err := metrics.StartServer(ctx, flags.metricsPort)
+	if err != nil {
+		return fmt.Errorf("start metrics server: %w", err)
+	}
+	defer func(ctx context.Context) {
+		shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 5*time.Second)
+		defer shutdownCancel()
+		if err := metricsServer.Stop(shutdownCtx); err != nil {
+			logger.Error(err, "failed to stop metrics server")
+		}
+	}(context.WithoutCancel(ctx))
+
+	var drivers []*Driver
+	defer func() {
+		for _, driver := range drivers {
+			if err := driver.Shutdown(logger); err != nil {
+				logger.Error(err, "Unable to cleanly shutdown driver", "driverName", driver.state.driverName)
+			}
+		}
+	}()
+	for _, config := range configs {
+		config.cancelMainCtx = cancel
+		driver, err := NewDriver(klog.NewContext(ctx, klog.LoggerWithValues(logger, "driverName", config.flags.driverName)), config)
+		if err != nil {
+			return fmt.Errorf("start driver %s: %w", config.flags.driverName, err)
+		}
+		drivers = append(drivers, driver)
+	}
+
+	healthcheck, err := startHealthcheck(ctx, flags.healthcheckPort, configs)
+	if err != nil {
+		return fmt.Errorf("start healthcheck: %w", err)
+	}
+	defer func() {
+		if healthcheck != nil {
+			healthcheck.Stop(logger)
+		}
+	}()
+
+	admin, err := startAdminServer(ctx, flags.adminAddress, flags.adminPort, drivers)
+	if err != nil {
+		return fmt.Errorf("start admin server: %w", err)
+	}
+	defer func(ctx context.Context) {
+		shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 5*time.Second)
+		defer shutdownCancel()
+		if err := admin.Stop(shutdownCtx); err != nil {
+			logger.Error(err, "failed to stop admin server")
+		}
+	}(context.WithoutCancel(ctx))
+
+	<-ctx.Done()
+	// restore default signal behavior as soon as possible in case graceful
+	// shutdown gets stuck.
+	stop()
+	if err := context.Cause(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		// A canceled context is the normal case here when the process receives
+		// a signal. Only log the error for more interesting cases.
+		logger.Error(err, "error from context")
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+
+	"sigs.k8s.io/dra-example-driver/internal/profiles/registry"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+func TestNewConfigs(t *testing.T) {
+	type driverConfig struct {
+		profile    string
+		driverName string
+		pluginPath string
+	}
+
+	tests := map[string]struct {
+		profile     string
+		driverName  string
+		expected    []driverConfig
+		expectedErr string
+	}{
+		"single profile": {
+			profile: "gpu",
+			expected: []driverConfig{
+				{"gpu", "gpu.example.com", "/plugins/gpu.example.com"},
+			},
+		},
+		"single profile with driver name": {
+			profile:    "net",
+			driverName: "nic.example.org",
+			expected: []driverConfig{
+				{"net", "nic.example.org", "/plugins/nic.example.org"},
+			},
+		},
+		"several profiles": {
+			profile: "gpu,net,cpu",
+			expected: []driverConfig{
+				{"gpu", "gpu.example.com", "/plugins/gpu.example.com"},
+				{"net", "net.example.com", "/plugins/net.example.com"},
+				{"cpu", "cpu.example.com", "/plugins/cpu.example.com"},
+			},
+		},
+		"several profiles with driver name": {
+			profile:     "gpu,net",
+			driverName:  "gpu.example.com",
+			expectedErr: "a driver name cannot be set when serving several device profiles",
+		},
+		"duplicate profile": {
+			profile:     "gpu,gpu",
+			expectedErr: `duplicate device profile "gpu"`,
+		},
+		"invalid profile": {
+			profile:     "gpu,tpu",
+			expectedErr: `invalid device profile "tpu"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			flags := &Flags{
+				nodeName:                    "test-node",
+				profile:                     test.profile,
+				driverName:                  test.driverName,
+				kubeletPluginsDirectoryPath: "/plugins",
+				numDevices:                  2,
+			}
+			configs, err := newConfigs(flags, registry.New(), nil)
+			if test.expectedErr != "" {
+				require.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var actual []driverConfig
+			for _, config := range configs {
+				actual = append(actual, driverConfig{
+					profile:    config.flags.profile,
+					driverName: config.flags.driverName,
+					pluginPath: filepath.ToSlash(config.DriverPluginPath()),
+				})
+				require.NotNil(t, config.profile)
+			}
+			assert.Equal(t, test.expected, actual)
+			// The shared flags are not modified.
+			assert.Equal(t, test.profile, flags.profile)
+			assert.Equal(t, test.driverName, flags.driverName)
+		})
+	}
+}
+
+func TestNewApp(t *testing.T) {
+	registry := profiles.NewRegistry()
+	registry.MustRegister(profiles.Registration{
+		Name: "tpu",
+		Flags: []cli.Flag{
+			&cli.IntFlag{Name: "tpu-cores"},
+		},
+		ConfigHandler: profiles.NoopConfigHandler{},
+		New: func(opts profiles.Options) (profiles.Profile, error) {
+			return nil, nil
+		},
+	})
+
+	app := NewApp(registry)
+	flags := make(map[string]cli.Flag)
+	for _, flag := range app.Flags {
+		flags[flag.Names()[0]] = flag
+	}
+	assert.Contains(t, flags, "tpu-cores", "the flags of registered profiles must be added")
+	require.Contains(t, flags, "device-profile")
+	assert.Equal(t, "tpu", flags["device-profile"].(*cli.StringFlag).Value, "the profile registered first must be the default")
+	require.Contains(t, flags, "admin-address")
+	assert.Equal(t, "127.0.0.1", flags["admin-address"].(*cli.StringFlag).Value, "the unauthenticated admin API must only listen on the loopback interface by default")
+}
@@ -14,11 +14,12 @@
  * limitations under the License.
  */
 
-package main
+package kubeletplugin
 
 import (
 	"fmt"
 	"os"
+	"path"
 	"regexp"
 	"strings"
 
@@ -26,7 +27,7 @@ import (
 	cdiparser "tags.cncf.io/container-device-interface/pkg/parser"
 	cdispec "tags.cncf.io/container-device-interface/specs-go"
 
-	"sigs.k8s.io/dra-example-driver/internal/profiles/helpers"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles/helpers"
 )
 
 const cdiCommonDeviceName = "common"
@@ -34,12 +35,16 @@ const cdiCommonDeviceName = "common"
 var nonWord = regexp.MustCompile(`[^a-zA-Z0-9]+`)
 
 type CDIHandler struct {
-	cache      *cdiapi.Cache
-	driverName string
-	class      string
+	cache            *cdiapi.Cache
+	driverName       string
+	class            string
+	hostInventoryDir string
 }
 
-func NewCDIHandler(root string, driverName, class string) (*CDIHandler, error) {
+// NewCDIHandler returns a handler for the CDI spec files of a driver in root.
+// Devices with admin access get the host inventory in hostInventoryDir
+// mounted into their containers.
+func NewCDIHandler(root string, driverName, class, hostInventoryDir string) (*CDIHandler, error) {
 	cache, err := cdiapi.NewCache(
 		cdiapi.WithSpecDirs(root),
 	)
@@ -47,9 +52,10 @@ func NewCDIHandler(root string, driverName, class string) (*CDIHandler, error) {
 		return nil, fmt.Errorf("unable to create a new CDI cache: %w", err)
 	}
 	handler := &CDIHandler{
-		cache:      cache,
-		driverName: driverName,
-		class:      class,
+		cache:            cache,
+		driverName:       driverName,
+		class:            class,
+		hostInventoryDir: hostInventoryDir,
 	}
 
 	return handler, nil
@@ -104,7 +110,11 @@ func (cdi *CDIHandler) CreateClaimSpecFile(claimUID string, devices PreparedDevi
 			},
 		}
 
-		// If this device has admin access, then here is where to inject host hardware information
+		// Devices with admin access get a read-only view of all devices of
+		// the driver on the node and the claims prepared for them.
+		if device.AdminAccess {
+			claimEdits.Append(cdi.hostInventoryEdits())
+		}
 
 		claimEdits.Append(device.ContainerEdits)
 
@@ -125,6 +135,27 @@ func (cdi *CDIHandler) CreateClaimSpecFile(claimUID string, devices PreparedDevi
 	return cdi.cache.WriteSpec(spec, specName)
 }
 
+// hostInventoryEdits mounts the host inventory read-only into the container
+// and points to it with an environment variable.
+func (cdi *CDIHandler) hostInventoryEdits() *cdiapi.ContainerEdits {
+	if cdi.hostInventoryDir == "" {
+		return nil
+	}
+	containerDir := path.Join(hostInventoryContainerRoot, cdi.driverName)
+	return &cdiapi.ContainerEdits{
+		ContainerEdits: &cdispec.ContainerEdits{
+			Env: []string{
+				fmt.Sprintf("%s_HOST_INVENTORY_FILE=%s", strings.ToUpper(cdi.class), path.Join(containerDir, HostInventoryFile)),
+			},
+			Mounts: []*cdispec.Mount{{
+				HostPath:      cdi.hostInventoryDir,
+				ContainerPath: containerDir,
+				Options:       []string{"ro", "nosuid", "nodev", "bind"},
+			}},
+		},
+	}
+}
+
 func (cdi *CDIHandler) DeleteClaimSpecFile(claimUID string) error {
 	specName := cdiapi.GenerateTransientSpecName(cdi.vendor(), cdi.class, claimUID)
 	return cdi.cache.RemoveSpec(specName)
@@ -0,0 +1,180 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	checkpointapi "sigs.k8s.io/dra-example-driver/internal/api/checkpoint"
+)
+
+func TestReadWriteCheckpointRoundtrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DriverPluginCheckpointFile)
+
+	decoder, encoder, err := checkpointSerializer()
+	if err != nil {
+		t.Fatal("failed to initialize checkpoint serializer:", err)
+	}
+
+	checkpoint, err := readCheckpoint(path, decoder)
+	assert.NoError(t, err)
+	assert.Equal(t, new(checkpointapi.Checkpoint), checkpoint)
+
+	// "prepare" some claims
+	updatedCheckpoint := &checkpointapi.Checkpoint{
+		PreparedClaims: []checkpointapi.PreparedClaim{
+			{UID: types.UID("123")},
+			{
+				UID: types.UID("456"),
+				Devices: []checkpointapi.PreparedDevice{
+					{PoolName: "node", DeviceName: "dev1"},
+					{PoolName: "node", DeviceName: "dev2", ShareID: ptr.To(types.UID("share"))},
+				},
+			},
+		},
+		DeviceStates: []checkpointapi.DeviceState{
+			{PoolName: "node", DeviceName: "dev1", State: "state"},
+		},
+	}
+	err = writeCheckpoint(path, encoder, updatedCheckpoint)
+	require.NoError(t, err)
+
+	checkpoint, err = readCheckpoint(path, decoder)
+	require.NoError(t, err)
+	assert.Equal(t, updatedCheckpoint, checkpoint)
+
+	// "unprepare" those claims, device states outlive them
+	updatedCheckpoint = &checkpointapi.Checkpoint{
+		PreparedClaims: nil,
+		DeviceStates:   updatedCheckpoint.DeviceStates,
+	}
+	err = writeCheckpoint(path, encoder, updatedCheckpoint)
+	require.NoError(t, err)
+
+	checkpoint, err = readCheckpoint(path, decoder)
+	require.NoError(t, err)
+	assert.Equal(t, updatedCheckpoint, checkpoint)
+}
+
+func TestCheckpointStore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DriverPluginCheckpointFile)
+	decoder, encoder, err := checkpointSerializer()
+	require.NoError(t, err)
+
+	// The checkpoint of the previous run is recovered.
+	recovered := &checkpointapi.Checkpoint{
+		PreparedClaims: []checkpointapi.PreparedClaim{{UID: "claim-a"}},
+	}
+	require.NoError(t, writeCheckpoint(path, encoder, recovered))
+	store, err := newCheckpointStore(path)
+	require.NoError(t, err)
+	checkpoint, err := store.read()
+	require.NoError(t, err)
+	assert.Equal(t, recovered, checkpoint)
+
+	// Readers get a copy.
+	checkpoint.PreparedClaims = nil
+	checkpoint, err = store.read()
+	require.NoError(t, err)
+	assert.Equal(t, recovered, checkpoint)
+
+	// Changes are written through.
+	require.NoError(t, store.update(func(checkpoint *checkpointapi.Checkpoint) {
+		checkpoint.PreparedClaims = append(checkpoint.PreparedClaims, checkpointapi.PreparedClaim{UID: "claim-b"})
+	}))
+	expected := &checkpointapi.Checkpoint{
+		PreparedClaims: []checkpointapi.PreparedClaim{{UID: "claim-a"}, {UID: "claim-b"}},
+	}
+	onDisk, err := readCheckpoint(path, decoder)
+	require.NoError(t, err)
+	assert.Equal(t, expected, onDisk)
+	checkpoint, err = store.read()
+	require.NoError(t, err)
+	assert.Equal(t, expected, checkpoint)
+
+	// A change which cannot be written is dropped.
+	require.NoError(t, os.RemoveAll(dir))
+	require.Error(t, store.update(func(checkpoint *checkpointapi.Checkpoint) {
+		checkpoint.PreparedClaims = nil
+	}))
+	checkpoint, err = store.read()
+	require.NoError(t, err)
+	assert.Equal(t, expected, checkpoint)
+}
+
+func TestCheckpointStore_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DriverPluginCheckpointFile)
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+
+	store, err := newCheckpointStore(path)
+	require.NoError(t, err)
+	_, err = store.read()
+	require.ErrorContains(t, err, "unmarshal JSON from")
+	require.ErrorContains(t, store.update(func(*checkpointapi.Checkpoint) {}), "unmarshal JSON from")
+
+	// Replacing the checkpoint recovers the store.
+	require.NoError(t, store.write(new(checkpointapi.Checkpoint)))
+	checkpoint, err := store.read()
+	require.NoError(t, err)
+	assert.Equal(t, new(checkpointapi.Checkpoint), checkpoint)
+}
+
+// BenchmarkCheckpointRead compares reading the checkpoint of a busy node from
+// memory, as done when preparing claims, with decoding it from disk.
+func BenchmarkCheckpointRead(b *testing.B) {
+	path := filepath.Join(b.TempDir(), DriverPluginCheckpointFile)
+	decoder, encoder, err := checkpointSerializer()
+	require.NoError(b, err)
+
+	checkpoint := new(checkpointapi.Checkpoint)
+	for i := range 256 {
+		checkpoint.PreparedClaims = append(checkpoint.PreparedClaims, checkpointapi.PreparedClaim{
+			UID: types.UID(fmt.Sprintf("claim-%d", i)),
+			Devices: []checkpointapi.PreparedDevice{
+				{PoolName: "node", DeviceName: fmt.Sprintf("gpu-%d", i%8), ShareID: ptr.To(types.UID(fmt.Sprintf("share-%d", i))), SharedConfig: "TimeSlicing with Default interval"},
+			},
+		})
+	}
+	require.NoError(b, writeCheckpoint(path, encoder, checkpoint))
+
+	b.Run("disk", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := readCheckpoint(path, decoder); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("memory", func(b *testing.B) {
+		store, err := newCheckpointStore(path)
+		require.NoError(b, err)
+		for b.Loop() {
+			if _, err := store.read(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -14,7 +14,7 @@
  * limitations under the License.
  */
 
-package main
+package kubeletplugin
 
 import (
 	"context"
@@ -22,27 +22,40 @@ import (
 	"fmt"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	resourceapi "k8s.io/api/resource/v1"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	coreclientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/dynamic-resource-allocation/kubeletplugin"
 	"k8s.io/klog/v2"
 
 	"sigs.k8s.io/dra-example-driver/pkg/metrics"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
 )
 
-type driver struct {
+// Driver is the DRA kubelet plugin of one device profile. It prepares and
+// unprepares the claims allocated to the node and publishes the profile's
+// devices.
+type Driver struct {
 	client      coreclientset.Interface
 	helper      *kubeletplugin.Helper
 	state       *DeviceState
-	healthcheck *healthcheck
+	workers     int
+	broadcaster record.EventBroadcaster
 	cancelCtx   func(error)
 }
 
-func NewDriver(ctx context.Context, config *Config) (*driver, error) {
-	driver := &driver{
+// NewDriver starts the kubelet plugin for config and publishes its devices.
+// The driver runs until it is shut down with [Driver.Shutdown].
+func NewDriver(ctx context.Context, config *Config) (*Driver, error) {
+	driver := &Driver{
 		client:    config.coreclient,
+		workers:   config.flags.prepareWorkers,
 		cancelCtx: config.cancelMainCtx,
 	}
 
@@ -65,39 +78,80 @@ func NewDriver(ctx context.Context, config *Config) (*driver, error) {
 	}
 	driver.helper = helper
 
-	driver.healthcheck, err = startHealthcheck(ctx, config)
-	if err != nil {
-		return nil, fmt.Errorf("start healthcheck: %w", err)
+	if err := driver.publishResources(ctx); err != nil {
+		return nil, err
 	}
 
-	if err := helper.PublishResources(ctx, state.driverResources); err != nil {
-		return nil, err
+	if watcher, ok := config.profile.(profiles.DeviceWatcher); ok {
+		go driver.watchDevices(ctx, config.profile, watcher)
 	}
 
+	driver.broadcaster = record.NewBroadcaster(record.WithContext(ctx))
+	driver.broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: config.coreclient.CoreV1().Events("")})
+	recorder := driver.broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{
+		Component: "dra-example-kubeletplugin",
+		Host:      config.flags.nodeName,
+	})
+	go newClaimReconciler(state, recorder).run(ctx, config.flags.reconcileInterval)
+
 	return driver, nil
 }
 
-func (d *driver) Shutdown(logger klog.Logger) error {
-	if d.healthcheck != nil {
-		d.healthcheck.Stop(logger)
+// watchDevices republishes the driver's ResourceSlices whenever the profile
+// reports that its devices may have changed.
+func (d *Driver) watchDevices(ctx context.Context, profile profiles.Profile, watcher profiles.DeviceWatcher) {
+	logger := klog.FromContext(ctx)
+	err := watcher.WatchDevices(ctx, func() {
+		driverResources, err := profile.EnumerateDevices()
+		if err != nil {
+			// Keep publishing the last good set of devices.
+			logger.Error(err, "Failed to enumerate devices, keeping previously published devices")
+			return
+		}
+		d.state.UpdateDevices(driverResources)
+		if err := d.publishResources(ctx); err != nil {
+			logger.Error(err, "Failed to publish updated devices")
+			return
+		}
+		logger.Info("Published updated devices")
+	})
+	if err != nil {
+		d.HandleError(ctx, err, "watching devices")
 	}
+}
+
+// publishResources publishes the devices currently advertised by the
+// driver's state and updates the host inventory accordingly.
+func (d *Driver) publishResources(ctx context.Context) error {
+	d.state.updateHostInventory(ctx)
+	return d.helper.PublishResources(ctx, d.state.DriverResources())
+}
+
+func (d *Driver) Shutdown(logger klog.Logger) error {
 	d.helper.Stop()
+	if d.broadcaster != nil {
+		d.broadcaster.Shutdown()
+	}
 	return nil
 }
 
-func (d *driver) PrepareResourceClaims(ctx context.Context, claims []*resourceapi.ResourceClaim) (map[types.UID]kubeletplugin.PrepareResult, error) {
+func (d *Driver) PrepareResourceClaims(ctx context.Context, claims []*resourceapi.ResourceClaim) (map[types.UID]kubeletplugin.PrepareResult, error) {
 	logger := klog.FromContext(ctx)
 	logger.Info("PrepareResourceClaims is called", "numClaims", len(claims))
-	result := make(map[types.UID]kubeletplugin.PrepareResult)
+	results := make([]kubeletplugin.PrepareResult, len(claims))
+	d.parallelize(ctx, len(claims), func(i int) {
+		results[i] = d.prepareResourceClaim(ctx, claims[i])
+	})
 
-	for _, claim := range claims {
-		result[claim.UID] = d.prepareResourceClaim(ctx, claim)
+	result := make(map[types.UID]kubeletplugin.PrepareResult)
+	for i, claim := range claims {
+		result[claim.UID] = results[i]
 	}
 
 	return result, nil
 }
 
-func (d *driver) prepareResourceClaim(ctx context.Context, claim *resourceapi.ResourceClaim) (result kubeletplugin.PrepareResult) {
+func (d *Driver) prepareResourceClaim(ctx context.Context, claim *resourceapi.ResourceClaim) (result kubeletplugin.PrepareResult) {
 	logger := klog.FromContext(ctx)
 	logger.Info("Preparing claim", "uid", claim.UID, "namespace", claim.Namespace, "name", claim.Name)
 
@@ -130,32 +184,45 @@ func (d *driver) prepareResourceClaim(ctx context.Context, claim *resourceapi.Re
 	return result
 }
 
-func (d *driver) UnprepareResourceClaims(ctx context.Context, claims []kubeletplugin.NamespacedObject) (map[types.UID]error, error) {
+func (d *Driver) UnprepareResourceClaims(ctx context.Context, claims []kubeletplugin.NamespacedObject) (map[types.UID]error, error) {
 	logger := klog.FromContext(ctx)
 	logger.Info("UnprepareResourceClaims is called", "numClaims", len(claims))
-	result := make(map[types.UID]error)
+	errs := make([]error, len(claims))
+	d.parallelize(ctx, len(claims), func(i int) {
+		errs[i] = d.unprepareResourceClaim(ctx, claims[i])
+	})
 
-	for _, claim := range claims {
-		result[claim.UID] = d.unprepareResourceClaim(ctx, claim)
+	result := make(map[types.UID]error)
+	for i, claim := range claims {
+		result[claim.UID] = errs[i]
 	}
 
 	return result, nil
 }
 
-func (d *driver) unprepareResourceClaim(_ context.Context, claim kubeletplugin.NamespacedObject) (err error) {
+// parallelize calls work for each of n claims with at most d.workers claims
+// at a time. Claims are independent of each other, so that one slow claim
+// only holds up claims sharing a device with it.
+func (d *Driver) parallelize(ctx context.Context, n int, work func(i int)) {
+	// Claims are always processed to completion, even when ctx is canceled
+	// in between, so that each of them gets a result.
+	workqueue.ParallelizeUntil(context.WithoutCancel(ctx), max(d.workers, 1), n, work)
+}
+
+func (d *Driver) unprepareResourceClaim(ctx context.Context, claim kubeletplugin.NamespacedObject) (err error) {
 	start := time.Now()
 	defer func() {
 		metrics.ObserveUnprepareClaim(err, time.Since(start))
 	}()
 
-	if err = d.state.Unprepare(claim.UID); err != nil {
+	if err = d.state.Unprepare(ctx, claim.UID); err != nil {
 		return fmt.Errorf("error unpreparing devices for claim %v: %w", claim.UID, err)
 	}
 
 	return nil
 }
 
-func (d *driver) HandleError(ctx context.Context, err error, msg string) {
+func (d *Driver) HandleError(ctx context.Context, err error, msg string) {
 	utilruntime.HandleErrorWithContext(ctx, err, msg)
 	if !errors.Is(err, kubeletplugin.ErrRecoverable) {
 		metrics.FatalBackgroundErrorsTotal.Inc()
@@ -0,0 +1,99 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/dynamic-resource-allocation/kubeletplugin"
+
+	"sigs.k8s.io/dra-example-driver/internal/profiles/fpga"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+// TestPrepareResourceClaimsConcurrently verifies that claims are prepared
+// concurrently by a bounded number of workers, while claims sharing a device
+// are prepared one after another.
+func TestPrepareResourceClaimsConcurrently(t *testing.T) {
+	const reprogramDuration = 200 * time.Millisecond
+
+	state := newTestState(t, fpga.NewProfile(testNodeName, 4, reprogramDuration, profiles.Topology{}))
+	d := &Driver{state: state, workers: 4}
+	ctx := context.Background()
+
+	// Each claim loads its own bitstream, which takes reprogramDuration.
+	claim := func(uid types.UID, device string) *resourceapi.ResourceClaim {
+		return fpgaClaim(uid, device, string(uid))
+	}
+	prepare := func(claims ...*resourceapi.ResourceClaim) time.Duration {
+		start := time.Now()
+		results, err := d.PrepareResourceClaims(ctx, claims)
+		require.NoError(t, err)
+		elapsed := time.Since(start)
+		require.Len(t, results, len(claims))
+		for _, claim := range claims {
+			require.NoError(t, results[claim.UID].Err, "claim %s", claim.UID)
+		}
+		return elapsed
+	}
+	unprepare := func(claims ...*resourceapi.ResourceClaim) {
+		var objects []kubeletplugin.NamespacedObject
+		for _, claim := range claims {
+			objects = append(objects, kubeletplugin.NamespacedObject{UID: claim.UID})
+		}
+		errs, err := d.UnprepareResourceClaims(ctx, objects)
+		require.NoError(t, err)
+		for _, claim := range claims {
+			require.NoError(t, errs[claim.UID], "claim %s", claim.UID)
+		}
+	}
+
+	var claims []*resourceapi.ResourceClaim
+	for i := range 8 {
+		claims = append(claims, claim(types.UID(fmt.Sprintf("claim-%d", i)), fmt.Sprintf("fpga-%d", i%4)))
+	}
+
+	// Four devices are reprogrammed at the same time.
+	elapsed := prepare(claims[:4]...)
+	assert.Less(t, elapsed, 2*reprogramDuration, "claims on different devices must be prepared concurrently")
+
+	// All claims must have been recorded in the checkpoint.
+	checkpoint, err := state.checkpoint.read()
+	require.NoError(t, err)
+	assert.Len(t, checkpoint.PreparedClaims, 4)
+	assert.Len(t, checkpoint.DeviceStates, 4)
+	unprepare(claims[:4]...)
+
+	// Claims sharing a device wait for each other, no matter how many
+	// workers are available.
+	d.workers = 8
+	elapsed = prepare(claims[4], claims[5], claims[6], claims[7], claim("claim-8", "fpga-0"))
+	assert.GreaterOrEqual(t, elapsed, 2*reprogramDuration, "claims on the same device must be prepared one after another")
+	assert.Less(t, elapsed, 3*reprogramDuration)
+	unprepare(claims[4], claims[5], claims[6], claims[7], claim("claim-8", "fpga-0"))
+
+	checkpoint, err = state.checkpoint.read()
+	require.NoError(t, err)
+	assert.Empty(t, checkpoint.PreparedClaims)
+}
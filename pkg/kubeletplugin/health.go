@@ -14,7 +14,7 @@
  * limitations under the License.
  */
 
-package main
+package kubeletplugin
 
 import (
 	"context"
@@ -41,24 +41,63 @@ type healthcheck struct {
 	server *grpc.Server
 	wg     sync.WaitGroup
 
-	regClient registerapi.RegistrationClient
-	draClient drapb.DRAPluginClient
+	plugins []healthcheckPlugin
 }
 
-func startHealthcheck(ctx context.Context, config *Config) (*healthcheck, error) {
+// healthcheckPlugin holds the connections to the sockets of one driver
+// served by the process.
+type healthcheckPlugin struct {
+	driverName string
+	regClient  registerapi.RegistrationClient
+	draClient  drapb.DRAPluginClient
+}
+
+// startHealthcheck starts a gRPC healthcheck service which reports the
+// process as serving when all of its drivers are.
+func startHealthcheck(ctx context.Context, port int, configs []*Config) (*healthcheck, error) {
 	log := klog.FromContext(ctx)
 
-	port := config.flags.healthcheckPort
 	if port < 0 {
 		return nil, nil
 	}
 
+	var plugins []healthcheckPlugin
+	for _, config := range configs {
+		plugin, err := connectHealthcheckPlugin(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		plugins = append(plugins, plugin)
+	}
+
 	addr := net.JoinHostPort("", strconv.Itoa(port))
 	lis, err := net.Listen("tcp", addr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to listen for healthcheck service at %s: %w", addr, err)
 	}
 
+	server := grpc.NewServer()
+	healthcheck := &healthcheck{
+		server:  server,
+		plugins: plugins,
+	}
+	grpc_health_v1.RegisterHealthServer(server, healthcheck)
+
+	healthcheck.wg.Add(1)
+	go func() {
+		defer healthcheck.wg.Done()
+		log.Info("starting healthcheck service", "addr", lis.Addr().String())
+		if err := server.Serve(lis); err != nil {
+			log.Error(err, "failed to serve healthcheck service", "addr", addr)
+		}
+	}()
+
+	return healthcheck, nil
+}
+
+func connectHealthcheckPlugin(ctx context.Context, config *Config) (healthcheckPlugin, error) {
+	log := klog.FromContext(ctx)
+
 	regSockPath := (&url.URL{
 		Scheme: "unix",
 		Path: func() string {
@@ -74,7 +113,7 @@ func startHealthcheck(ctx context.Context, config *Config) (*healthcheck, error)
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("connect to registration socket: %w", err)
+		return healthcheckPlugin{}, fmt.Errorf("connect to registration socket: %w", err)
 	}
 
 	draSockPath := (&url.URL{
@@ -92,27 +131,14 @@ func startHealthcheck(ctx context.Context, config *Config) (*healthcheck, error)
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("connect to DRA socket: %w", err)
-	}
-
-	server := grpc.NewServer()
-	healthcheck := &healthcheck{
-		server:    server,
-		regClient: registerapi.NewRegistrationClient(regConn),
-		draClient: drapb.NewDRAPluginClient(draConn),
+		return healthcheckPlugin{}, fmt.Errorf("connect to DRA socket: %w", err)
 	}
-	grpc_health_v1.RegisterHealthServer(server, healthcheck)
-
-	healthcheck.wg.Add(1)
-	go func() {
-		defer healthcheck.wg.Done()
-		log.Info("starting healthcheck service", "addr", lis.Addr().String())
-		if err := server.Serve(lis); err != nil {
-			log.Error(err, "failed to serve healthcheck service", "addr", addr)
-		}
-	}()
 
-	return healthcheck, nil
+	return healthcheckPlugin{
+		driverName: config.flags.driverName,
+		regClient:  registerapi.NewRegistrationClient(regConn),
+		draClient:  drapb.NewDRAPluginClient(draConn),
+	}, nil
 }
 
 func (h *healthcheck) Stop(logger klog.Logger) {
@@ -136,19 +162,23 @@ func (h *healthcheck) Check(ctx context.Context, req *grpc_health_v1.HealthCheck
 		Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING,
 	}
 
-	info, err := h.regClient.GetInfo(ctx, &registerapi.InfoRequest{})
-	if err != nil {
-		log.Error(err, "failed to call GetInfo")
-		return status, nil
-	}
-	log.V(5).Info("Successfully invoked GetInfo", "info", info)
+	for _, plugin := range h.plugins {
+		log := log.WithValues("driverName", plugin.driverName)
 
-	_, err = h.draClient.NodePrepareResources(ctx, &drapb.NodePrepareResourcesRequest{})
-	if err != nil {
-		log.Error(err, "failed to call NodePrepareResources")
-		return status, nil
+		info, err := plugin.regClient.GetInfo(ctx, &registerapi.InfoRequest{})
+		if err != nil {
+			log.Error(err, "failed to call GetInfo")
+			return status, nil
+		}
+		log.V(5).Info("Successfully invoked GetInfo", "info", info)
+
+		_, err = plugin.draClient.NodePrepareResources(ctx, &drapb.NodePrepareResourcesRequest{})
+		if err != nil {
+			log.Error(err, "failed to call NodePrepareResources")
+			return status, nil
+		}
+		log.V(5).Info("Successfully invoked NodePrepareResources")
 	}
-	log.V(5).Info("Successfully invoked NodePrepareResources")
 
 	status.Status = grpc_health_v1.HealthCheckResponse_SERVING
 	return status, nil
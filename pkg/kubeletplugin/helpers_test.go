@@ -0,0 +1,133 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	resourceapi "k8s.io/api/resource/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	coreclientset "k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/dra-example-driver/internal/profiles/cpu"
+	"sigs.k8s.io/dra-example-driver/internal/profiles/fpga"
+	"sigs.k8s.io/dra-example-driver/internal/profiles/gpu"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+const (
+	testNodeName       = "test-node"
+	testDriverName     = "cpu.example.com"
+	testGpuDriverName  = "gpu.example.com"
+	testFpgaDriverName = "fpga.example.com"
+)
+
+// testStateOption changes the config of a DeviceState created by
+// newTestState.
+type testStateOption func(*Config)
+
+// withClient sets the client the DeviceState uses to talk to the API server.
+func withClient(client coreclientset.Interface) testStateOption {
+	return func(config *Config) {
+		config.coreclient = client
+	}
+}
+
+// withCDIRoot sets the directory the DeviceState writes CDI spec files to.
+func withCDIRoot(cdiRoot string) testStateOption {
+	return func(config *Config) {
+		config.flags.cdiRoot = cdiRoot
+	}
+}
+
+// newTestState returns a DeviceState for profile on testNodeName which keeps
+// all its files in temporary directories.
+func newTestState(t *testing.T, profile profiles.Profile, opts ...testStateOption) *DeviceState {
+	t.Helper()
+
+	var profileName string
+	switch profile.(type) {
+	case cpu.Profile:
+		profileName = cpu.ProfileName
+	case fpga.Profile:
+		profileName = fpga.ProfileName
+	case gpu.Profile:
+		profileName = gpu.ProfileName
+	default:
+		t.Fatalf("unsupported profile %T", profile)
+	}
+
+	config := &Config{
+		flags: &Flags{
+			cdiRoot:                     t.TempDir(),
+			kubeletPluginsDirectoryPath: t.TempDir(),
+			driverName:                  profileName + ".example.com",
+			profile:                     profileName,
+			nodeName:                    testNodeName,
+		},
+		profile: profile,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	require.NoError(t, os.MkdirAll(config.DriverPluginPath(), 0750))
+	state, err := NewDeviceState(config)
+	require.NoError(t, err)
+	return state
+}
+
+// newTestGpuProfile returns a gpu profile with two GPUs which may be
+// allocated multiple times, backed by hardware if not nil.
+func newTestGpuProfile(hardware *gpu.Hardware) gpu.Profile {
+	return gpu.NewProfile(testNodeName, 2, 0, false, false, true, "", nil, profiles.Topology{}, hardware)
+}
+
+// fpgaClaim returns a claim allocating an FPGA which must be programmed with
+// bitstream.
+func fpgaClaim(uid types.UID, device, bitstream string) *resourceapi.ResourceClaim {
+	return &resourceapi.ResourceClaim{
+		ObjectMeta: metav1.ObjectMeta{UID: uid, Namespace: "default", Name: string(uid)},
+		Status: resourceapi.ResourceClaimStatus{
+			Allocation: &resourceapi.AllocationResult{
+				Devices: resourceapi.DeviceAllocationResult{
+					Results: []resourceapi.DeviceRequestAllocationResult{{
+						Request: "fpga",
+						Driver:  testFpgaDriverName,
+						Pool:    testNodeName,
+						Device:  device,
+					}},
+					Config: []resourceapi.DeviceAllocationConfiguration{{
+						Source: resourceapi.AllocationConfigSourceClaim,
+						DeviceConfiguration: resourceapi.DeviceConfiguration{
+							Opaque: &resourceapi.OpaqueDeviceConfiguration{
+								Driver: testFpgaDriverName,
+								Parameters: runtime.RawExtension{
+									Raw: []byte(`{"apiVersion":"fpga.resource.example.com/v1alpha1","kind":"FpgaConfig","bitstream":"` + bitstream + `"}`),
+								},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
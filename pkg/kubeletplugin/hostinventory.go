@@ -0,0 +1,216 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	checkpointapi "sigs.k8s.io/dra-example-driver/internal/api/checkpoint"
+)
+
+const (
+	// HostInventoryDir is the directory below the driver's plugin data
+	// directory which holds the host inventory. The whole directory is
+	// mounted into containers so that they see the file being replaced.
+	HostInventoryDir = "host-inventory"
+	// HostInventoryFile is the name of the host inventory file.
+	HostInventoryFile = "host-inventory.json"
+	// hostInventoryContainerRoot is where the host inventory directories of
+	// all drivers are mounted in containers.
+	hostInventoryContainerRoot = "/var/run/dra-example-driver"
+)
+
+// Device health reported in the host inventory.
+const (
+	DeviceHealthy   = "Healthy"
+	DeviceDegraded  = "Degraded"
+	DeviceUnhealthy = "Unhealthy"
+)
+
+// HostInventory describes all devices of a driver on the node and the claims
+// prepared for them. It is mounted read-only into the containers of claims
+// with admin access, e.g. for monitoring.
+type HostInventory struct {
+	NodeName string                `json:"nodeName"`
+	Driver   string                `json:"driver"`
+	Devices  []HostInventoryDevice `json:"devices"`
+}
+
+// HostInventoryDevice is a single device in a [HostInventory].
+type HostInventoryDevice struct {
+	Pool string `json:"pool"`
+	Name string `json:"name"`
+	// Advertised is false for devices which still have claims prepared on
+	// them but are not advertised by the kubelet plugin, e.g. because they
+	// were hot-unplugged or belong to a network-attached pool.
+	Advertised bool `json:"advertised"`
+	// Health is derived from Taints, which report e.g. simulated faults.
+	// Devices with NoSchedule or NoExecute taints are unhealthy, devices
+	// with only informational taints are degraded.
+	Health string                    `json:"health"`
+	Taints []resourceapi.DeviceTaint `json:"taints,omitempty"`
+	Claims []HostInventoryClaim      `json:"claims,omitempty"`
+}
+
+// HostInventoryClaim is a claim prepared on a [HostInventoryDevice].
+type HostInventoryClaim struct {
+	UID       types.UID  `json:"uid"`
+	Namespace string     `json:"namespace,omitempty"`
+	Name      string     `json:"name,omitempty"`
+	ShareID   *types.UID `json:"shareID,omitempty"`
+}
+
+// hostInventory writes the host inventory of a driver. Writes are serialized
+// so that the file always reflects the latest state.
+type hostInventory struct {
+	sync.Mutex
+	dir string
+}
+
+// buildHostInventory lists the advertised devices and all devices the
+// prepared claims in checkpoint refer to.
+func buildHostInventory(nodeName, driverName string, allocatable AllocatableDevices, checkpoint *checkpointapi.Checkpoint) *HostInventory {
+	devices := make(map[deviceKey]*HostInventoryDevice)
+	for name, device := range allocatable {
+		devices[deviceKey{pool: nodeName, device: name}] = &HostInventoryDevice{
+			Pool:       nodeName,
+			Name:       name,
+			Advertised: true,
+			Health:     deviceHealth(device.Taints),
+			Taints:     device.Taints,
+		}
+	}
+	for _, claim := range checkpoint.PreparedClaims {
+		for _, prepared := range claim.Devices {
+			key := deviceKey{pool: prepared.PoolName, device: prepared.DeviceName}
+			device, exists := devices[key]
+			if !exists {
+				device = &HostInventoryDevice{
+					Pool:   prepared.PoolName,
+					Name:   prepared.DeviceName,
+					Health: DeviceHealthy,
+				}
+				devices[key] = device
+			}
+			device.Claims = append(device.Claims, HostInventoryClaim{
+				UID:       claim.UID,
+				Namespace: claim.Namespace,
+				Name:      claim.Name,
+				ShareID:   prepared.ShareID,
+			})
+		}
+	}
+
+	inventory := &HostInventory{
+		NodeName: nodeName,
+		Driver:   driverName,
+		Devices:  []HostInventoryDevice{},
+	}
+	for _, device := range devices {
+		slices.SortFunc(device.Claims, func(a, b HostInventoryClaim) int {
+			return cmp.Compare(a.UID, b.UID)
+		})
+		inventory.Devices = append(inventory.Devices, *device)
+	}
+	slices.SortFunc(inventory.Devices, func(a, b HostInventoryDevice) int {
+		return cmp.Or(cmp.Compare(a.Pool, b.Pool), cmp.Compare(a.Name, b.Name))
+	})
+	return inventory
+}
+
+// deviceHealth derives the health of a device from its taints.
+func deviceHealth(taints []resourceapi.DeviceTaint) string {
+	health := DeviceHealthy
+	for _, taint := range taints {
+		switch taint.Effect {
+		case resourceapi.DeviceTaintEffectNoSchedule, resourceapi.DeviceTaintEffectNoExecute:
+			return DeviceUnhealthy
+		default:
+			health = DeviceDegraded
+		}
+	}
+	return health
+}
+
+// write replaces the host inventory file with inventory.
+func (h *hostInventory) write(inventory *HostInventory) (err error) {
+	data, err := json.MarshalIndent(inventory, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode host inventory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(h.dir, "tmp-host-inventory-*")
+	if err != nil {
+		return fmt.Errorf("create temp file in %s: %w", h.dir, err)
+	}
+	defer func() {
+		if err1 := tmp.Close(); err1 != nil && err == nil {
+			err = fmt.Errorf("close temp file: %w", err1)
+		}
+	}()
+	// The file is read by containers which may run as any user.
+	if err := tmp.Chmod(0644); err != nil {
+		return fmt.Errorf("chmod temp file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("write temp file %s: %w", tmp.Name(), err)
+	}
+	path := filepath.Join(h.dir, HostInventoryFile)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename %s to %s: %w", tmp.Name(), path, err)
+	}
+	return nil
+}
+
+// writeHostInventory writes the host inventory for the devices currently
+// advertised and the claims currently prepared.
+func (s *DeviceState) writeHostInventory() error {
+	s.hostInventory.Lock()
+	defer s.hostInventory.Unlock()
+
+	checkpoint, err := s.checkpoint.read()
+	if err != nil {
+		return fmt.Errorf("unable to sync from checkpoint: %v", err)
+	}
+	// The advertised devices may change concurrently, but are never modified
+	// in place.
+	s.Lock()
+	allocatable := s.allocatable
+	s.Unlock()
+
+	return s.hostInventory.write(buildHostInventory(s.nodeName, s.driverName, allocatable, checkpoint))
+}
+
+// updateHostInventory updates the host inventory after the devices or the
+// prepared claims changed. The inventory is informational, so failing to
+// update it does not fail the change.
+func (s *DeviceState) updateHostInventory(ctx context.Context) {
+	if err := s.writeHostInventory(); err != nil {
+		klog.FromContext(ctx).Error(err, "Failed to update host inventory")
+	}
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+	cdispec "tags.cncf.io/container-device-interface/specs-go"
+
+	checkpointapi "sigs.k8s.io/dra-example-driver/internal/api/checkpoint"
+	"sigs.k8s.io/dra-example-driver/internal/profiles/cpu"
+)
+
+func TestBuildHostInventory(t *testing.T) {
+	faulty := resourceapi.DeviceTaint{Key: "example.com/fault", Value: "xid-79", Effect: resourceapi.DeviceTaintEffectNoExecute}
+	informational := resourceapi.DeviceTaint{Key: "example.com/ecc", Effect: resourceapi.DeviceTaintEffectNone}
+	allocatable := AllocatableDevices{
+		"dev-0": {Name: "dev-0", Taints: []resourceapi.DeviceTaint{faulty}},
+		"dev-1": {Name: "dev-1", Taints: []resourceapi.DeviceTaint{informational}},
+		"dev-2": {Name: "dev-2"},
+	}
+	checkpoint := &checkpointapi.Checkpoint{
+		PreparedClaims: []checkpointapi.PreparedClaim{
+			{
+				UID:       "claim-b",
+				Namespace: "default",
+				Name:      "b",
+				Devices:   []checkpointapi.PreparedDevice{{PoolName: testNodeName, DeviceName: "dev-0", ShareID: ptr.To(types.UID(testShareId))}},
+			},
+			{
+				UID:       "claim-a",
+				Namespace: "default",
+				Name:      "a",
+				Devices: []checkpointapi.PreparedDevice{
+					{PoolName: testNodeName, DeviceName: "dev-0"},
+					{PoolName: testNodeName, DeviceName: "dev-9"},
+					{PoolName: "fabric", DeviceName: "dev-0"},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, &HostInventory{
+		NodeName: testNodeName,
+		Driver:   testDriverName,
+		Devices: []HostInventoryDevice{
+			{
+				Pool:   "fabric",
+				Name:   "dev-0",
+				Health: DeviceHealthy,
+				Claims: []HostInventoryClaim{{UID: "claim-a", Namespace: "default", Name: "a"}},
+			},
+			{
+				Pool:       testNodeName,
+				Name:       "dev-0",
+				Advertised: true,
+				Health:     DeviceUnhealthy,
+				Taints:     []resourceapi.DeviceTaint{faulty},
+				Claims: []HostInventoryClaim{
+					{UID: "claim-a", Namespace: "default", Name: "a"},
+					{UID: "claim-b", Namespace: "default", Name: "b", ShareID: ptr.To(types.UID(testShareId))},
+				},
+			},
+			{
+				Pool:       testNodeName,
+				Name:       "dev-1",
+				Advertised: true,
+				Health:     DeviceDegraded,
+				Taints:     []resourceapi.DeviceTaint{informational},
+			},
+			{
+				Pool:       testNodeName,
+				Name:       "dev-2",
+				Advertised: true,
+				Health:     DeviceHealthy,
+			},
+			{
+				Pool:   testNodeName,
+				Name:   "dev-9",
+				Health: DeviceHealthy,
+				Claims: []HostInventoryClaim{{UID: "claim-a", Namespace: "default", Name: "a"}},
+			},
+		},
+	}, buildHostInventory(testNodeName, testDriverName, allocatable, checkpoint))
+}
+
+func TestHostInventoryAdminAccess(t *testing.T) {
+	ctx := context.Background()
+	cdiRoot := t.TempDir()
+	state := newTestState(t, cpu.NewProfile(testNodeName, testDriverName, 2, 4, false, nil), withCDIRoot(cdiRoot))
+	hostInventoryDir := state.hostInventory.dir
+
+	readInventory := func() *HostInventory {
+		data, err := os.ReadFile(filepath.Join(hostInventoryDir, HostInventoryFile))
+		require.NoError(t, err)
+		inventory := new(HostInventory)
+		require.NoError(t, json.Unmarshal(data, inventory))
+		return inventory
+	}
+	readSpec := func(claimUID string) *cdispec.Spec {
+		paths, err := filepath.Glob(filepath.Join(cdiRoot, "*"+claimUID+"*"))
+		require.NoError(t, err)
+		require.Len(t, paths, 1)
+		data, err := os.ReadFile(paths[0])
+		require.NoError(t, err)
+		spec := new(cdispec.Spec)
+		require.NoError(t, yaml.Unmarshal(data, spec))
+		return spec
+	}
+
+	_, err := state.Prepare(ctx, allocatedClaim("claim-user", testNodeName, "numa-0"))
+	require.NoError(t, err)
+	adminClaim := allocatedClaim("claim-admin", testNodeName, "numa-0")
+	adminClaim.Status.Allocation.Devices.Results[0].AdminAccess = ptr.To(true)
+	_, err = state.Prepare(ctx, adminClaim)
+	require.NoError(t, err)
+
+	// Only containers of claims with admin access get the inventory.
+	assert.Empty(t, readSpec("claim-user").Devices[0].ContainerEdits.Mounts)
+	edits := readSpec("claim-admin").Devices[0].ContainerEdits
+	assert.Contains(t, edits.Env, "CPU_HOST_INVENTORY_FILE=/var/run/dra-example-driver/cpu.example.com/host-inventory.json")
+	assert.Equal(t, []*cdispec.Mount{{
+		HostPath:      hostInventoryDir,
+		ContainerPath: "/var/run/dra-example-driver/cpu.example.com",
+		Options:       []string{"ro", "nosuid", "nodev", "bind"},
+	}}, edits.Mounts)
+
+	claims := func() map[string][]types.UID {
+		claims := make(map[string][]types.UID)
+		for _, device := range readInventory().Devices {
+			claims[device.Name] = nil
+			for _, claim := range device.Claims {
+				claims[device.Name] = append(claims[device.Name], claim.UID)
+			}
+		}
+		return claims
+	}
+	assert.Equal(t, map[string][]types.UID{"numa-0": {"claim-admin", "claim-user"}, "numa-1": nil}, claims())
+
+	require.NoError(t, state.Unprepare(ctx, "claim-user"))
+	assert.Equal(t, map[string][]types.UID{"numa-0": {"claim-admin"}, "numa-1": nil}, claims())
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/dynamic-resource-allocation/resourceslice"
+
+	checkpointapi "sigs.k8s.io/dra-example-driver/internal/api/checkpoint"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles/helpers"
+)
+
+var (
+	errDeviceNotFound = errors.New("device not found")
+	errDeviceExists   = errors.New("device already exists")
+)
+
+// DeviceReport describes the devices of a [DeviceState] for the admin
+// endpoint.
+type DeviceReport struct {
+	// Devices lists the names of all devices currently advertised.
+	Devices []string `json:"devices"`
+	// Unplugged lists the names of devices removed at runtime which the
+	// profile would otherwise advertise.
+	Unplugged []string `json:"unplugged,omitempty"`
+	// OrphanedClaims maps the names of devices that are no longer advertised
+	// to the claims which are still prepared on them.
+	OrphanedClaims map[string][]types.UID `json:"orphanedClaims,omitempty"`
+}
+
+// syncDevices recomputes the advertised driverResources and allocatable
+// devices from the enumerated devices and any runtime changes. The caller must
+// hold the lock.
+func (s *DeviceState) syncDevices() {
+	pool := s.enumerated.Pools[s.nodeName]
+
+	var sharedCounters []resourceapi.CounterSet
+	var devices []resourceapi.Device
+	for _, slice := range pool.Slices {
+		sharedCounters = append(sharedCounters, slice.SharedCounters...)
+		for _, device := range slice.Devices {
+			if !s.unplugged.Has(device.Name) {
+				devices = append(devices, device)
+			}
+		}
+	}
+	devices = append(devices, s.plugged...)
+
+	pools := make(map[string]resourceslice.Pool, len(s.enumerated.Pools))
+	for name, p := range s.enumerated.Pools {
+		pools[name] = p
+	}
+	pool.Slices = helpers.PoolSlices(sharedCounters, devices)
+	pools[s.nodeName] = pool
+
+	s.driverResources = resourceslice.DriverResources{Pools: pools}
+	s.allocatable = allocatableDevices(s.driverResources, s.nodeName)
+}
+
+// PlugDevice makes a device available at runtime. When device is nil, a device
+// previously removed with [DeviceState.UnplugDevice] is advertised again.
+// Otherwise device is advertised in addition to the devices enumerated by the
+// profile.
+func (s *DeviceState) PlugDevice(name string, device *resourceapi.Device) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, exists := s.allocatable[name]; exists {
+		return fmt.Errorf("%w: %s", errDeviceExists, name)
+	}
+
+	if device == nil {
+		if !s.unplugged.Has(name) {
+			return fmt.Errorf("%w: %s", errDeviceNotFound, name)
+		}
+		s.unplugged.Delete(name)
+		s.syncDevices()
+		return nil
+	}
+
+	if device.Name == "" {
+		device.Name = name
+	}
+	if device.Name != name {
+		return fmt.Errorf("device name %q does not match %q", device.Name, name)
+	}
+	s.plugged = append(s.plugged, *device)
+	s.syncDevices()
+	return nil
+}
+
+// UnplugDevice stops advertising a device at runtime. It returns the claims
+// which are still prepared on the device. Those claims can still be
+// unprepared, but no new claims will be prepared for the device.
+func (s *DeviceState) UnplugDevice(name string) ([]types.UID, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, exists := s.allocatable[name]; !exists {
+		return nil, fmt.Errorf("%w: %s", errDeviceNotFound, name)
+	}
+
+	checkpoint, err := s.checkpoint.read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to sync from checkpoint: %v", err)
+	}
+
+	if i := slices.IndexFunc(s.plugged, func(d resourceapi.Device) bool { return d.Name == name }); i >= 0 {
+		s.plugged = slices.Delete(s.plugged, i, i+1)
+	} else {
+		s.unplugged.Insert(name)
+	}
+	s.syncDevices()
+
+	return s.claimsOnDevice(checkpoint.PreparedClaims, name), nil
+}
+
+// Devices reports the devices currently advertised and the prepared claims
+// which refer to devices that are not.
+func (s *DeviceState) Devices() (*DeviceReport, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	checkpoint, err := s.checkpoint.read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to sync from checkpoint: %v", err)
+	}
+
+	report := &DeviceReport{
+		Devices:   []string{},
+		Unplugged: sets.List(s.unplugged),
+	}
+	for name := range s.allocatable {
+		report.Devices = append(report.Devices, name)
+	}
+	slices.Sort(report.Devices)
+
+	for _, claim := range checkpoint.PreparedClaims {
+		for _, device := range claim.Devices {
+			if !s.isLocal(device) {
+				continue
+			}
+			if _, exists := s.allocatable[device.DeviceName]; exists {
+				continue
+			}
+			if report.OrphanedClaims == nil {
+				report.OrphanedClaims = make(map[string][]types.UID)
+			}
+			if !slices.Contains(report.OrphanedClaims[device.DeviceName], claim.UID) {
+				report.OrphanedClaims[device.DeviceName] = append(report.OrphanedClaims[device.DeviceName], claim.UID)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// isLocal returns true if a prepared device belongs to the node-local pool
+// rather than to a network-attached pool. Runtime changes only apply to the
+// node-local pool.
+func (s *DeviceState) isLocal(device checkpointapi.PreparedDevice) bool {
+	return device.PoolName == s.nodeName
+}
+
+// claimsOnDevice returns the UIDs of the claims prepared on the named
+// node-local device.
+func (s *DeviceState) claimsOnDevice(claims []checkpointapi.PreparedClaim, name string) []types.UID {
+	var uids []types.UID
+	for _, claim := range claims {
+		if slices.ContainsFunc(claim.Devices, func(d checkpointapi.PreparedDevice) bool { return s.isLocal(d) && d.DeviceName == name }) {
+			uids = append(uids, claim.UID)
+		}
+	}
+	return uids
+}
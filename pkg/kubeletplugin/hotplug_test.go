@@ -0,0 +1,124 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resourceapi "k8s.io/api/resource/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/dra-example-driver/internal/profiles/cpu"
+)
+
+// allocatedClaim returns a claim with a single device allocated from pool.
+func allocatedClaim(uid types.UID, pool, device string) *resourceapi.ResourceClaim {
+	return &resourceapi.ResourceClaim{
+		ObjectMeta: metav1.ObjectMeta{UID: uid},
+		Status: resourceapi.ResourceClaimStatus{
+			Allocation: &resourceapi.AllocationResult{
+				Devices: resourceapi.DeviceAllocationResult{Results: []resourceapi.DeviceRequestAllocationResult{{
+					Request: "cpus",
+					Driver:  testDriverName,
+					Pool:    pool,
+					Device:  device,
+				}}},
+			},
+		},
+	}
+}
+
+func TestHotplug(t *testing.T) {
+	state := newTestState(t, cpu.NewProfile(testNodeName, testDriverName, 2, 4, false, nil))
+	claim := func(uid types.UID, device string) *resourceapi.ResourceClaim {
+		return allocatedClaim(uid, testNodeName, device)
+	}
+	ctx := context.Background()
+
+	_, err := state.Prepare(ctx, claim("claim-a", "numa-0"))
+	require.NoError(t, err)
+
+	claims, err := state.UnplugDevice("numa-0")
+	require.NoError(t, err)
+	assert.Equal(t, []types.UID{"claim-a"}, claims)
+	_, err = state.UnplugDevice("numa-0")
+	require.ErrorIs(t, err, errDeviceNotFound)
+
+	report, err := state.Devices()
+	require.NoError(t, err)
+	assert.Equal(t, &DeviceReport{
+		Devices:        []string{"numa-1"},
+		Unplugged:      []string{"numa-0"},
+		OrphanedClaims: map[string][]types.UID{"numa-0": {"claim-a"}},
+	}, report)
+	assert.Len(t, state.DriverResources().Pools[testNodeName].Slices[0].Devices, 1)
+
+	// An already prepared claim is still served, new claims are rejected.
+	_, err = state.Prepare(ctx, claim("claim-a", "numa-0"))
+	require.NoError(t, err)
+	_, err = state.Prepare(ctx, claim("claim-b", "numa-0"))
+	require.ErrorContains(t, err, "requested device is not allocatable: numa-0")
+
+	require.NoError(t, state.Unprepare(ctx, "claim-a"))
+	report, err = state.Devices()
+	require.NoError(t, err)
+	assert.Empty(t, report.OrphanedClaims)
+
+	require.NoError(t, state.PlugDevice("numa-0", nil))
+	require.ErrorIs(t, state.PlugDevice("numa-0", nil), errDeviceExists)
+	_, err = state.Prepare(ctx, claim("claim-b", "numa-0"))
+	require.NoError(t, err)
+
+	// New devices can be added and removed again.
+	require.ErrorIs(t, state.PlugDevice("numa-2", nil), errDeviceNotFound)
+	require.NoError(t, state.PlugDevice("numa-2", &resourceapi.Device{}))
+	report, err = state.Devices()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"numa-0", "numa-1", "numa-2"}, report.Devices)
+	assert.Empty(t, report.Unplugged)
+
+	claims, err = state.UnplugDevice("numa-2")
+	require.NoError(t, err)
+	assert.Empty(t, claims)
+	report, err = state.Devices()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"numa-0", "numa-1"}, report.Devices)
+	assert.Empty(t, report.Unplugged)
+}
+
+func TestNetworkAttachedDevices(t *testing.T) {
+	state := newTestState(t, cpu.NewProfile(testNodeName, testDriverName, 2, 4, false, nil))
+	ctx := context.Background()
+
+	// Devices from pools published by the controller are not advertised by
+	// the kubelet plugin itself but can still be prepared.
+	_, err := state.Prepare(ctx, allocatedClaim("claim-a", "fabric-a", "numa-100"))
+	require.NoError(t, err)
+	_, err = state.Prepare(ctx, allocatedClaim("claim-b", testNodeName, "numa-100"))
+	require.ErrorContains(t, err, "requested device is not allocatable: numa-100")
+
+	report, err := state.Devices()
+	require.NoError(t, err)
+	assert.Empty(t, report.OrphanedClaims)
+
+	require.NoError(t, state.Unprepare(ctx, "claim-a"))
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	checkpointapi "sigs.k8s.io/dra-example-driver/internal/api/checkpoint"
+)
+
+// keyedMutex provides a mutex per key, e.g. per claim or device, so that
+// work on different keys can proceed concurrently. Mutexes are created on
+// demand and dropped once nobody holds or waits for them.
+type keyedMutex[K comparable] struct {
+	mu    sync.Mutex
+	locks map[K]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	users int
+}
+
+// lock locks the mutexes of keys one after another and returns a function
+// which unlocks them again. Callers locking several keys must lock them in
+// the same order to avoid deadlocks, and keys must not repeat.
+func (m *keyedMutex[K]) lock(keys ...K) (unlock func()) {
+	for _, key := range keys {
+		m.acquire(key).Lock()
+	}
+	return func() {
+		for _, key := range keys {
+			m.release(key)
+		}
+	}
+}
+
+// acquire returns the mutex of key, which stays in use until it is released.
+func (m *keyedMutex[K]) acquire(key K) *keyedLock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.locks == nil {
+		m.locks = make(map[K]*keyedLock)
+	}
+	l, exists := m.locks[key]
+	if !exists {
+		l = new(keyedLock)
+		m.locks[key] = l
+	}
+	l.users++
+	return l
+}
+
+// release unlocks the mutex of key and drops it when it is no longer used.
+func (m *keyedMutex[K]) release(key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l := m.locks[key]
+	l.Unlock()
+	l.users--
+	if l.users == 0 {
+		delete(m.locks, key)
+	}
+}
+
+// claimDevices returns the devices of the driver allocated to a claim in the
+// order in which they must be locked.
+func (s *DeviceState) claimDevices(claim *resourceapi.ResourceClaim) []deviceKey {
+	var keys []deviceKey
+	if claim.Status.Allocation != nil {
+		for _, result := range claim.Status.Allocation.Devices.Results {
+			if result.Driver == s.driverName {
+				keys = append(keys, deviceKey{pool: result.Pool, device: result.Device})
+			}
+		}
+	}
+	return sortedDeviceKeys(keys)
+}
+
+// preparedClaimDevices returns the devices recorded for a prepared claim in
+// checkpoint in the order in which they must be locked.
+func preparedClaimDevices(checkpoint *checkpointapi.Checkpoint, claimUID types.UID) []deviceKey {
+	var keys []deviceKey
+	for _, claim := range checkpoint.PreparedClaims {
+		if claim.UID != claimUID {
+			continue
+		}
+		for _, device := range claim.Devices {
+			keys = append(keys, deviceKey{pool: device.PoolName, device: device.DeviceName})
+		}
+	}
+	return sortedDeviceKeys(keys)
+}
+
+// sortedDeviceKeys sorts keys and removes duplicates, which occur when a
+// device is allocated several times.
+func sortedDeviceKeys(keys []deviceKey) []deviceKey {
+	slices.SortFunc(keys, func(a, b deviceKey) int {
+		return cmp.Or(cmp.Compare(a.pool, b.pool), cmp.Compare(a.device, b.device))
+	})
+	return slices.Compact(keys)
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	var m keyedMutex[string]
+
+	// Holders of the same key exclude each other.
+	var active, overlaps atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			unlock := m.lock("a", "b")
+			defer unlock()
+			if active.Add(1) > 1 {
+				overlaps.Add(1)
+			}
+			defer active.Add(-1)
+			time.Sleep(time.Millisecond)
+		})
+	}
+	wg.Wait()
+	assert.Zero(t, overlaps.Load())
+
+	// Different keys do not.
+	unlock := m.lock("a")
+	locked := make(chan struct{})
+	go func() {
+		defer close(locked)
+		m.lock("b")()
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("locking another key must not block")
+	}
+	unlock()
+
+	assert.Empty(t, m.locks, "unused mutexes must be dropped")
+}
+
+func TestSortedDeviceKeys(t *testing.T) {
+	keys := sortedDeviceKeys([]deviceKey{
+		{pool: "pool-b", device: "dev-0"},
+		{pool: "pool-a", device: "dev-1"},
+		{pool: "pool-a", device: "dev-0"},
+		{pool: "pool-a", device: "dev-1"},
+	})
+	assert.Equal(t, []deviceKey{
+		{pool: "pool-a", device: "dev-0"},
+		{pool: "pool-a", device: "dev-1"},
+		{pool: "pool-b", device: "dev-0"},
+	}, keys)
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	resourceapi "k8s.io/api/resource/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	draclient "k8s.io/dynamic-resource-allocation/client"
+	"k8s.io/klog/v2"
+
+	checkpointapi "sigs.k8s.io/dra-example-driver/internal/api/checkpoint"
+	"sigs.k8s.io/dra-example-driver/pkg/metrics"
+)
+
+// Reasons of the events emitted for orphaned claims.
+const (
+	reasonOrphanedClaimUnprepared      = "OrphanedClaimUnprepared"
+	reasonFailedOrphanedClaimUnprepare = "FailedOrphanedClaimUnprepare"
+)
+
+// claimReconciler unprepares claims which are recorded as prepared in the
+// checkpoint but were deleted or deallocated without the kubelet asking the
+// driver to unprepare them, e.g. because the driver was not running at the
+// time.
+type claimReconciler struct {
+	state    *DeviceState
+	recorder record.EventRecorder
+}
+
+func newClaimReconciler(state *DeviceState, recorder record.EventRecorder) *claimReconciler {
+	return &claimReconciler{
+		state:    state,
+		recorder: recorder,
+	}
+}
+
+// run reconciles the prepared claims once and then every interval until the
+// context is canceled. A non-positive interval only reconciles once.
+func (r *claimReconciler) run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		r.reconcile(ctx)
+		return
+	}
+	wait.UntilWithContext(ctx, r.reconcile, interval)
+}
+
+// reconcile unprepares all orphaned claims in the checkpoint.
+func (r *claimReconciler) reconcile(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+
+	checkpoint, err := r.state.checkpoint.read()
+	if err != nil {
+		logger.Error(err, "Unable to read checkpoint, skipping reconciliation of prepared claims")
+		return
+	}
+
+	for _, prepared := range checkpoint.PreparedClaims {
+		// Claims prepared by older versions of the driver cannot be looked
+		// up, they are left to the kubelet.
+		if prepared.Name == "" {
+			continue
+		}
+		logger := klog.LoggerWithValues(logger, "uid", prepared.UID, "namespace", prepared.Namespace, "name", prepared.Name)
+
+		reason, err := r.orphanReason(ctx, prepared)
+		if err != nil {
+			logger.Error(err, "Unable to check whether prepared claim is orphaned")
+			continue
+		}
+		if reason == "" {
+			continue
+		}
+
+		logger.Info("Unpreparing orphaned claim", "reason", reason)
+		err = r.state.Unprepare(ctx, prepared.UID)
+		metrics.ObserveOrphanedClaim(reason, err)
+		ref := claimReference(prepared)
+		if err != nil {
+			logger.Error(err, "Unable to unprepare orphaned claim")
+			r.recorder.Eventf(ref, corev1.EventTypeWarning, reasonFailedOrphanedClaimUnprepare,
+				"Failed to unprepare devices of %s claim on node %s: %v", reason, r.state.nodeName, err)
+			continue
+		}
+		r.recorder.Eventf(ref, corev1.EventTypeNormal, reasonOrphanedClaimUnprepared,
+			"Unprepared devices of %s claim on node %s", reason, r.state.nodeName)
+	}
+}
+
+// orphanReason returns why a prepared claim is orphaned, or an empty string
+// if the claim is still allocated to the devices it was prepared for.
+func (r *claimReconciler) orphanReason(ctx context.Context, prepared checkpointapi.PreparedClaim) (string, error) {
+	claim, err := draclient.New(r.state.coreClient).ResourceClaims(prepared.Namespace).Get(ctx, prepared.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return metrics.OrphanReasonDeleted, nil
+	case err != nil:
+		return "", fmt.Errorf("get claim: %w", err)
+	case claim.UID != prepared.UID:
+		// The claim was deleted and recreated with the same name.
+		return metrics.OrphanReasonDeleted, nil
+	case claim.Status.Allocation == nil:
+		return metrics.OrphanReasonDeallocated, nil
+	}
+
+	for _, device := range prepared.Devices {
+		allocated := slices.ContainsFunc(claim.Status.Allocation.Devices.Results, func(result resourceapi.DeviceRequestAllocationResult) bool {
+			return result.Driver == r.state.driverName && result.Pool == device.PoolName && result.Device == device.DeviceName
+		})
+		if !allocated {
+			return metrics.OrphanReasonDeallocated, nil
+		}
+	}
+	return "", nil
+}
+
+// claimReference refers to a prepared claim in events. It is built from the
+// checkpoint because the claim may no longer exist.
+func claimReference(prepared checkpointapi.PreparedClaim) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: resourceapi.SchemeGroupVersion.String(),
+		Kind:       "ResourceClaim",
+		Namespace:  prepared.Namespace,
+		Name:       prepared.Name,
+		UID:        prepared.UID,
+	}
+}
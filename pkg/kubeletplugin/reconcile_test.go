@@ -0,0 +1,145 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	checkpointapi "sigs.k8s.io/dra-example-driver/internal/api/checkpoint"
+	"sigs.k8s.io/dra-example-driver/internal/profiles/cpu"
+)
+
+func TestReconcileClaims(t *testing.T) {
+	prepared := func() *resourceapi.ResourceClaim {
+		claim := allocatedClaim("claim-a", testNodeName, "numa-0")
+		claim.Namespace = "default"
+		claim.Name = "claim"
+		return claim
+	}
+
+	testcases := map[string]struct {
+		claim     *resourceapi.ResourceClaim
+		legacy    bool
+		orphaned  bool
+		wantEvent string
+	}{
+		"allocated": {
+			claim: prepared(),
+		},
+		"deleted": {
+			orphaned:  true,
+			wantEvent: "Normal OrphanedClaimUnprepared Unprepared devices of deleted claim on node test-node",
+		},
+		"recreated": {
+			claim: func() *resourceapi.ResourceClaim {
+				claim := prepared()
+				claim.UID = "claim-b"
+				return claim
+			}(),
+			orphaned:  true,
+			wantEvent: "Normal OrphanedClaimUnprepared Unprepared devices of deleted claim on node test-node",
+		},
+		"deallocated": {
+			claim: func() *resourceapi.ResourceClaim {
+				claim := prepared()
+				claim.Status.Allocation = nil
+				return claim
+			}(),
+			orphaned:  true,
+			wantEvent: "Normal OrphanedClaimUnprepared Unprepared devices of deallocated claim on node test-node",
+		},
+		"allocated to other device": {
+			claim: func() *resourceapi.ResourceClaim {
+				claim := prepared()
+				claim.Status.Allocation.Devices.Results[0].Pool = "other-node"
+				return claim
+			}(),
+			orphaned:  true,
+			wantEvent: "Normal OrphanedClaimUnprepared Unprepared devices of deallocated claim on node test-node",
+		},
+		"prepared without name": {
+			legacy: true,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			cdiRoot := t.TempDir()
+			var objects []runtime.Object
+			if tc.claim != nil {
+				objects = append(objects, tc.claim)
+			}
+			state := newTestState(t, cpu.NewProfile(testNodeName, testDriverName, 2, 4, false, nil),
+				withClient(fake.NewClientset(objects...)), withCDIRoot(cdiRoot))
+
+			_, err := state.Prepare(ctx, prepared())
+			require.NoError(t, err)
+			if tc.legacy {
+				require.NoError(t, state.checkpoint.update(func(checkpoint *checkpointapi.Checkpoint) {
+					checkpoint.PreparedClaims[0].Namespace = ""
+					checkpoint.PreparedClaims[0].Name = ""
+				}))
+			}
+
+			recorder := record.NewFakeRecorder(10)
+			newClaimReconciler(state, recorder).reconcile(ctx)
+
+			checkpoint, err := state.checkpoint.read()
+			require.NoError(t, err)
+			specs, err := filepath.Glob(filepath.Join(cdiRoot, "*claim-a*"))
+			require.NoError(t, err)
+			if tc.orphaned {
+				assert.Empty(t, checkpoint.PreparedClaims)
+				assert.Empty(t, specs)
+			} else {
+				assert.Equal(t, []types.UID{"claim-a"}, preparedClaimUIDs(checkpoint))
+				assert.Len(t, specs, 1)
+			}
+
+			close(recorder.Events)
+			var events []string
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			if tc.wantEvent == "" {
+				assert.Empty(t, events)
+			} else {
+				assert.Equal(t, []string{tc.wantEvent}, events)
+			}
+		})
+	}
+}
+
+func preparedClaimUIDs(checkpoint *checkpointapi.Checkpoint) []types.UID {
+	var uids []types.UID
+	for _, claim := range checkpoint.PreparedClaims {
+		uids = append(uids, claim.UID)
+	}
+	return uids
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	checkpointapi "sigs.k8s.io/dra-example-driver/internal/api/checkpoint"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+// deviceKey identifies a device across pools.
+type deviceKey struct {
+	pool   string
+	device string
+}
+
+// sharedDevice is the effective config of a device used by prepared claims.
+// The claims are reference-counted: the device is released and may take on a
+// different config once the last of them is unprepared.
+type sharedDevice struct {
+	config string
+	claims []types.UID
+}
+
+// sharedDevices returns the devices of all claims in checkpoint which were
+// prepared with a shared config.
+func sharedDevices(checkpoint *checkpointapi.Checkpoint) map[deviceKey]*sharedDevice {
+	devices := make(map[deviceKey]*sharedDevice)
+	for _, claim := range checkpoint.PreparedClaims {
+		for _, device := range claim.Devices {
+			addSharedDevice(devices, claim.UID, device.PoolName, device.DeviceName, device.SharedConfig)
+		}
+	}
+	return devices
+}
+
+// addSharedDevice adds a reference to a device used by a claim with the given
+// shared config.
+func addSharedDevice(devices map[deviceKey]*sharedDevice, claimUID types.UID, pool, device, config string) {
+	if config == "" {
+		return
+	}
+	key := deviceKey{pool: pool, device: device}
+	shared, exists := devices[key]
+	if !exists {
+		shared = &sharedDevice{config: config}
+		devices[key] = shared
+	}
+	if !slices.Contains(shared.claims, claimUID) {
+		shared.claims = append(shared.claims, claimUID)
+	}
+}
+
+// checkSharedConfigs returns an error if the shared config of a device
+// prepared for a claim conflicts with the effective config of the device,
+// either as used by other prepared claims or by other requests of the same
+// claim.
+func checkSharedConfigs(checkpoint *checkpointapi.Checkpoint, claimUID types.UID, preparedDevices PreparedDevices) error {
+	devices := sharedDevices(checkpoint)
+	for _, device := range preparedDevices {
+		if device.SharedConfig == "" {
+			continue
+		}
+		shared := devices[deviceKey{pool: device.PoolName, device: device.DeviceName}]
+		if shared != nil && shared.config != device.SharedConfig {
+			return fmt.Errorf("config %q for device %v conflicts with config %q of claims %v sharing the device", device.SharedConfig, device.DeviceName, shared.config, shared.claims)
+		}
+		addSharedDevice(devices, claimUID, device.PoolName, device.DeviceName, device.SharedConfig)
+	}
+	return nil
+}
+
+// sharedConfig returns the part of config which all claims using a device must
+// agree on when the profile implements [profiles.SharedConfigHandler].
+func (s *DeviceState) sharedConfig(config runtime.Object) (string, error) {
+	handler, ok := s.configHandler.(profiles.SharedConfigHandler)
+	if !ok {
+		return "", nil
+	}
+	return handler.SharedConfig(config)
+}
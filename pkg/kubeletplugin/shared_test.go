@@ -0,0 +1,153 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	drapbv1 "k8s.io/kubelet/pkg/apis/dra/v1"
+
+	checkpointapi "sigs.k8s.io/dra-example-driver/internal/api/checkpoint"
+)
+
+func TestCheckSharedConfigs(t *testing.T) {
+	checkpoint := &checkpointapi.Checkpoint{
+		PreparedClaims: []checkpointapi.PreparedClaim{
+			{UID: "claim-a", Devices: []checkpointapi.PreparedDevice{{PoolName: "pool", DeviceName: "dev-0", SharedConfig: "mode-a"}}},
+			{UID: "claim-b", Devices: []checkpointapi.PreparedDevice{{PoolName: "pool", DeviceName: "dev-0", SharedConfig: "mode-a"}}},
+			{UID: "claim-c", Devices: []checkpointapi.PreparedDevice{{PoolName: "pool", DeviceName: "dev-1"}}},
+		},
+	}
+	device := func(pool, name, config string) *PreparedDevice {
+		return &PreparedDevice{
+			Device:       drapbv1.Device{PoolName: pool, DeviceName: name},
+			SharedConfig: config,
+		}
+	}
+
+	tests := map[string]struct {
+		preparedDevices PreparedDevices
+		expectedErr     string
+	}{
+		"same config": {
+			preparedDevices: PreparedDevices{device("pool", "dev-0", "mode-a")},
+		},
+		"conflicting config": {
+			preparedDevices: PreparedDevices{device("pool", "dev-0", "mode-b")},
+			expectedErr:     `config "mode-b" for device dev-0 conflicts with config "mode-a" of claims [claim-a claim-b] sharing the device`,
+		},
+		"no shared config": {
+			preparedDevices: PreparedDevices{device("pool", "dev-0", "")},
+		},
+		"device without shared config": {
+			preparedDevices: PreparedDevices{device("pool", "dev-1", "mode-b")},
+		},
+		"device of other pool": {
+			preparedDevices: PreparedDevices{device("other-pool", "dev-0", "mode-b")},
+		},
+		"conflict within claim": {
+			preparedDevices: PreparedDevices{device("pool", "dev-2", "mode-a"), device("pool", "dev-2", "mode-b")},
+			expectedErr:     `config "mode-b" for device dev-2 conflicts with config "mode-a" of claims [claim-d] sharing the device`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := checkSharedConfigs(checkpoint, "claim-d", test.preparedDevices)
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+// TestSharedConfigRefCount verifies that a device shared by several claims
+// keeps its config until the last of them is unprepared.
+func TestSharedConfigRefCount(t *testing.T) {
+	state := newTestState(t, newTestGpuProfile(nil))
+	ctx := context.Background()
+
+	defaultTimeSlicing := `{"strategy":"TimeSlicing","timeSlicingConfig":{"interval":"Default"}}`
+	impliedTimeSlicing := `{"strategy":"TimeSlicing"}`
+	longTimeSlicing := `{"strategy":"TimeSlicing","timeSlicingConfig":{"interval":"Long"}}`
+
+	prepare := func(uid types.UID, sharing string) error {
+		_, err := state.Prepare(ctx, gpuClaim(uid, sharing, "gpu-0"))
+		return err
+	}
+
+	require.NoError(t, prepare("claim-a", defaultTimeSlicing))
+	require.NoError(t, prepare("claim-b", impliedTimeSlicing), "configs must be compared with their implied defaults")
+	require.NoError(t, prepare("claim-a", defaultTimeSlicing), "preparing a claim again must succeed")
+	err := prepare("claim-c", longTimeSlicing)
+	require.ErrorContains(t, err, `config "TimeSlicing with Long interval" for device gpu-0 conflicts with config "TimeSlicing with Default interval" of claims [claim-a claim-b] sharing the device`)
+
+	require.NoError(t, state.Unprepare(ctx, "claim-a"))
+	err = prepare("claim-c", longTimeSlicing)
+	require.ErrorContains(t, err, "of claims [claim-b] sharing the device", "the device must remain in use by claim-b")
+
+	require.NoError(t, state.Unprepare(ctx, "claim-b"))
+	require.NoError(t, prepare("claim-c", longTimeSlicing), "the device must be released with its last claim")
+
+	checkpoint, err := state.checkpoint.read()
+	require.NoError(t, err)
+	assert.Equal(t, map[deviceKey]*sharedDevice{
+		{pool: testNodeName, device: "gpu-0"}: {config: "TimeSlicing with Long interval", claims: []types.UID{"claim-c"}},
+	}, sharedDevices(checkpoint))
+}
+
+// TestSharedConfigConcurrently verifies that claims prepared at the same time
+// on a shared device cannot end up with conflicting configs.
+func TestSharedConfigConcurrently(t *testing.T) {
+	state := newTestState(t, newTestGpuProfile(nil))
+	ctx := context.Background()
+
+	intervals := []string{"Short", "Long"}
+	errs := make([]error, 10)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Go(func() {
+			sharing := `{"strategy":"TimeSlicing","timeSlicingConfig":{"interval":"` + intervals[i%2] + `"}}`
+			_, errs[i] = state.Prepare(ctx, gpuClaim(types.UID(fmt.Sprintf("claim-%d", i)), sharing, "gpu-0"))
+		})
+	}
+	wg.Wait()
+
+	var prepared []types.UID
+	for i, err := range errs {
+		if err == nil {
+			prepared = append(prepared, types.UID(fmt.Sprintf("claim-%d", i)))
+			continue
+		}
+		require.ErrorContains(t, err, "sharing the device")
+	}
+	assert.Len(t, prepared, 5, "only the claims agreeing with the first one must be prepared")
+
+	checkpoint, err := state.checkpoint.read()
+	require.NoError(t, err)
+	devices := sharedDevices(checkpoint)
+	require.Len(t, devices, 1)
+	assert.ElementsMatch(t, prepared, devices[deviceKey{pool: testNodeName, device: "gpu-0"}].claims)
+}
@@ -0,0 +1,833 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	resourceapi "k8s.io/api/resource/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	coreclientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	draclient "k8s.io/dynamic-resource-allocation/client"
+	"k8s.io/dynamic-resource-allocation/resourceslice"
+
+	"k8s.io/klog/v2"
+	drapbv1 "k8s.io/kubelet/pkg/apis/dra/v1"
+	cdiapi "tags.cncf.io/container-device-interface/pkg/cdi"
+
+	checkpointapi "sigs.k8s.io/dra-example-driver/internal/api/checkpoint"
+	checkpointinstall "sigs.k8s.io/dra-example-driver/internal/api/checkpoint/install"
+	checkpointv1alpha1 "sigs.k8s.io/dra-example-driver/internal/api/checkpoint/v1"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles/helpers"
+)
+
+type AllocatableDevices map[string]resourceapi.Device
+type PreparedDevices []*PreparedDevice
+
+type OpaqueDeviceConfig struct {
+	Requests []string
+	Config   runtime.Object
+}
+
+type PreparedDevice struct {
+	drapbv1.Device
+	ContainerEdits *cdiapi.ContainerEdits
+	AdminAccess    bool
+	// ShareID distinguishes one allocation of a device shared via consumable
+	// capacity; it is nil for exclusively allocated devices.
+	ShareID *types.UID
+	// SharedConfig is the part of the device's config which all claims using
+	// the device must agree on, see [profiles.SharedConfigHandler].
+	SharedConfig string
+}
+
+func (pds PreparedDevices) GetDevices() []*drapbv1.Device {
+	var devices []*drapbv1.Device
+	for _, pd := range pds {
+		devices = append(devices, &pd.Device)
+	}
+	return devices
+}
+
+// DeviceState prepares claims concurrently. A claim is prepared or
+// unprepared only once at a time, and claims sharing a device are prepared
+// one after another. The embedded mutex only guards the set of advertised
+// devices.
+type DeviceState struct {
+	sync.Mutex
+	nodeName        string
+	driverName      string
+	cdi             *CDIHandler
+	driverResources resourceslice.DriverResources
+	allocatable     AllocatableDevices
+	configDecoder   runtime.Decoder
+	configHandler   profiles.ConfigHandler
+
+	// enumerated holds the devices last reported by the profile. The
+	// published driverResources are derived from it by applying any devices
+	// hot-plugged or hot-unplugged at runtime.
+	enumerated resourceslice.DriverResources
+	plugged    []resourceapi.Device
+	unplugged  sets.Set[string]
+
+	claimLocks  keyedMutex[types.UID]
+	deviceLocks keyedMutex[deviceKey]
+	checkpoint  *checkpointStore
+
+	hostInventory *hostInventory
+
+	// afterPrepareStep, when set, is called after each step of preparing a
+	// claim and may fail it. It is used by tests to inject faults.
+	afterPrepareStep func(name string) error
+
+	coreClient coreclientset.Interface
+}
+
+func NewDeviceState(config *Config) (*DeviceState, error) {
+	driverResources, err := config.profile.EnumerateDevices()
+	if err != nil {
+		return nil, fmt.Errorf("error enumerating all possible devices: %v", err)
+	}
+
+	hostInventoryDir := filepath.Join(config.DriverPluginPath(), HostInventoryDir)
+	if err := os.MkdirAll(hostInventoryDir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create host inventory directory: %w", err)
+	}
+
+	cdi, err := NewCDIHandler(config.flags.cdiRoot, config.flags.driverName, config.flags.profile, hostInventoryDir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create CDI handler: %v", err)
+	}
+
+	err = cdi.CreateCommonSpecFile()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create CDI spec file for common edits: %v", err)
+	}
+
+	configScheme := runtime.NewScheme()
+	configHandler := config.profile
+	sb := configHandler.SchemeBuilder()
+	if err := sb.AddToScheme(configScheme); err != nil {
+		return nil, fmt.Errorf("create config scheme: %w", err)
+	}
+
+	// Set up a json serializer to decode our types.
+	configDecoder := json.NewSerializerWithOptions(
+		json.DefaultMetaFactory,
+		configScheme,
+		configScheme,
+		json.SerializerOptions{
+			Pretty: true,
+			// Config objects are defined by users in ResourceClaims. Strict
+			// decoding helps prevent mistakes.
+			//
+			// Note: this flag only produces errors when decoding objects that
+			// define duplicate keys. Unknown fields are still silently dropped.
+			Strict: true,
+		},
+	)
+
+	checkpoint, err := newCheckpointStore(filepath.Join(config.DriverPluginPath(), DriverPluginCheckpointFile))
+	if err != nil {
+		return nil, err
+	}
+
+	state := &DeviceState{
+		nodeName:      config.flags.nodeName,
+		driverName:    config.flags.driverName,
+		cdi:           cdi,
+		enumerated:    driverResources,
+		unplugged:     sets.New[string](),
+		configDecoder: configDecoder,
+		configHandler: configHandler,
+		checkpoint:    checkpoint,
+		hostInventory: &hostInventory{dir: hostInventoryDir},
+		coreClient:    config.coreclient,
+	}
+	state.syncDevices()
+
+	return state, nil
+}
+
+// allocatableDevices returns the devices published for the given node, keyed
+// by device name.
+func allocatableDevices(driverResources resourceslice.DriverResources, nodeName string) AllocatableDevices {
+	allocatable := make(AllocatableDevices)
+	for _, slice := range driverResources.Pools[nodeName].Slices {
+		for _, device := range slice.Devices {
+			allocatable[device.Name] = device
+		}
+	}
+	return allocatable
+}
+
+// DriverResources returns the resources currently advertised by the driver.
+func (s *DeviceState) DriverResources() resourceslice.DriverResources {
+	s.Lock()
+	defer s.Unlock()
+	return s.driverResources
+}
+
+// UpdateDevices replaces the set of devices advertised by the driver. Claims
+// that are already prepared are not affected, but new claims may only be
+// prepared against devices that are part of driverResources. Devices
+// hot-unplugged at runtime remain hidden.
+func (s *DeviceState) UpdateDevices(driverResources resourceslice.DriverResources) {
+	s.Lock()
+	defer s.Unlock()
+	s.enumerated = driverResources
+	s.syncDevices()
+}
+
+func (s *DeviceState) Prepare(ctx context.Context, claim *resourceapi.ResourceClaim) (PreparedDevices, error) {
+	unlockClaim := s.claimLocks.lock(claim.UID)
+	defer unlockClaim()
+	unlockDevices := s.deviceLocks.lock(s.claimDevices(claim)...)
+	defer unlockDevices()
+
+	checkpoint, err := s.checkpoint.read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to sync from checkpoint: %v", err)
+	}
+	restoredDevices, err := s.restoreClaimFromCheckpoint(checkpoint, claim)
+	if err != nil {
+		return nil, fmt.Errorf("unable to restore from checkpoint: %v", err)
+	}
+	if restoredDevices != nil {
+		return restoredDevices, nil
+	}
+
+	preparedDevices, err := s.prepareDevices(ctx, checkpoint, claim)
+	if err != nil {
+		return nil, fmt.Errorf("prepare failed: %v", err)
+	}
+	s.updateHostInventory(ctx)
+
+	return preparedDevices, nil
+}
+
+func (s *DeviceState) Unprepare(ctx context.Context, claimUID types.UID) error {
+	unlockClaim := s.claimLocks.lock(claimUID)
+	defer unlockClaim()
+
+	checkpoint, err := s.checkpoint.read()
+	if err != nil {
+		checkpoint = new(checkpointapi.Checkpoint)
+		if err := s.checkpoint.write(checkpoint); err != nil {
+			return fmt.Errorf("unable to create new checkpoint: %v", err)
+		}
+	}
+	unlockDevices := s.deviceLocks.lock(preparedClaimDevices(checkpoint, claimUID)...)
+	defer unlockDevices()
+
+	if err := s.unprepareDevices(ctx, claimUID); err != nil {
+		return fmt.Errorf("unprepare failed: %v", err)
+	}
+
+	err = s.cdi.DeleteClaimSpecFile(string(claimUID))
+	if err != nil {
+		return fmt.Errorf("unable to delete CDI spec file for claim: %v", err)
+	}
+
+	err = s.checkpoint.update(func(checkpoint *checkpointapi.Checkpoint) {
+		s.removeClaimFromCheckpoint(checkpoint, claimUID)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to sync to checkpoint: %v", err)
+	}
+	s.updateHostInventory(ctx)
+
+	return nil
+}
+
+// prepareDevices performs one-time setup for the devices allocated to a
+// ResourceClaim before being consumed by a Pod, creates the claim's CDI spec
+// file and records the claim in the checkpoint. The setup is done as a
+// [transaction], so that the claim is either prepared completely or not at
+// all. State of the devices which outlives the claim is recorded in
+// checkpoint.
+func (s *DeviceState) prepareDevices(ctx context.Context, checkpoint *checkpointapi.Checkpoint, claim *resourceapi.ResourceClaim) (PreparedDevices, error) {
+	// Only newly prepared claims must refer to advertised devices. A claim
+	// that was already prepared is restored from the checkpoint even when its
+	// device has since been removed so that it can still be unprepared.
+	if err := s.checkAllocatable(claim); err != nil {
+		return nil, err
+	}
+
+	preparedDevices, err := s.computeDeviceConfig(claim)
+	if err != nil {
+		return nil, err
+	}
+
+	// A device may already be used by other prepared claims, which must
+	// agree on the config of the device as a whole.
+	if err := checkSharedConfigs(checkpoint, claim.UID, preparedDevices); err != nil {
+		return nil, err
+	}
+
+	// The steps below have side-effects on the node and the API server. If
+	// any of them fails, the steps done so far are undone, so that nothing is
+	// left behind for a claim which is not prepared. The claim only counts as
+	// prepared once it is recorded in the checkpoint, which therefore comes
+	// last.
+	var statusPublished bool
+	tx := &transaction{
+		steps: []step{
+			{
+				// Bring the devices into the state their config requires,
+				// e.g. program them with a bitstream. This may take a while,
+				// so it is only done once the config is known to be valid.
+				// The state of a device outlives the claim and is recorded
+				// even on failure, so there is nothing to undo.
+				name: "prepare device states",
+				do: func(ctx context.Context) error {
+					return s.prepareDeviceStates(ctx, checkpoint, claim)
+				},
+			},
+			{
+				// Configure the devices for the claim, e.g. split a GPU
+				// into partitions.
+				name: "configure devices",
+				do: func(ctx context.Context) error {
+					return s.configureDevices(ctx, claim)
+				},
+				undo: func(ctx context.Context) error {
+					return s.releaseDevices(ctx, claim.UID)
+				},
+			},
+			{
+				name: "create CDI spec file",
+				do: func(context.Context) error {
+					return s.cdi.CreateClaimSpecFile(string(claim.UID), preparedDevices)
+				},
+				undo: func(context.Context) error {
+					return s.cdi.DeleteClaimSpecFile(string(claim.UID))
+				},
+			},
+			{
+				name: "publish device status",
+				do: func(ctx context.Context) error {
+					var err error
+					statusPublished, err = s.publishDeviceStatus(ctx, claim)
+					return err
+				},
+				undo: func(ctx context.Context) error {
+					if !statusPublished {
+						return nil
+					}
+					return s.updateDeviceStatus(ctx, claim.Namespace, claim.Name)
+				},
+			},
+			{
+				// The claim was not in the checkpoint before, otherwise it
+				// would have been restored from it.
+				name: "update checkpoint",
+				do: func(context.Context) error {
+					return s.checkpoint.update(func(checkpoint *checkpointapi.Checkpoint) {
+						s.addClaimToCheckpoint(checkpoint, claim, preparedDevices)
+					})
+				},
+				undo: func(context.Context) error {
+					return s.checkpoint.update(func(checkpoint *checkpointapi.Checkpoint) {
+						s.removeClaimFromCheckpoint(checkpoint, claim.UID)
+					})
+				},
+			},
+		},
+		afterStep: s.afterPrepareStep,
+	}
+	if err := tx.run(ctx); err != nil {
+		return nil, err
+	}
+
+	return preparedDevices, nil
+}
+
+// publishDeviceStatus publishes per-device status (e.g. uuid, model,
+// driverVersion) into ResourceClaim.status.devices[].data when the profile
+// implements [profiles.DeviceStatusBuilder]. It reports whether the status
+// was published.
+//
+// This is a side-effect on the API server and therefore part of
+// [DeviceState.prepareDevices] (rather than computeDeviceConfig, which must be
+// deterministic and side-effect free).
+func (s *DeviceState) publishDeviceStatus(ctx context.Context, claim *resourceapi.ResourceClaim) (bool, error) {
+	builder, ok := s.configHandler.(profiles.DeviceStatusBuilder)
+	if !ok {
+		return false, nil
+	}
+	configs, err := s.getDeviceConfigs(claim)
+	if err != nil {
+		return false, err
+	}
+
+	// The advertised devices may change concurrently, but are never modified
+	// in place.
+	s.Lock()
+	allocatable := s.allocatable
+	s.Unlock()
+
+	var deviceStatuses []resourceapi.AllocatedDeviceStatus
+	for _, result := range claim.Status.Allocation.Devices.Results {
+		if result.Driver != s.driverName {
+			continue
+		}
+		config := configForRequest(configs, result.Request)
+		if status := builder.BuildDeviceStatus(allocatable, config, &result); status != nil {
+			deviceStatuses = append(deviceStatuses, *status)
+		}
+	}
+	if len(deviceStatuses) == 0 {
+		return false, nil
+	}
+
+	klog.FromContext(ctx).Info("Publishing device status to ResourceClaim",
+		"namespace", claim.Namespace, "name", claim.Name, "devices", len(deviceStatuses))
+	if err := s.updateDeviceStatus(ctx, claim.Namespace, claim.Name, deviceStatuses...); err != nil {
+		// A failure to publish status is non-fatal: the device is still
+		// prepared and the claim status will simply be missing the data.
+		klog.FromContext(ctx).Error(err, "Failed to update device status on ResourceClaim",
+			"namespace", claim.Namespace, "name", claim.Name)
+		return false, nil
+	}
+	return true, nil
+}
+
+// prepareDeviceStates prepares the state of the devices allocated to the claim
+// when the profile implements [profiles.DeviceStatePreparer]. The state each
+// device was left in is recorded in checkpoint, so that it is known to the
+// next claim using the device.
+func (s *DeviceState) prepareDeviceStates(ctx context.Context, checkpoint *checkpointapi.Checkpoint, claim *resourceapi.ResourceClaim) error {
+	preparer, ok := s.configHandler.(profiles.DeviceStatePreparer)
+	if !ok {
+		return nil
+	}
+	configs, err := s.getDeviceConfigs(claim)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range claim.Status.Allocation.Devices.Results {
+		if result.Driver != s.driverName {
+			continue
+		}
+		config := configForRequest(configs, result.Request)
+		current := deviceStateFromCheckpoint(checkpoint, result.Pool, result.Device)
+		state, prepareErr := preparer.PrepareDeviceState(ctx, config, &result, current)
+		// Preparing may have changed the state of the device before failing,
+		// which must be recorded even though the claim is not prepared.
+		err := s.checkpoint.update(func(checkpoint *checkpointapi.Checkpoint) {
+			setDeviceStateInCheckpoint(checkpoint, result.Pool, result.Device, state)
+		})
+		if err != nil {
+			return fmt.Errorf("unable to sync state of device %v to checkpoint: %w", result.Device, err)
+		}
+		if prepareErr != nil {
+			return fmt.Errorf("error preparing device %v: %w", result.Device, prepareErr)
+		}
+	}
+	return nil
+}
+
+// configureDevices configures the devices allocated to the claim when the
+// profile implements [profiles.DeviceConfigurer]. If a device cannot be
+// configured, the devices already configured for the claim remain configured
+// until they are released with [DeviceState.releaseDevices].
+func (s *DeviceState) configureDevices(ctx context.Context, claim *resourceapi.ResourceClaim) error {
+	configurer, ok := s.configHandler.(profiles.DeviceConfigurer)
+	if !ok {
+		return nil
+	}
+	configs, err := s.getDeviceConfigs(claim)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range claim.Status.Allocation.Devices.Results {
+		if result.Driver != s.driverName {
+			continue
+		}
+		config := configForRequest(configs, result.Request)
+		if err := configurer.ConfigureDevice(ctx, claim.UID, config, &result); err != nil {
+			return fmt.Errorf("error configuring device %v: %w", result.Device, err)
+		}
+	}
+	return nil
+}
+
+// releaseDevices releases the devices configured for the claim when the
+// profile implements [profiles.DeviceConfigurer].
+func (s *DeviceState) releaseDevices(ctx context.Context, claimUID types.UID) error {
+	configurer, ok := s.configHandler.(profiles.DeviceConfigurer)
+	if !ok {
+		return nil
+	}
+	if err := configurer.ReleaseDevices(ctx, claimUID); err != nil {
+		return fmt.Errorf("error releasing devices: %w", err)
+	}
+	return nil
+}
+
+// unprepareDevices undoes any side-effects produced by
+// [DeviceState.prepareDevices] on the devices.
+func (s *DeviceState) unprepareDevices(ctx context.Context, claimUID types.UID) error {
+	return s.releaseDevices(ctx, claimUID)
+}
+
+// computeDeviceConfig computes the CDI config for devices allocated to the claim
+// designated for this driver. It is called each time the kubelet tells the
+// driver to prepare a claim which may occur more than once, and therefore
+// should be deterministic and produce no side-effects. Non-deterministic data or
+// side-effects should be produced by [DeviceState.prepareDevices] directly and
+// recorded in the checkpoint by [DeviceState.addClaimToCheckpoint].
+func (s *DeviceState) computeDeviceConfig(claim *resourceapi.ResourceClaim) (PreparedDevices, error) {
+	if claim.Status.Allocation == nil {
+		return nil, fmt.Errorf("claim not yet allocated")
+	}
+	// Check if any device request has admin access
+	hasAdminAccess := s.checkAdminAccess(claim)
+
+	configs, err := s.getDeviceConfigs(claim)
+	if err != nil {
+		return nil, err
+	}
+
+	// Look through the configs and figure out which one will be applied to
+	// each device allocation result based on their order of precedence.
+	configResultsMap := make(map[runtime.Object][]*resourceapi.DeviceRequestAllocationResult)
+	for _, result := range claim.Status.Allocation.Devices.Results {
+		// The claim may include allocations meant for other drivers.
+		if result.Driver != s.driverName {
+			continue
+		}
+
+		config := configForRequest(configs, result.Request)
+		configResultsMap[config] = append(configResultsMap[config], &result)
+	}
+
+	// Apply all configs associated with devices that need to be prepared.
+	// Track container edits generated from applying the config to the set
+	// of device allocation results.
+	perDeviceCDIContainerEdits := make(profiles.PerDeviceCDIContainerEdits)
+	for config, results := range configResultsMap {
+		// Apply the config to the list of results associated with it.
+		containerEdits, err := s.configHandler.ApplyConfig(config, results)
+		if err != nil {
+			return nil, fmt.Errorf("error applying config: %w", err)
+		}
+
+		// Merge any new container edits with the overall per device map.
+		for k, v := range containerEdits {
+			perDeviceCDIContainerEdits[k] = v
+		}
+	}
+
+	// Walk through each config and its associated device allocation results
+	// and construct the list of prepared devices to return.
+	var preparedDevices PreparedDevices
+	for config, results := range configResultsMap {
+		sharedConfig, err := s.sharedConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("error getting shared config: %w", err)
+		}
+		// Devices with admin access are only observed and therefore do
+		// not need to agree on the config of the device.
+		if hasAdminAccess {
+			sharedConfig = ""
+		}
+		for _, result := range results {
+			deviceID := helpers.GetCDIDeviceID(result.Device, (*string)(result.ShareID))
+			device := &PreparedDevice{
+				Device: drapbv1.Device{
+					RequestNames: []string{result.Request},
+					PoolName:     result.Pool,
+					DeviceName:   result.Device,
+					CdiDeviceIds: s.cdi.GetClaimDevices(string(claim.UID), []string{deviceID}),
+				},
+				ContainerEdits: perDeviceCDIContainerEdits[deviceID],
+				AdminAccess:    hasAdminAccess,
+				ShareID:        result.ShareID,
+				SharedConfig:   sharedConfig,
+			}
+			preparedDevices = append(preparedDevices, device)
+		}
+	}
+
+	return preparedDevices, nil
+}
+
+// getDeviceConfigs retrieves the full set of device configs of the claim for
+// the driver in order of increasing precedence.
+func (s *DeviceState) getDeviceConfigs(claim *resourceapi.ResourceClaim) ([]*OpaqueDeviceConfig, error) {
+	configs, err := GetOpaqueDeviceConfigs(
+		s.configDecoder,
+		s.driverName,
+		claim.Status.Allocation.Devices.Config,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting opaque device configs: %v", err)
+	}
+
+	// Add the default config to the front of the config list with the
+	// lowest precedence. This guarantees there will be at least one config in
+	// the list with len(Requests) == 0 for [configForRequest].
+	return slices.Insert(configs, 0, &OpaqueDeviceConfig{}), nil
+}
+
+// configForRequest returns the config with the highest precedence which
+// applies to the devices allocated for the named request. A nil config stands
+// for the profile's default config.
+func configForRequest(configs []*OpaqueDeviceConfig, request string) runtime.Object {
+	for _, c := range slices.Backward(configs) {
+		if len(c.Requests) == 0 || slices.Contains(c.Requests, request) {
+			return c.Config
+		}
+	}
+	return nil
+}
+
+// checkAllocatable returns an error if the claim has been allocated a device
+// of this driver from the node-local pool which is not currently advertised.
+func (s *DeviceState) checkAllocatable(claim *resourceapi.ResourceClaim) error {
+	if claim.Status.Allocation == nil {
+		return fmt.Errorf("claim not yet allocated")
+	}
+	s.Lock()
+	defer s.Unlock()
+	for _, result := range claim.Status.Allocation.Devices.Results {
+		if result.Driver != s.driverName {
+			continue
+		}
+		// Devices from other pools are network-attached devices published by
+		// the controller. The scheduler already made sure that they are
+		// accessible from this node.
+		if result.Pool != s.nodeName {
+			continue
+		}
+		if _, exists := s.allocatable[result.Device]; !exists {
+			return fmt.Errorf("requested device is not allocatable: %v", result.Device)
+		}
+	}
+	return nil
+}
+
+// addClaimToCheckpoint updates the checkpoint with results of preparing the
+// devices for the claim. If any parts of the [PreparedDevices] are
+// non-deterministic or expensive to recompute, then those should also be added
+// to the checkpoint here.
+func (*DeviceState) addClaimToCheckpoint(checkpoint *checkpointapi.Checkpoint, claim *resourceapi.ResourceClaim, preparedDevices PreparedDevices) {
+	preparedClaim := checkpointapi.PreparedClaim{
+		UID:       claim.UID,
+		Namespace: claim.Namespace,
+		Name:      claim.Name,
+	}
+	for _, device := range preparedDevices {
+		preparedClaim.Devices = append(preparedClaim.Devices, checkpointapi.PreparedDevice{
+			PoolName:     device.PoolName,
+			DeviceName:   device.DeviceName,
+			ShareID:      device.ShareID,
+			SharedConfig: device.SharedConfig,
+		})
+	}
+	checkpoint.PreparedClaims = append(checkpoint.PreparedClaims, preparedClaim)
+}
+
+// removeClaimFromCheckpoint updates the checkpoint to remove all data
+// associated with the claim.
+func (*DeviceState) removeClaimFromCheckpoint(checkpoint *checkpointapi.Checkpoint, claimUID types.UID) {
+	checkpoint.PreparedClaims = slices.DeleteFunc(checkpoint.PreparedClaims, func(c checkpointapi.PreparedClaim) bool { return c.UID == claimUID })
+}
+
+// deviceStateFromCheckpoint returns the state recorded for a device in the
+// checkpoint, or an empty string if there is none.
+func deviceStateFromCheckpoint(checkpoint *checkpointapi.Checkpoint, poolName, deviceName string) string {
+	for _, device := range checkpoint.DeviceStates {
+		if device.PoolName == poolName && device.DeviceName == deviceName {
+			return device.State
+		}
+	}
+	return ""
+}
+
+// setDeviceStateInCheckpoint records the state of a device in the checkpoint.
+// Devices with an empty state are removed from the checkpoint. Device states
+// are not removed together with claims as they outlive them.
+func setDeviceStateInCheckpoint(checkpoint *checkpointapi.Checkpoint, poolName, deviceName, state string) {
+	checkpoint.DeviceStates = slices.DeleteFunc(checkpoint.DeviceStates, func(d checkpointapi.DeviceState) bool {
+		return d.PoolName == poolName && d.DeviceName == deviceName
+	})
+	if state == "" {
+		return
+	}
+	checkpoint.DeviceStates = append(checkpoint.DeviceStates, checkpointapi.DeviceState{
+		PoolName:   poolName,
+		DeviceName: deviceName,
+		State:      state,
+	})
+}
+
+// restoreClaimFromCheckpoint returns the device definitions for devices already prepared
+// for the given claim. If the claim has not yet been prepared, it returns nil.
+func (s *DeviceState) restoreClaimFromCheckpoint(checkpoint *checkpointapi.Checkpoint, claim *resourceapi.ResourceClaim) (PreparedDevices, error) {
+	if slices.ContainsFunc(checkpoint.PreparedClaims, func(c checkpointapi.PreparedClaim) bool { return c.UID == claim.UID }) {
+		// If [DeviceState.addClaimToCheckpoint] associated any other data with
+		// the claim in the checkpoint, then that should be added to the
+		// returned [PreparedDevices] here.
+		return s.computeDeviceConfig(claim)
+	}
+	return nil, nil
+}
+
+// checkAdminAccess determines if a resource claim requires admin access.
+func (s *DeviceState) checkAdminAccess(claim *resourceapi.ResourceClaim) bool {
+	if claim != nil && claim.Status.Allocation != nil {
+		for _, result := range claim.Status.Allocation.Devices.Results {
+			if result.AdminAccess != nil && *result.AdminAccess {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func checkpointSerializer() (runtime.Decoder, runtime.Encoder, error) {
+	checkpointScheme := checkpointinstall.NewScheme()
+	checkpointJSON := json.NewSerializerWithOptions(
+		json.DefaultMetaFactory,
+		checkpointScheme,
+		checkpointScheme,
+		json.SerializerOptions{
+			Pretty: true,
+			// Checkpoints are meant to be read and written only by the driver,
+			// so there is minimal risk that strict decoding will identify any
+			// mistakes. Performance is the better trade-off.
+			Strict: false,
+		},
+	)
+	checkpointCodecFactory := serializer.NewCodecFactory(checkpointScheme)
+	checkpointEncoder := checkpointCodecFactory.EncoderForVersion(checkpointJSON, checkpointv1alpha1.SchemeGroupVersion)
+	checkpointDecoder := checkpointCodecFactory.UniversalDecoder(checkpointapi.SchemeGroupVersion)
+
+	return checkpointDecoder, checkpointEncoder, nil
+}
+
+// GetOpaqueDeviceConfigs returns an ordered list of the configs contained in possibleConfigs for this driver.
+//
+// Configs can either come from the resource claim itself or from the device
+// class associated with the request. Configs coming directly from the resource
+// claim take precedence over configs coming from the device class. Moreover,
+// configs found later in the list of configs attached to its source take
+// precedence over configs found earlier in the list for that source.
+//
+// All of the configs relevant to the driver from the list of possibleConfigs
+// will be returned in order of precedence (from lowest to highest). If no
+// configs are found, nil is returned.
+func GetOpaqueDeviceConfigs(
+	decoder runtime.Decoder,
+	driverName string,
+	possibleConfigs []resourceapi.DeviceAllocationConfiguration,
+) ([]*OpaqueDeviceConfig, error) {
+	// Collect all configs in order of reverse precedence.
+	var classConfigs []resourceapi.DeviceAllocationConfiguration
+	var claimConfigs []resourceapi.DeviceAllocationConfiguration
+	var candidateConfigs []resourceapi.DeviceAllocationConfiguration
+	for _, config := range possibleConfigs {
+		switch config.Source {
+		case resourceapi.AllocationConfigSourceClass:
+			classConfigs = append(classConfigs, config)
+		case resourceapi.AllocationConfigSourceClaim:
+			claimConfigs = append(claimConfigs, config)
+		default:
+			return nil, fmt.Errorf("invalid config source: %v", config.Source)
+		}
+	}
+	candidateConfigs = append(candidateConfigs, classConfigs...)
+	candidateConfigs = append(candidateConfigs, claimConfigs...)
+
+	// Decode all configs that are relevant for the driver.
+	var resultConfigs []*OpaqueDeviceConfig
+	for _, config := range candidateConfigs {
+		// If this is nil, the driver doesn't support some future API extension
+		// and needs to be updated.
+		if config.Opaque == nil {
+			return nil, fmt.Errorf("only opaque parameters are supported by this driver")
+		}
+
+		// Configs for different drivers may have been specified because a
+		// single request can be satisfied by different drivers. This is not
+		// an error -- drivers must skip over other driver's configs in order
+		// to support this.
+		if config.Opaque.Driver != driverName {
+			continue
+		}
+
+		decodedConfig, err := runtime.Decode(decoder, config.Opaque.Parameters.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding config parameters: %w", err)
+		}
+
+		resultConfig := &OpaqueDeviceConfig{
+			Requests: config.Requests,
+			Config:   decodedConfig,
+		}
+
+		resultConfigs = append(resultConfigs, resultConfig)
+	}
+
+	return resultConfigs, nil
+}
+
+func (s *DeviceState) updateDeviceStatus(ctx context.Context, ns, name string, devices ...resourceapi.AllocatedDeviceStatus) error {
+	// Converting wrapper to use latest API types,
+	// converts to/from server-supported version.
+	c := draclient.New(s.coreClient)
+	rc := c.ResourceClaims(ns)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		claim, err := rc.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		// copy the object and update only the status.devices entries of
+		// this driver, keeping those published by other drivers
+		claim = claim.DeepCopy()
+		var statuses []resourceapi.AllocatedDeviceStatus
+		for _, status := range claim.Status.Devices {
+			if status.Driver != s.driverName {
+				statuses = append(statuses, status)
+			}
+		}
+		claim.Status.Devices = append(statuses, devices...)
+
+		_, err = rc.UpdateStatus(ctx, claim, metav1.UpdateOptions{})
+		return err
+	})
+}
@@ -0,0 +1,462 @@
+/*
+ * Copyright 2025 The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	drapbv1 "k8s.io/kubelet/pkg/apis/dra/v1"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/dra-example-driver/internal/profiles/cpu"
+	"sigs.k8s.io/dra-example-driver/internal/profiles/fpga"
+	"sigs.k8s.io/dra-example-driver/internal/profiles/gpu"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
+)
+
+var (
+	testShareId = "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+)
+
+func TestPreparedDevicesGetDevices(t *testing.T) {
+	tests := map[string]struct {
+		preparedDevices PreparedDevices
+		expected        []*drapbv1.Device
+	}{
+		"nil PreparedDevices": {
+			preparedDevices: nil,
+			expected:        nil,
+		},
+		"several PreparedDevices": {
+			preparedDevices: PreparedDevices{
+				{Device: drapbv1.Device{DeviceName: "dev1"}},
+				{Device: drapbv1.Device{DeviceName: "dev2"}},
+				{Device: drapbv1.Device{DeviceName: "dev3"}},
+			},
+			expected: []*drapbv1.Device{
+				{DeviceName: "dev1"},
+				{DeviceName: "dev2"},
+				{DeviceName: "dev3"},
+			},
+		},
+		"preparedDevice with shareId": {
+			preparedDevices: PreparedDevices{
+				{Device: drapbv1.Device{DeviceName: "dev1", ShareId: &testShareId}},
+				{Device: drapbv1.Device{DeviceName: "dev2", ShareId: &testShareId}},
+			},
+			expected: []*drapbv1.Device{
+				{DeviceName: "dev1", ShareId: &testShareId},
+				{DeviceName: "dev2", ShareId: &testShareId},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			devices := test.preparedDevices.GetDevices()
+			assert.Equal(t, test.expected, devices)
+		})
+	}
+}
+
+// TestComputeDeviceConfigShareID verifies that the ShareID the scheduler
+// assigns to each allocation result is carried through to the prepared device,
+// which the kubelet plugin then forwards to the kubelet. This guards the
+// consumable-capacity sharing path: two allocations of the same device must
+// keep their distinct ShareIDs.
+func TestComputeDeviceConfigShareID(t *testing.T) {
+	const (
+		nodeName   = "test-node"
+		driverName = "cpu.example.com"
+	)
+
+	state := newTestState(t, cpu.NewProfile(nodeName, driverName, 1, 4, false, nil))
+
+	result := func(request string, shareID *types.UID) resourceapi.DeviceRequestAllocationResult {
+		return resourceapi.DeviceRequestAllocationResult{
+			Request: request,
+			Driver:  driverName,
+			Pool:    nodeName,
+			Device:  "numa-0",
+			ShareID: shareID,
+		}
+	}
+
+	tests := map[string]struct {
+		results          []resourceapi.DeviceRequestAllocationResult
+		expectedShareIDs []*types.UID
+		// expectedCDISuffixes are CDI device-ID suffixes that must each appear
+		// on some prepared device, proving the ShareID is woven into the CDI
+		// device name so shares of one device stay distinct.
+		expectedCDISuffixes []string
+	}{
+		"no ShareID": {
+			results:             []resourceapi.DeviceRequestAllocationResult{result("cpus", nil)},
+			expectedShareIDs:    []*types.UID{nil},
+			expectedCDISuffixes: []string{"claim-uid-numa-0"},
+		},
+		"distinct ShareIDs sharing one device": {
+			results: []resourceapi.DeviceRequestAllocationResult{
+				result("cpus-0", ptr.To(types.UID("share-0"))),
+				result("cpus-1", ptr.To(types.UID("share-1"))),
+			},
+			expectedShareIDs:    []*types.UID{ptr.To(types.UID("share-0")), ptr.To(types.UID("share-1"))},
+			expectedCDISuffixes: []string{"claim-uid-numa-0-share-0", "claim-uid-numa-0-share-1"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			claim := &resourceapi.ResourceClaim{
+				ObjectMeta: metav1.ObjectMeta{UID: "claim-uid"},
+				Status: resourceapi.ResourceClaimStatus{
+					Allocation: &resourceapi.AllocationResult{
+						Devices: resourceapi.DeviceAllocationResult{Results: test.results},
+					},
+				},
+			}
+
+			prepared, err := state.computeDeviceConfig(claim)
+			require.NoError(t, err)
+			require.Len(t, prepared, len(test.expectedShareIDs))
+
+			var shareIDs []*types.UID
+			var cdiDeviceIDs []string
+			for _, device := range prepared {
+				shareIDs = append(shareIDs, device.ShareID)
+				cdiDeviceIDs = append(cdiDeviceIDs, device.CdiDeviceIds...)
+			}
+			assert.ElementsMatch(t, test.expectedShareIDs, shareIDs)
+
+			for _, suffix := range test.expectedCDISuffixes {
+				matched := slices.ContainsFunc(cdiDeviceIDs, func(id string) bool {
+					return strings.HasSuffix(id, suffix)
+				})
+				assert.True(t, matched, "expected a CDI device ID ending with %q in %v", suffix, cdiDeviceIDs)
+			}
+		})
+	}
+}
+
+// TestComputeDeviceConfigSharedDeviceContainerEdits verifies that when one
+// device is allocated multiple times in a claim (consumable capacity), each
+// share keeps its own container edits keyed by the share-aware device id rather
+// than collapsing onto a single entry for the bare device name.
+func TestComputeDeviceConfigSharedDeviceContainerEdits(t *testing.T) {
+	const (
+		nodeName   = "test-node"
+		driverName = "cpu.example.com"
+	)
+
+	state := newTestState(t, cpu.NewProfile(nodeName, driverName, 1, 4, false, nil))
+
+	capacityKey := resourceapi.QualifiedName(driverName + "/cpu")
+	result := func(request string, shareID types.UID, consumed string) resourceapi.DeviceRequestAllocationResult {
+		return resourceapi.DeviceRequestAllocationResult{
+			Request: request,
+			Driver:  driverName,
+			Pool:    nodeName,
+			Device:  "numa-0",
+			ShareID: ptr.To(shareID),
+			ConsumedCapacity: map[resourceapi.QualifiedName]resource.Quantity{
+				capacityKey: resource.MustParse(consumed),
+			},
+		}
+	}
+
+	claim := &resourceapi.ResourceClaim{
+		ObjectMeta: metav1.ObjectMeta{UID: "claim-uid"},
+		Status: resourceapi.ResourceClaimStatus{
+			Allocation: &resourceapi.AllocationResult{
+				Devices: resourceapi.DeviceAllocationResult{Results: []resourceapi.DeviceRequestAllocationResult{
+					result("cpu0", "share-0", "1"),
+					result("cpu1", "share-1", "3"),
+				}},
+			},
+		},
+	}
+
+	prepared, err := state.computeDeviceConfig(claim)
+	require.NoError(t, err)
+	require.Len(t, prepared, 2)
+
+	consumedByShare := map[types.UID]string{}
+	for _, device := range prepared {
+		require.NotNil(t, device.ShareID)
+		require.NotNil(t, device.ContainerEdits)
+		require.NotNil(t, device.ContainerEdits.ContainerEdits)
+		for _, env := range device.ContainerEdits.Env {
+			if consumed, ok := strings.CutPrefix(env, "CPU_DEVICE_0_CONSUMED_CPU="); ok {
+				consumedByShare[*device.ShareID] = consumed
+			}
+		}
+	}
+	assert.Equal(t, "1", consumedByShare["share-0"], "share-0 should keep its own consumed CPU edit")
+	assert.Equal(t, "3", consumedByShare["share-1"], "share-1 should keep its own consumed CPU edit")
+}
+
+// TestPrepareExclusiveCPUShares verifies that exclusive shares of one NUMA
+// device prepared for different claims are pinned to distinct CPUs and that
+// the CPUs of an unprepared claim can be pinned again.
+func TestPrepareExclusiveCPUShares(t *testing.T) {
+	pinning := cpu.NewPinning(filepath.Join(t.TempDir(), cpu.PinningFile))
+	state := newTestState(t, cpu.NewProfile(testNodeName, testDriverName, 1, 8, false, pinning))
+	claim := func(uid types.UID, shareID types.UID) *resourceapi.ResourceClaim {
+		claim := allocatedClaim(uid, testNodeName, "numa-0")
+		result := &claim.Status.Allocation.Devices.Results[0]
+		result.ShareID = ptr.To(shareID)
+		result.ConsumedCapacity = map[resourceapi.QualifiedName]resource.Quantity{
+			resourceapi.QualifiedName(testDriverName + "/cpu"): resource.MustParse("2"),
+		}
+		claim.Status.Allocation.Devices.Config = []resourceapi.DeviceAllocationConfiguration{{
+			Source: resourceapi.AllocationConfigSourceClaim,
+			DeviceConfiguration: resourceapi.DeviceConfiguration{
+				Opaque: &resourceapi.OpaqueDeviceConfiguration{
+					Driver: testDriverName,
+					Parameters: runtime.RawExtension{
+						Raw: []byte(`{"apiVersion":"cpu.resource.example.com/v1alpha1","kind":"CpuConfig","pinningPolicy":"Exclusive"}`),
+					},
+				},
+			},
+		}}
+		return claim
+	}
+	ctx := context.Background()
+	prepare := func(claim *resourceapi.ResourceClaim) string {
+		prepared, err := state.Prepare(ctx, claim)
+		require.NoError(t, err)
+		require.Len(t, prepared, 1)
+		for _, env := range prepared[0].ContainerEdits.Env {
+			if cpus, ok := strings.CutPrefix(env, "CPU_DEVICE_0_CPUSET="); ok {
+				return cpus
+			}
+		}
+		require.Fail(t, "no cpuset in container edits", "claim %s", claim.UID)
+		return ""
+	}
+
+	assert.Equal(t, "0-1", prepare(claim("claim-a", "share-a")))
+	assert.Equal(t, "2-3", prepare(claim("claim-b", "share-b")), "CPUs pinned for claim-a must not be pinned again")
+	assert.Equal(t, "0-1", prepare(claim("claim-a", "share-a")), "preparing again must keep the pinned CPUs")
+
+	require.NoError(t, state.Unprepare(ctx, "claim-a"))
+	assert.Equal(t, "0-1", prepare(claim("claim-c", "share-c")), "CPUs of an unprepared claim can be pinned again")
+
+	pinned, err := pinning.State()
+	require.NoError(t, err)
+	assert.Equal(t, &cpu.PinningState{Devices: map[string][]cpu.PinnedCPUs{
+		testNodeName + "/numa-0": {
+			{Claim: "claim-b", ShareID: "share-b", CPUs: "2-3"},
+			{Claim: "claim-c", ShareID: "share-c", CPUs: "0-1"},
+		},
+	}}, pinned)
+}
+
+// TestPrepareDeviceStates verifies that the state a device is prepared into
+// is recorded in the checkpoint, outlives the claim and is not prepared again
+// for the next claim requiring the same state.
+func TestPrepareDeviceStates(t *testing.T) {
+	const reprogramDuration = 100 * time.Millisecond
+
+	state := newTestState(t, fpga.NewProfile(testNodeName, 1, reprogramDuration, profiles.Topology{}))
+	claim := func(uid types.UID, bitstream string) *resourceapi.ResourceClaim {
+		return fpgaClaim(uid, "fpga-0", bitstream)
+	}
+	prepare := func(claim *resourceapi.ResourceClaim) time.Duration {
+		start := time.Now()
+		_, err := state.Prepare(context.Background(), claim)
+		require.NoError(t, err)
+		return time.Since(start)
+	}
+	loadedBitstream := func() string {
+		checkpoint, err := state.checkpoint.read()
+		require.NoError(t, err)
+		return deviceStateFromCheckpoint(checkpoint, testNodeName, "fpga-0")
+	}
+
+	assert.GreaterOrEqual(t, prepare(claim("claim-a", "aes-256")), reprogramDuration, "loading a bitstream must reprogram the device")
+	assert.Equal(t, "aes-256", loadedBitstream())
+
+	require.NoError(t, state.Unprepare(context.Background(), "claim-a"))
+	assert.Equal(t, "aes-256", loadedBitstream(), "the loaded bitstream must outlive the claim")
+
+	assert.Less(t, prepare(claim("claim-b", "aes-256")), reprogramDuration, "reprogramming must be skipped when the bitstream is loaded")
+	require.NoError(t, state.Unprepare(context.Background(), "claim-b"))
+
+	assert.GreaterOrEqual(t, prepare(claim("claim-c", "video-transcode")), reprogramDuration, "loading another bitstream must reprogram the device")
+	assert.Equal(t, "video-transcode", loadedBitstream())
+}
+
+// gpuClaim returns a claim allocating devices of the gpu driver with the
+// given JSON-encoded GpuSharing.
+func gpuClaim(uid types.UID, sharing string, devices ...string) *resourceapi.ResourceClaim {
+	var results []resourceapi.DeviceRequestAllocationResult
+	for _, device := range devices {
+		results = append(results, resourceapi.DeviceRequestAllocationResult{
+			Request: "gpu",
+			Driver:  testGpuDriverName,
+			Pool:    testNodeName,
+			Device:  device,
+		})
+	}
+	return &resourceapi.ResourceClaim{
+		ObjectMeta: metav1.ObjectMeta{UID: uid},
+		Status: resourceapi.ResourceClaimStatus{
+			Allocation: &resourceapi.AllocationResult{
+				Devices: resourceapi.DeviceAllocationResult{
+					Results: results,
+					Config: []resourceapi.DeviceAllocationConfiguration{{
+						Source: resourceapi.AllocationConfigSourceClaim,
+						DeviceConfiguration: resourceapi.DeviceConfiguration{
+							Opaque: &resourceapi.OpaqueDeviceConfiguration{
+								Driver: testGpuDriverName,
+								Parameters: runtime.RawExtension{
+									Raw: []byte(`{"apiVersion":"gpu.resource.example.com/v1alpha1","kind":"GpuConfig","sharing":` + sharing + `}`),
+								},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// TestConfigureDevices verifies that preparing a claim configures the
+// simulated hardware and that the mode of a device in use by another claim is
+// not changed until that claim is unprepared.
+func TestConfigureDevices(t *testing.T) {
+	hardware := gpu.NewHardware(filepath.Join(t.TempDir(), gpu.HardwareFile))
+	state := newTestState(t, newTestGpuProfile(hardware))
+	ctx := context.Background()
+
+	modes := func() map[string]string {
+		hardwareState, err := hardware.State()
+		require.NoError(t, err)
+		modes := make(map[string]string)
+		for name, device := range hardwareState.Devices {
+			modes[name] = fmt.Sprintf("%s %v", device.Sharing.Strategy, device.Claims)
+		}
+		return modes
+	}
+
+	longTimeSlicing := `{"strategy":"TimeSlicing","timeSlicingConfig":{"interval":"Long"}}`
+	fourPartitions := `{"strategy":"SpacePartitioning","spacePartitioningConfig":{"partitionCount":4}}`
+
+	_, err := state.Prepare(ctx, gpuClaim("claim-a", longTimeSlicing, "gpu-0"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"test-node/gpu-0": "TimeSlicing [claim-a]"}, modes())
+
+	_, err = state.Prepare(ctx, gpuClaim("claim-b", fourPartitions, "gpu-1", "gpu-0"))
+	require.ErrorContains(t, err, `config "SpacePartitioning into 4 partitions" for device gpu-0 conflicts with config "TimeSlicing with Long interval" of claims [claim-a] sharing the device`)
+	assert.Equal(t, map[string]string{"test-node/gpu-0": "TimeSlicing [claim-a]"}, modes(), "no device may be configured for a conflicting claim")
+
+	require.NoError(t, state.Unprepare(ctx, "claim-a"))
+	_, err = state.Prepare(ctx, gpuClaim("claim-b", fourPartitions, "gpu-1", "gpu-0"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"test-node/gpu-0": "SpacePartitioning [claim-b]", "test-node/gpu-1": "SpacePartitioning [claim-b]"}, modes())
+
+	require.NoError(t, state.Unprepare(ctx, "claim-b"))
+	assert.Equal(t, map[string]string{"test-node/gpu-0": "SpacePartitioning []", "test-node/gpu-1": "SpacePartitioning []"}, modes())
+}
+
+func TestPrepareRollback(t *testing.T) {
+	steps := []string{
+		"prepare device states",
+		"configure devices",
+		"create CDI spec file",
+		"publish device status",
+		"update checkpoint",
+	}
+
+	for _, failedStep := range steps {
+		t.Run(failedStep, func(t *testing.T) {
+			ctx := context.Background()
+			claim := gpuClaim("claim-a", `{"strategy":"TimeSlicing","timeSlicingConfig":{"interval":"Long"}}`, "gpu-0")
+			claim.Namespace = "default"
+			claim.Name = "claim"
+			client := fake.NewClientset(claim)
+
+			cdiRoot := t.TempDir()
+			hardware := gpu.NewHardware(filepath.Join(t.TempDir(), gpu.HardwareFile))
+			// The profile publishes device status, so that there is a
+			// status update to roll back.
+			state := newTestState(t, gpu.NewProfile(testNodeName, 2, 0, true, false, true, "", nil, profiles.Topology{}, hardware),
+				withClient(client), withCDIRoot(cdiRoot))
+
+			// leftovers returns what preparing the claim left behind.
+			leftovers := func() []string {
+				var leftovers []string
+				specs, err := filepath.Glob(filepath.Join(cdiRoot, "*claim-a*"))
+				require.NoError(t, err)
+				for _, spec := range specs {
+					leftovers = append(leftovers, "CDI spec "+filepath.Base(spec))
+				}
+				checkpoint, err := state.checkpoint.read()
+				require.NoError(t, err)
+				for _, claim := range checkpoint.PreparedClaims {
+					leftovers = append(leftovers, "checkpoint entry "+string(claim.UID))
+				}
+				hardwareState, err := hardware.State()
+				require.NoError(t, err)
+				for name, device := range hardwareState.Devices {
+					if len(device.Claims) > 0 {
+						leftovers = append(leftovers, fmt.Sprintf("device %s configured for %v", name, device.Claims))
+					}
+				}
+				claim, err := client.ResourceV1().ResourceClaims(claim.Namespace).Get(ctx, claim.Name, metav1.GetOptions{})
+				require.NoError(t, err)
+				for _, status := range claim.Status.Devices {
+					leftovers = append(leftovers, "device status "+status.Device)
+				}
+				return leftovers
+			}
+
+			state.afterPrepareStep = func(name string) error {
+				if name == failedStep {
+					return errors.New("injected fault")
+				}
+				return nil
+			}
+			_, err := state.Prepare(ctx, claim)
+			require.ErrorContains(t, err, failedStep+": injected fault")
+			assert.Empty(t, leftovers())
+
+			// The claim can still be prepared once the fault is gone.
+			state.afterPrepareStep = nil
+			_, err = state.Prepare(ctx, claim)
+			require.NoError(t, err)
+			assert.Len(t, leftovers(), 4, "CDI spec, checkpoint entry, device configuration and device status")
+		})
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/klog/v2"
+)
+
+// step is a single step of a [transaction].
+type step struct {
+	name string
+	do   func(ctx context.Context) error
+	// undo reverts the side-effects of do. It is also called when do itself
+	// failed, so it must cope with do having been done partially or not at
+	// all. Steps without side-effects which need to be reverted leave it nil.
+	undo func(ctx context.Context) error
+}
+
+// transaction runs steps in order. When a step fails, all steps run so far,
+// including the failed one, are undone in reverse order, so that either all
+// steps take effect or none.
+type transaction struct {
+	steps []step
+
+	// afterStep, when set, is called after each step which succeeded and may
+	// fail it. It is used by tests to inject faults.
+	afterStep func(name string) error
+}
+
+// run runs the steps of the transaction. The steps are undone even when ctx
+// is canceled.
+func (t *transaction) run(ctx context.Context) error {
+	for i, step := range t.steps {
+		err := step.do(ctx)
+		if err == nil && t.afterStep != nil {
+			err = t.afterStep(step.name)
+		}
+		if err != nil {
+			err = fmt.Errorf("%s: %w", step.name, err)
+			if rollbackErr := t.rollback(context.WithoutCancel(ctx), i); rollbackErr != nil {
+				return errors.Join(err, fmt.Errorf("rollback failed: %w", rollbackErr))
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// rollback undoes the steps up to and including the step at index last. All
+// steps are undone even if some of them fail.
+func (t *transaction) rollback(ctx context.Context, last int) error {
+	logger := klog.FromContext(ctx)
+	var errs []error
+	for i := last; i >= 0; i-- {
+		step := t.steps[i]
+		if step.undo == nil {
+			continue
+		}
+		logger.V(4).Info("Undoing step", "step", step.name)
+		if err := step.undo(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", step.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
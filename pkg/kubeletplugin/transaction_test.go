@@ -0,0 +1,111 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubeletplugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransaction(t *testing.T) {
+	testcases := map[string]struct {
+		failDo    string
+		failUndo  string
+		failAfter string
+		wantErr   string
+		wantCalls []string
+	}{
+		"success": {
+			wantCalls: []string{"do a", "do b", "do c"},
+		},
+		"first step fails": {
+			failDo:    "a",
+			wantErr:   "a: do a failed",
+			wantCalls: []string{"do a", "undo a"},
+		},
+		"last step fails": {
+			failDo:    "c",
+			wantErr:   "c: do c failed",
+			wantCalls: []string{"do a", "do b", "do c", "undo b", "undo a"},
+		},
+		"injected fault": {
+			failAfter: "b",
+			wantErr:   "b: fault after b",
+			wantCalls: []string{"do a", "do b", "undo b", "undo a"},
+		},
+		"undo fails": {
+			failDo:    "c",
+			failUndo:  "b",
+			wantErr:   "c: do c failed\nrollback failed: b: undo b failed",
+			wantCalls: []string{"do a", "do b", "do c", "undo b", "undo a"},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			var calls []string
+			newStep := func(name string, undo bool) step {
+				s := step{
+					name: name,
+					do: func(context.Context) error {
+						calls = append(calls, "do "+name)
+						if name == tc.failDo {
+							return errors.New("do " + name + " failed")
+						}
+						return nil
+					},
+				}
+				if undo {
+					s.undo = func(ctx context.Context) error {
+						// Steps are undone even if the transaction was
+						// canceled.
+						require.NoError(t, ctx.Err())
+						calls = append(calls, "undo "+name)
+						if name == tc.failUndo {
+							return errors.New("undo " + name + " failed")
+						}
+						return nil
+					}
+				}
+				return s
+			}
+			tx := &transaction{
+				steps: []step{newStep("a", true), newStep("b", true), newStep("c", false)},
+				afterStep: func(name string) error {
+					if name == tc.failAfter {
+						return errors.New("fault after " + name)
+					}
+					return nil
+				},
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			err := tx.run(ctx)
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.wantErr)
+			}
+			assert.Equal(t, tc.wantCalls, calls)
+		})
+	}
+}
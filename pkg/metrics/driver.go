@@ -28,6 +28,15 @@ const (
 	resultError   = "error"
 )
 
+// Reasons for which a prepared claim is considered orphaned.
+const (
+	// OrphanReasonDeleted is used for claims which no longer exist.
+	OrphanReasonDeleted = "deleted"
+	// OrphanReasonDeallocated is used for claims which are no longer
+	// allocated to the devices they were prepared for.
+	OrphanReasonDeallocated = "deallocated"
+)
+
 var (
 	PrepareClaimsTotal = k8smetrics.NewCounterVec(&k8smetrics.CounterOpts{
 		Namespace:      Namespace,
@@ -71,12 +80,21 @@ var (
 		Help:           "Total number of fatal background errors reported by the driver.",
 	})
 
+	OrphanedClaimsTotal = k8smetrics.NewCounterVec(&k8smetrics.CounterOpts{
+		Namespace:      Namespace,
+		Subsystem:      Subsystem,
+		Name:           "orphaned_claims_total",
+		StabilityLevel: k8smetrics.ALPHA,
+		Help:           "Total number of prepared resource claims which were deleted or deallocated without being unprepared and were cleaned up by the driver.",
+	}, []string{"reason", "result"})
+
 	driverMetrics = []k8smetrics.Registerable{
 		PrepareClaimsTotal,
 		PrepareClaimDurationSeconds,
 		UnprepareClaimsTotal,
 		UnprepareClaimDurationSeconds,
 		FatalBackgroundErrorsTotal,
+		OrphanedClaimsTotal,
 	}
 )
 
@@ -93,6 +111,9 @@ func initDriverMetricSeries() {
 	for _, result := range []string{resultSuccess, resultError} {
 		PrepareClaimsTotal.WithLabelValues(result).Add(0)
 		UnprepareClaimsTotal.WithLabelValues(result).Add(0)
+		for _, reason := range []string{OrphanReasonDeleted, OrphanReasonDeallocated} {
+			OrphanedClaimsTotal.WithLabelValues(reason, result).Add(0)
+		}
 	}
 }
 
@@ -115,3 +136,13 @@ func ObserveUnprepareClaim(err error, duration time.Duration) {
 	UnprepareClaimsTotal.WithLabelValues(result).Inc()
 	UnprepareClaimDurationSeconds.WithLabelValues(result).Observe(duration.Seconds())
 }
+
+// ObserveOrphanedClaim records metrics for cleaning up a single orphaned
+// claim.
+func ObserveOrphanedClaim(reason string, err error) {
+	result := resultSuccess
+	if err != nil {
+		result = resultError
+	}
+	OrphanedClaimsTotal.WithLabelValues(reason, result).Inc()
+}
@@ -46,6 +46,19 @@ func TestObserveUnprepareClaim(t *testing.T) {
 	require.Equal(t, float64(1), counterValue(t, "dra_example_driver_unprepare_claims_total", map[string]string{"result": "error"}))
 }
 
+func TestObserveOrphanedClaim(t *testing.T) {
+	t.Parallel()
+
+	ObserveOrphanedClaim(OrphanReasonDeleted, nil)
+	ObserveOrphanedClaim(OrphanReasonDeleted, nil)
+	ObserveOrphanedClaim(OrphanReasonDeallocated, errors.New("unprepare failed"))
+
+	require.Equal(t, float64(2), counterValue(t, "dra_example_driver_orphaned_claims_total", map[string]string{"reason": "deleted", "result": "success"}))
+	require.Equal(t, float64(0), counterValue(t, "dra_example_driver_orphaned_claims_total", map[string]string{"reason": "deleted", "result": "error"}))
+	require.Equal(t, float64(0), counterValue(t, "dra_example_driver_orphaned_claims_total", map[string]string{"reason": "deallocated", "result": "success"}))
+	require.Equal(t, float64(1), counterValue(t, "dra_example_driver_orphaned_claims_total", map[string]string{"reason": "deallocated", "result": "error"}))
+}
+
 func counterValue(t *testing.T, name string, labels map[string]string) float64 {
 	t.Helper()
 
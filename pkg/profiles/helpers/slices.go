@@ -0,0 +1,108 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package helpers
+
+import (
+	"slices"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/dynamic-resource-allocation/resourceslice"
+)
+
+// PoolSlices distributes the shared counters and devices of a pool across as
+// many slices as needed to stay within the ResourceSlice API limits.
+//
+// Counter sets are published in slices of their own, since a slice may not
+// have both devices and shared counters. Devices may consume counter sets from
+// any slice in the same pool. Consecutive devices consuming the same counter
+// set, like the partitions of one GPU, are kept in the same slice where
+// possible.
+func PoolSlices(sharedCounters []resourceapi.CounterSet, devices []resourceapi.Device) []resourceslice.Slice {
+	var result []resourceslice.Slice
+	for counters := range slices.Chunk(sharedCounters, resourceapi.ResourceSliceMaxCounterSets) {
+		result = append(result, resourceslice.Slice{SharedCounters: counters})
+	}
+
+	var current []resourceapi.Device
+	advanced := false
+	flush := func() {
+		if len(current) > 0 {
+			result = append(result, resourceslice.Slice{Devices: current})
+		}
+		current, advanced = nil, false
+	}
+	for _, group := range groupByCounterSet(devices) {
+		groupAdvanced := advanced || slices.ContainsFunc(group, usesAdvancedFeatures)
+		if len(current)+len(group) > maxDevicesPerSlice(groupAdvanced) {
+			flush()
+		}
+		for _, device := range group {
+			deviceAdvanced := advanced || usesAdvancedFeatures(device)
+			if len(current) >= maxDevicesPerSlice(deviceAdvanced) {
+				flush()
+				deviceAdvanced = usesAdvancedFeatures(device)
+			}
+			current = append(current, device)
+			advanced = deviceAdvanced
+		}
+	}
+	flush()
+
+	if len(result) == 0 {
+		return []resourceslice.Slice{{}}
+	}
+	return result
+}
+
+// groupByCounterSet splits devices into runs of consecutive devices which
+// consume from the same counter set. Devices without counters form groups of
+// their own.
+func groupByCounterSet(devices []resourceapi.Device) [][]resourceapi.Device {
+	var groups [][]resourceapi.Device
+	start := 0
+	for i := 1; i <= len(devices); i++ {
+		if i < len(devices) {
+			counterSet := firstCounterSet(devices[i])
+			if counterSet != "" && counterSet == firstCounterSet(devices[i-1]) {
+				continue
+			}
+		}
+		groups = append(groups, devices[start:i:i])
+		start = i
+	}
+	return groups
+}
+
+func firstCounterSet(device resourceapi.Device) string {
+	if len(device.ConsumesCounters) == 0 {
+		return ""
+	}
+	return device.ConsumesCounters[0].CounterSet
+}
+
+// usesAdvancedFeatures returns true if the device counts against the lower
+// per-slice device limit.
+func usesAdvancedFeatures(device resourceapi.Device) bool {
+	return len(device.Taints) > 0 || len(device.ConsumesCounters) > 0
+}
+
+func maxDevicesPerSlice(advanced bool) int {
+	if advanced {
+		return resourceapi.ResourceSliceMaxDevicesWithAdvancedFeatures
+	}
+	return resourceapi.ResourceSliceMaxDevices
+}
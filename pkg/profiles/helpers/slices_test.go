@@ -0,0 +1,110 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package helpers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	resourceapi "k8s.io/api/resource/v1"
+)
+
+func TestPoolSlices(t *testing.T) {
+	counterSets := func(n int) []resourceapi.CounterSet {
+		var sets []resourceapi.CounterSet
+		for i := range n {
+			sets = append(sets, resourceapi.CounterSet{Name: fmt.Sprintf("set-%d", i)})
+		}
+		return sets
+	}
+	// devices returns perSet devices consuming each of numSets counter sets,
+	// or perSet plain devices when numSets is 0.
+	devices := func(numSets, perSet int) []resourceapi.Device {
+		var devices []resourceapi.Device
+		for i := range max(numSets, 1) {
+			for j := range perSet {
+				device := resourceapi.Device{Name: fmt.Sprintf("dev-%d-%d", i, j)}
+				if numSets > 0 {
+					device.ConsumesCounters = []resourceapi.DeviceCounterConsumption{{CounterSet: fmt.Sprintf("set-%d", i)}}
+				}
+				devices = append(devices, device)
+			}
+		}
+		return devices
+	}
+
+	tests := map[string]struct {
+		sharedCounters []resourceapi.CounterSet
+		devices        []resourceapi.Device
+		// expected lists the number of counter sets (negative) or devices
+		// (positive) in each slice.
+		expected []int
+	}{
+		"empty pool": {
+			expected: []int{0},
+		},
+		"single slice": {
+			devices:  devices(0, 8),
+			expected: []int{8},
+		},
+		"plain devices": {
+			devices:  devices(0, 300),
+			expected: []int{128, 128, 44},
+		},
+		"small partitioned pool": {
+			sharedCounters: counterSets(2),
+			devices:        devices(2, 5),
+			expected:       []int{-2, 10},
+		},
+		"groups are not split": {
+			sharedCounters: counterSets(20),
+			devices:        devices(20, 5),
+			expected:       []int{-8, -8, -4, 60, 40},
+		},
+		"oversized group": {
+			sharedCounters: counterSets(1),
+			devices:        devices(1, 100),
+			expected:       []int{-1, 64, 36},
+		},
+		"plain devices followed by partitions": {
+			sharedCounters: counterSets(1),
+			devices:        append(devices(0, 100), devices(1, 5)...),
+			expected:       []int{-1, 100, 5},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			slices := PoolSlices(test.sharedCounters, test.devices)
+
+			var sizes []int
+			var allDevices []resourceapi.Device
+			for _, slice := range slices {
+				if len(slice.SharedCounters) > 0 {
+					assert.Empty(t, slice.Devices)
+					sizes = append(sizes, -len(slice.SharedCounters))
+					continue
+				}
+				sizes = append(sizes, len(slice.Devices))
+				allDevices = append(allDevices, slice.Devices...)
+			}
+			assert.Equal(t, test.expected, sizes)
+			assert.Equal(t, test.devices, allDevices)
+		})
+	}
+}
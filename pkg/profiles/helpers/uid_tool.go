@@ -14,6 +14,8 @@
  * limitations under the License.
  */
 
+// Package helpers provides building blocks for implementing device profiles,
+// like the CDI device IDs their container edits are keyed by.
 package helpers
 
 import (
@@ -0,0 +1,136 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"k8s.io/klog/v2"
+)
+
+// FileWatcher reports changes of the content of a file. The directory
+// containing the file is watched rather than the file itself so that atomic
+// replacements (including ConfigMap volume updates, which swap a symlink) are
+// noticed.
+type FileWatcher struct {
+	path    string
+	watcher *fsnotify.Watcher
+	last    []byte
+}
+
+// NewFileWatcher starts watching the file at path and reads its current
+// content as the baseline to compare changes against. Every change made after
+// NewFileWatcher returns is reported by [FileWatcher.Run], so the caller
+// should read the file only afterwards. A missing directory is created so that
+// the file can be created later.
+func NewFileWatcher(path string) (_ *FileWatcher, err error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create directory of watched file: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create file watcher: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = watcher.Close()
+		}
+	}()
+
+	if err := watcher.Add(dir); err != nil {
+		return nil, fmt.Errorf("watch %s: %w", dir, err)
+	}
+
+	// A file that does not exist yet is treated like an empty file.
+	last, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("read watched file: %w", err)
+	}
+
+	return &FileWatcher{
+		path:    path,
+		watcher: watcher,
+		last:    last,
+	}, nil
+}
+
+// Close stops watching the file.
+func (w *FileWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+// Run blocks until ctx is canceled, calling onChange each time the content of
+// the file differs from the content last seen.
+func (w *FileWatcher) Run(ctx context.Context, onChange func()) error {
+	logger := klog.FromContext(ctx).WithValues("path", w.path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logger.Error(err, "Error watching file")
+		case _, ok := <-w.watcher.Events:
+			if !ok {
+				return nil
+			}
+			data, err := os.ReadFile(w.path)
+			if err != nil {
+				// The file may be briefly missing while it is replaced.
+				logger.V(5).Info("Unable to read watched file", "err", err)
+				continue
+			}
+			if bytes.Equal(data, w.last) {
+				continue
+			}
+			w.last = data
+			logger.Info("Watched file changed")
+			onChange()
+		}
+	}
+}
+
+// WatchFile blocks until ctx is canceled, calling onChange each time the
+// content of the file at path changes. It is meant for callers which read the
+// file before they start watching it: onChange is called once as soon as the
+// file is watched, so that changes made in between are not missed. Callers
+// which can start watching first should use [NewFileWatcher] instead.
+func WatchFile(ctx context.Context, path string, onChange func()) error {
+	watcher, err := NewFileWatcher(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := watcher.Close(); err != nil {
+			klog.FromContext(ctx).Error(err, "Failed to close file watcher", "path", path)
+		}
+	}()
+	onChange()
+	return watcher.Run(ctx, onChange)
+}
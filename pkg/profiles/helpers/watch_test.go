@@ -0,0 +1,95 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package helpers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileWatcher(t *testing.T) {
+	testcases := map[string]struct {
+		// dir is the directory of the watched file, relative to a
+		// temporary directory.
+		dir string
+	}{
+		"existing directory": {
+			dir: ".",
+		},
+		"missing directory": {
+			dir: "missing/dir",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.dir, "file")
+			watcher, err := NewFileWatcher(path)
+			require.NoError(t, err)
+			defer func() {
+				require.NoError(t, watcher.Close())
+			}()
+
+			// A change made before Run is called is not missed.
+			require.NoError(t, os.WriteFile(path, []byte("a"), 0600))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			changed := make(chan struct{}, 10)
+			done := make(chan error)
+			go func() {
+				done <- watcher.Run(ctx, func() { changed <- struct{}{} })
+			}()
+
+			select {
+			case <-changed:
+			case <-time.After(5 * time.Second):
+				assert.Fail(t, "no change notification")
+			}
+
+			cancel()
+			require.NoError(t, <-done)
+		})
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changed := make(chan struct{}, 10)
+	done := make(chan error)
+	go func() {
+		done <- WatchFile(ctx, path, func() { changed <- struct{}{} })
+	}()
+
+	// The caller may have read the file before it was watched, so it is
+	// asked to read it again once it is.
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "no initial notification")
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+}
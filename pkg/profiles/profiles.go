@@ -0,0 +1,137 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package profiles defines the device profiles served by the example driver.
+// A profile enumerates the devices of a node and handles the opaque
+// configuration of the claims allocating them. Profiles are made known to the
+// kubelet plugin and the admission webhook through a [Registry], which allows
+// building drivers for other kinds of devices on top of them.
+package profiles
+
+import (
+	"context"
+	"errors"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/dynamic-resource-allocation/resourceslice"
+	cdiapi "tags.cncf.io/container-device-interface/pkg/cdi"
+)
+
+type PerDeviceCDIContainerEdits map[string]*cdiapi.ContainerEdits
+
+// Profile describes a kind of device that can be managed by the driver.
+type Profile interface {
+	ConfigHandler
+	EnumerateDevices() (resourceslice.DriverResources, error)
+}
+
+// ConfigHandler handles opaque configuration set for requests in ResourceClaims.
+type ConfigHandler interface {
+	// SchemeBuilder produces a [runtime.Scheme] for the profile's configuration types.
+	SchemeBuilder() runtime.SchemeBuilder
+	// Validate returns nil for valid configuration, or an error explaining why the configuration is invalid.
+	Validate(config runtime.Object) error
+	// ApplyConfig applies a configuration to a set of device allocation
+	// results. When `config` is nil, the profile's default configuration should
+	// be applied.
+	ApplyConfig(config runtime.Object, results []*resourceapi.DeviceRequestAllocationResult) (PerDeviceCDIContainerEdits, error)
+}
+
+// NoopConfigHandler implements a [ConfigHandler] that does not allow
+// configuration.
+type NoopConfigHandler struct{}
+
+// ApplyConfig implements [ConfigHandler].
+func (n NoopConfigHandler) ApplyConfig(config runtime.Object, results []*resourceapi.DeviceRequestAllocationResult) (PerDeviceCDIContainerEdits, error) {
+	if config != nil {
+		return nil, errors.New("configuration not allowed")
+	}
+	return nil, nil
+}
+
+// SchemeBuilder implements [ConfigHandler].
+func (n NoopConfigHandler) SchemeBuilder() runtime.SchemeBuilder {
+	return runtime.NewSchemeBuilder()
+}
+
+// Validate implements [ConfigHandler].
+func (n NoopConfigHandler) Validate(config runtime.Object) error {
+	return errors.New("configuration not allowed")
+}
+
+// SharedConfigHandler is an optional interface that a [ConfigHandler] may
+// implement when part of a configuration applies to a device as a whole, like
+// the sharing strategy of a GPU. A device may be used by several claims at the
+// same time, e.g. when it allows multiple allocations, and all of them must
+// agree on that part.
+type SharedConfigHandler interface {
+	// SharedConfig returns the part of config which applies to the whole
+	// device in a canonical and human-readable form. config is nil for the
+	// profile's default configuration. An empty string means that no part of
+	// config applies to the whole device.
+	SharedConfig(config runtime.Object) (string, error)
+}
+
+// DeviceStatusBuilder is an optional interface that a [Profile] may implement
+// to publish per-device status (e.g. uuid, model, driverVersion) into
+// ResourceClaim.status.devices[].data.
+type DeviceStatusBuilder interface {
+	// BuildDeviceStatus returns the status of an allocated device, or nil if
+	// none should be published. config is the configuration which was
+	// applied to the device, nil for the profile's default configuration.
+	BuildDeviceStatus(allocatable map[string]resourceapi.Device, config runtime.Object, result *resourceapi.DeviceRequestAllocationResult) *resourceapi.AllocatedDeviceStatus
+}
+
+// DeviceWatcher is an optional interface that a [Profile] may implement when
+// the set of devices it enumerates can change while the driver is running.
+type DeviceWatcher interface {
+	// WatchDevices blocks until ctx is canceled, calling onChange each time
+	// the result of EnumerateDevices may have changed.
+	WatchDevices(ctx context.Context, onChange func()) error
+}
+
+// DeviceStatePreparer is an optional interface that a [Profile] may implement
+// when preparing a device changes the state of the device itself, like the
+// image an FPGA is programmed with. Unlike container edits, that state
+// outlives the claim it was prepared for and is recorded per device in the
+// driver's checkpoint.
+type DeviceStatePreparer interface {
+	// PrepareDeviceState brings an allocated device into the state required
+	// by config, nil for the profile's default configuration. current is the
+	// state returned for the device the last time, empty if unknown. The
+	// returned state is recorded even when an error is returned, since
+	// preparing may have been interrupted halfway.
+	PrepareDeviceState(ctx context.Context, config runtime.Object, result *resourceapi.DeviceRequestAllocationResult, current string) (string, error)
+}
+
+// DeviceConfigurer is an optional interface that a [Profile] may implement
+// when applying a configuration changes the device itself, like splitting a
+// GPU into partitions. Unlike with a [DeviceStatePreparer], a device is
+// configured on behalf of a claim and remains in use by it until the claim is
+// unprepared, so that the device can refuse being reconfigured for other
+// claims in the meantime.
+type DeviceConfigurer interface {
+	// ConfigureDevice configures an allocated device for a claim as required
+	// by config, nil for the profile's default configuration. A claim may be
+	// prepared more than once, so configuring a device again for the same
+	// claim and config must succeed.
+	ConfigureDevice(ctx context.Context, claimUID types.UID, config runtime.Object, result *resourceapi.DeviceRequestAllocationResult) error
+	// ReleaseDevices releases all devices configured for a claim. It must
+	// succeed when there are none.
+	ReleaseDevices(ctx context.Context, claimUID types.UID) error
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package profiles
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Options holds the settings of a profile which are common to all profiles.
+// They are set by the driver binaries, while settings specific to a profile
+// are set by the flags of its [Registration].
+type Options struct {
+	NodeName   string
+	DriverName string
+	// DeviceStatus enables publishing device status, see
+	// [DeviceStatusBuilder].
+	DeviceStatus bool
+	// NumDevices is the number of devices to advertise, for profiles whose
+	// number of devices is not set by their own flags.
+	NumDevices int
+	Topology   Topology
+	// StateDir is a directory in which the profile may persist node-local
+	// state across restarts of the driver, like that of simulated hardware.
+	// It is empty when no state should be persisted.
+	StateDir string
+}
+
+// Registration describes a device profile to the driver binaries.
+type Registration struct {
+	// Name identifies the profile in the --device-profile flag. Drivers
+	// serving the profile are named "<name>.example.com" by default.
+	Name string
+	// Flags are the command line flags with the settings specific to the
+	// profile. The kubelet plugin adds them to its own flags, so their names
+	// must be unique, e.g. by prefixing them with the profile name.
+	Flags []cli.Flag
+	// ConfigHandler validates opaque configuration in the admission webhook.
+	// It must not depend on Flags, which are not set there.
+	ConfigHandler ConfigHandler
+	// New creates the profile in the kubelet plugin after Flags were set. It
+	// returns an error if the settings are invalid.
+	New func(opts Options) (Profile, error)
+}
+
+// Registry holds the device profiles known to the driver binaries.
+type Registry struct {
+	registrations []Registration
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a profile to the registry. It returns an error if the name of
+// the profile or of one of its flags is already registered.
+func (r *Registry) Register(registration Registration) error {
+	if registration.Name == "" {
+		return fmt.Errorf("device profile without name")
+	}
+	if registration.ConfigHandler == nil || registration.New == nil {
+		return fmt.Errorf("device profile %q: config handler and constructor are required", registration.Name)
+	}
+	if _, ok := r.lookup(registration.Name); ok {
+		return fmt.Errorf("device profile %q is already registered", registration.Name)
+	}
+	for _, flag := range registration.Flags {
+		for _, name := range flag.Names() {
+			if slices.ContainsFunc(r.Flags(), func(f cli.Flag) bool { return slices.Contains(f.Names(), name) }) {
+				return fmt.Errorf("device profile %q: flag %q is already registered", registration.Name, name)
+			}
+		}
+	}
+	r.registrations = append(r.registrations, registration)
+	return nil
+}
+
+// MustRegister is like [Registry.Register] but panics on errors.
+func (r *Registry) MustRegister(registrations ...Registration) {
+	for _, registration := range registrations {
+		if err := r.Register(registration); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Names returns the sorted names of all registered profiles.
+func (r *Registry) Names() []string {
+	var names []string
+	for _, registration := range r.registrations {
+		names = append(names, registration.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Default returns the name of the profile registered first, which the driver
+// binaries serve by default, or an empty string if the registry is empty.
+func (r *Registry) Default() string {
+	if len(r.registrations) == 0 {
+		return ""
+	}
+	return r.registrations[0].Name
+}
+
+// Flags returns the flags of all registered profiles in registration order.
+func (r *Registry) Flags() []cli.Flag {
+	var flags []cli.Flag
+	for _, registration := range r.registrations {
+		flags = append(flags, registration.Flags...)
+	}
+	return flags
+}
+
+// Get returns the registration of the named profile.
+func (r *Registry) Get(name string) (Registration, error) {
+	registration, ok := r.lookup(name)
+	if !ok {
+		return Registration{}, fmt.Errorf("invalid device profile %q, valid profiles are %q", name, r.Names())
+	}
+	return registration, nil
+}
+
+// New creates the named profile from the given options.
+func (r *Registry) New(name string, opts Options) (Profile, error) {
+	registration, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := registration.New(opts)
+	if err != nil {
+		return nil, fmt.Errorf("create device profile %q: %w", name, err)
+	}
+	return profile, nil
+}
+
+func (r *Registry) lookup(name string) (Registration, bool) {
+	index := slices.IndexFunc(r.registrations, func(registration Registration) bool { return registration.Name == name })
+	if index < 0 {
+		return Registration{}, false
+	}
+	return r.registrations[index], true
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package profiles
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+	"k8s.io/dynamic-resource-allocation/resourceslice"
+)
+
+type testProfile struct {
+	NoopConfigHandler
+	opts Options
+}
+
+func (p testProfile) EnumerateDevices() (resourceslice.DriverResources, error) {
+	return resourceslice.DriverResources{}, nil
+}
+
+func testRegistration(name string, flags ...string) Registration {
+	registration := Registration{
+		Name:          name,
+		ConfigHandler: NoopConfigHandler{},
+		New: func(opts Options) (Profile, error) {
+			if opts.NodeName == "" {
+				return nil, errors.New("no node name")
+			}
+			return testProfile{opts: opts}, nil
+		},
+	}
+	for _, flag := range flags {
+		registration.Flags = append(registration.Flags, &cli.StringFlag{Name: flag})
+	}
+	return registration
+}
+
+func TestRegister(t *testing.T) {
+	tests := map[string]struct {
+		registration Registration
+		expectedErr  string
+	}{
+		"valid": {
+			registration: testRegistration("baz", "baz-flag"),
+		},
+		"no name": {
+			registration: testRegistration(""),
+			expectedErr:  "device profile without name",
+		},
+		"no constructor": {
+			registration: Registration{Name: "baz", ConfigHandler: NoopConfigHandler{}},
+			expectedErr:  `device profile "baz": config handler and constructor are required`,
+		},
+		"duplicate name": {
+			registration: testRegistration("foo"),
+			expectedErr:  `device profile "foo" is already registered`,
+		},
+		"duplicate flag": {
+			registration: testRegistration("baz", "bar-flag"),
+			expectedErr:  `device profile "baz": flag "bar-flag" is already registered`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			registry := NewRegistry()
+			registry.MustRegister(testRegistration("foo", "foo-flag"), testRegistration("bar", "bar-flag"))
+
+			err := registry.Register(test.registration)
+			if test.expectedErr != "" {
+				assert.EqualError(t, err, test.expectedErr)
+				assert.Equal(t, []string{"bar", "foo"}, registry.Names())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{"bar", "baz", "foo"}, registry.Names())
+			assert.Len(t, registry.Flags(), 3)
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	assert.Empty(t, registry.Default())
+
+	registry.MustRegister(testRegistration("foo", "foo-flag"), testRegistration("bar"))
+	assert.Equal(t, "foo", registry.Default(), "the profile registered first must be the default")
+	assert.Equal(t, []string{"bar", "foo"}, registry.Names())
+	require.Len(t, registry.Flags(), 1)
+	assert.Equal(t, []string{"foo-flag"}, registry.Flags()[0].Names())
+
+	registration, err := registry.Get("bar")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", registration.Name)
+
+	profile, err := registry.New("foo", Options{NodeName: "test-node"})
+	require.NoError(t, err)
+	assert.Equal(t, Options{NodeName: "test-node"}, profile.(testProfile).opts)
+
+	_, err = registry.New("foo", Options{})
+	assert.EqualError(t, err, `create device profile "foo": no node name`)
+
+	_, err = registry.Get("baz")
+	assert.EqualError(t, err, `invalid device profile "baz", valid profiles are ["bar" "foo"]`)
+}
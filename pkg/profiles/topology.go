@@ -0,0 +1,90 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package profiles
+
+import (
+	"fmt"
+
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/dynamic-resource-allocation/deviceattribute"
+	"k8s.io/utils/ptr"
+)
+
+// Topology attributes are fully qualified so that they have the same name for
+// all example drivers, which allows matchAttribute constraints across
+// drivers. The PCIe root uses the standard
+// [deviceattribute.StandardDeviceAttributePCIeRoot] attribute.
+const (
+	NUMANodeAttribute           resourceapi.QualifiedName = "example.com/numaNode"
+	InterconnectIslandAttribute resourceapi.QualifiedName = "example.com/interconnectIsland"
+)
+
+// Topology describes how simulated devices are attached to a node. Devices
+// are distributed evenly across the PCIe roots in blocks, so that devices
+// with neighboring indices share a PCIe root, and the PCIe roots are
+// distributed evenly across the NUMA nodes. Profiles with the same topology
+// place their devices consistently, e.g. with 4 GPUs and 2 NICs on 2 PCIe
+// roots, gpu-0, gpu-1 and nic-0 share the first PCIe root.
+type Topology struct {
+	// NUMANodes is the number of NUMA nodes. 0 disables the NUMA node and
+	// PCIe root attributes.
+	NUMANodes int
+	// PCIeRootsPerNUMANode is the number of PCIe roots per NUMA node.
+	PCIeRootsPerNUMANode int
+	// IslandSize is the number of devices per interconnect island, e.g.
+	// GPUs connected through a high-speed fabric. 0 disables the island
+	// attribute.
+	IslandSize int
+}
+
+// Validate ensures that a Topology has a valid set of values.
+func (t Topology) Validate() error {
+	if t.NUMANodes < 0 {
+		return fmt.Errorf("negative number of NUMA nodes: %d", t.NUMANodes)
+	}
+	if t.NUMANodes > 0 && t.PCIeRootsPerNUMANode < 1 {
+		return fmt.Errorf("number of PCIe roots per NUMA node must be at least 1: %d", t.PCIeRootsPerNUMANode)
+	}
+	if t.NUMANodes*t.PCIeRootsPerNUMANode > 256 {
+		return fmt.Errorf("too many PCIe roots: %d", t.NUMANodes*t.PCIeRootsPerNUMANode)
+	}
+	if t.IslandSize < 0 {
+		return fmt.Errorf("negative interconnect island size: %d", t.IslandSize)
+	}
+	return nil
+}
+
+// Attributes returns the topology attributes of the device with the given
+// index out of count devices.
+func (t Topology) Attributes(index, count int) map[resourceapi.QualifiedName]resourceapi.DeviceAttribute {
+	attrs := make(map[resourceapi.QualifiedName]resourceapi.DeviceAttribute)
+	if t.NUMANodes > 0 && t.PCIeRootsPerNUMANode > 0 && count > 0 {
+		root := index * t.NUMANodes * t.PCIeRootsPerNUMANode / count
+		attrs[NUMANodeAttribute] = resourceapi.DeviceAttribute{IntValue: ptr.To(int64(root / t.PCIeRootsPerNUMANode))}
+		attrs[deviceattribute.StandardDeviceAttributePCIeRoot] = resourceapi.DeviceAttribute{StringValue: ptr.To(PCIeRoot(root))}
+	}
+	if t.IslandSize > 0 {
+		attrs[InterconnectIslandAttribute] = resourceapi.DeviceAttribute{IntValue: ptr.To(int64(index / t.IslandSize))}
+	}
+	return attrs
+}
+
+// PCIeRoot returns the name of the n-th simulated PCIe root complex in the
+// `pci<domain>:<bus>` format of the standard pcieRoot attribute.
+func PCIeRoot(n int) string {
+	return fmt.Sprintf("pci0000:%02x", n)
+}
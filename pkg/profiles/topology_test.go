@@ -0,0 +1,118 @@
+/*
+ * Copyright The Kubernetes Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package profiles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	resourceapi "k8s.io/api/resource/v1"
+	"k8s.io/dynamic-resource-allocation/deviceattribute"
+	"k8s.io/utils/ptr"
+)
+
+func TestTopologyAttributes(t *testing.T) {
+	topology := Topology{NUMANodes: 2, PCIeRootsPerNUMANode: 2, IslandSize: 4}
+
+	type placement struct {
+		numaNode int64
+		pcieRoot string
+		island   int64
+	}
+	place := func(index, count int) placement {
+		attrs := topology.Attributes(index, count)
+		return placement{
+			numaNode: *attrs[NUMANodeAttribute].IntValue,
+			pcieRoot: *attrs[deviceattribute.StandardDeviceAttributePCIeRoot].StringValue,
+			island:   *attrs[InterconnectIslandAttribute].IntValue,
+		}
+	}
+
+	// 8 GPUs are placed two per PCIe root.
+	var gpus []placement
+	for i := range 8 {
+		gpus = append(gpus, place(i, 8))
+	}
+	assert.Equal(t, []placement{
+		{0, "pci0000:00", 0},
+		{0, "pci0000:00", 0},
+		{0, "pci0000:01", 0},
+		{0, "pci0000:01", 0},
+		{1, "pci0000:02", 1},
+		{1, "pci0000:02", 1},
+		{1, "pci0000:03", 1},
+		{1, "pci0000:03", 1},
+	}, gpus)
+
+	// 4 NICs on the same topology get one PCIe root each, shared with the
+	// GPUs with matching positions.
+	for i := range 4 {
+		nic := place(i, 4)
+		assert.Equal(t, gpus[2*i].pcieRoot, nic.pcieRoot)
+		assert.Equal(t, gpus[2*i].numaNode, nic.numaNode)
+	}
+}
+
+func TestTopologyAttributesDisabled(t *testing.T) {
+	assert.Empty(t, Topology{}.Attributes(0, 1))
+	assert.Equal(t,
+		map[resourceapi.QualifiedName]resourceapi.DeviceAttribute{
+			InterconnectIslandAttribute: {IntValue: ptr.To(int64(1))},
+		},
+		Topology{IslandSize: 2}.Attributes(3, 4))
+}
+
+func TestTopologyValidate(t *testing.T) {
+	tests := map[string]struct {
+		topology    Topology
+		expectedErr string
+	}{
+		"disabled": {
+			topology: Topology{},
+		},
+		"valid": {
+			topology: Topology{NUMANodes: 2, PCIeRootsPerNUMANode: 4, IslandSize: 8},
+		},
+		"negative NUMA nodes": {
+			topology:    Topology{NUMANodes: -1},
+			expectedErr: "negative number of NUMA nodes: -1",
+		},
+		"no PCIe roots": {
+			topology:    Topology{NUMANodes: 2},
+			expectedErr: "number of PCIe roots per NUMA node must be at least 1: 0",
+		},
+		"too many PCIe roots": {
+			topology:    Topology{NUMANodes: 16, PCIeRootsPerNUMANode: 32},
+			expectedErr: "too many PCIe roots: 512",
+		},
+		"negative island size": {
+			topology:    Topology{IslandSize: -1},
+			expectedErr: "negative interconnect island size: -1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.topology.Validate()
+			if test.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, test.expectedErr)
+		})
+	}
+}
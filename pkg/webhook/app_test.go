@@ -14,15 +14,17 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package main
+package webhook
 
 import (
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -34,9 +36,16 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
 
+	cpuconfigapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/cpu/v1alpha1"
 	configapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/gpu/v1alpha1"
+	netconfigapi "sigs.k8s.io/dra-example-driver/api/example.com/resource/net/v1alpha1"
+	"sigs.k8s.io/dra-example-driver/internal/profiles/cpu"
 	"sigs.k8s.io/dra-example-driver/internal/profiles/gpu"
+	"sigs.k8s.io/dra-example-driver/internal/profiles/net"
+	"sigs.k8s.io/dra-example-driver/internal/profiles/registry"
+	"sigs.k8s.io/dra-example-driver/pkg/profiles"
 )
 
 const driverName = "gpu.example.com"
@@ -170,8 +179,7 @@ func TestResourceClaimValidatingWebhook(t *testing.T) {
 		},
 	}
 
-	configHandler := gpu.Profile{}
-	mux, err := newMux(configHandler, driverName)
+	mux, err := newMux(map[string]profiles.ConfigHandler{driverName: gpu.Profile{}})
 	assert.NoError(t, err)
 
 	s := httptest.NewServer(mux)
@@ -213,6 +221,250 @@ func TestResourceClaimValidatingWebhook(t *testing.T) {
 	}
 }
 
+func TestCpuConfigValidatingWebhook(t *testing.T) {
+	const cpuDriverName = "cpu.example.com"
+
+	tests := map[string]struct {
+		cpuConfig       *cpuconfigapi.CpuConfig
+		expectedAllowed bool
+		expectedMessage string
+	}{
+		"valid CpuConfig": {
+			cpuConfig: &cpuconfigapi.CpuConfig{
+				PinningPolicy: cpuconfigapi.ExclusivePinningPolicy,
+				SMT:           cpuconfigapi.FullCoresSMTPolicy,
+				ReservedCores: "0-1",
+			},
+			expectedAllowed: true,
+		},
+		"defaulted CpuConfig": {
+			cpuConfig:       &cpuconfigapi.CpuConfig{},
+			expectedAllowed: true,
+		},
+		"invalid CpuConfig": {
+			cpuConfig: &cpuconfigapi.CpuConfig{
+				SMT: cpuconfigapi.FullCoresSMTPolicy,
+			},
+			expectedMessage: "1 configs failed to validate: object at spec.devices.config[0].opaque.parameters is invalid: SMT policy FullCores requires the Exclusive pinning policy",
+		},
+	}
+
+	mux, err := newMux(map[string]profiles.ConfigHandler{cpuDriverName: cpu.Profile{}})
+	require.NoError(t, err)
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.cpuConfig.SetGroupVersionKind(cpuconfigapi.SchemeGroupVersion.WithKind(cpuconfigapi.CpuConfigKind))
+			claim := &resourceapi.ResourceClaim{}
+			claim.SetGroupVersionKind(resourceapi.SchemeGroupVersion.WithKind("ResourceClaim"))
+			claim.Spec.Devices.Config = []resourceapi.DeviceClaimConfiguration{{
+				DeviceConfiguration: resourceapi.DeviceConfiguration{
+					Opaque: &resourceapi.OpaqueDeviceConfiguration{
+						Driver:     cpuDriverName,
+						Parameters: runtime.RawExtension{Object: test.cpuConfig},
+					},
+				},
+			}}
+			requestBody, err := json.Marshal(admissionReviewWithObject(claim, resourceClaimResourceV1))
+			require.NoError(t, err)
+
+			res, err := http.Post(s.URL+"/validate-resource-claim-parameters", "application/json", bytes.NewReader(requestBody))
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, res.StatusCode)
+			responseBody, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			res.Body.Close()
+
+			responseAdmissionReview, err := readAdmissionReview(responseBody)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedAllowed, responseAdmissionReview.Response.Allowed)
+			if !test.expectedAllowed {
+				assert.Equal(t, test.expectedMessage, responseAdmissionReview.Response.Result.Message)
+			}
+		})
+	}
+}
+
+func TestNetConfigValidatingWebhook(t *testing.T) {
+	const netDriverName = "net.example.com"
+
+	tests := map[string]struct {
+		netConfig       *netconfigapi.NetConfig
+		expectedAllowed bool
+		expectedMessage string
+	}{
+		"valid NetConfig": {
+			netConfig: &netconfigapi.NetConfig{
+				BandwidthBurst: &netconfigapi.BandwidthBurstEntry{},
+				QoS: &netconfigapi.QoSConfig{
+					TrafficClass: netconfigapi.VoiceTrafficClass,
+					DSCP:         ptr.To(int32(46)),
+				},
+			},
+			expectedAllowed: true,
+		},
+		"invalid NetConfig": {
+			netConfig: &netconfigapi.NetConfig{
+				BandwidthBurst: &netconfigapi.BandwidthBurstEntry{},
+				QoS: &netconfigapi.QoSConfig{
+					TrafficClass: netconfigapi.BestEffortTrafficClass,
+					DSCP:         ptr.To(int32(64)),
+				},
+			},
+			expectedMessage: "1 configs failed to validate: object at spec.devices.config[0].opaque.parameters is invalid: dscp must be between 0 and 63: 64",
+		},
+	}
+
+	registration, err := registry.New().Get(net.ProfileName)
+	require.NoError(t, err)
+	mux, err := newMux(map[string]profiles.ConfigHandler{netDriverName: registration.ConfigHandler})
+	require.NoError(t, err)
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.netConfig.SetGroupVersionKind(netconfigapi.SchemeGroupVersion.WithKind(netconfigapi.NetConfigKind))
+			claim := &resourceapi.ResourceClaim{}
+			claim.SetGroupVersionKind(resourceapi.SchemeGroupVersion.WithKind("ResourceClaim"))
+			claim.Spec.Devices.Config = []resourceapi.DeviceClaimConfiguration{{
+				DeviceConfiguration: resourceapi.DeviceConfiguration{
+					Opaque: &resourceapi.OpaqueDeviceConfiguration{
+						Driver:     netDriverName,
+						Parameters: runtime.RawExtension{Object: test.netConfig},
+					},
+				},
+			}}
+			requestBody, err := json.Marshal(admissionReviewWithObject(claim, resourceClaimResourceV1))
+			require.NoError(t, err)
+
+			res, err := http.Post(s.URL+"/validate-resource-claim-parameters", "application/json", bytes.NewReader(requestBody))
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, res.StatusCode)
+			responseBody, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			res.Body.Close()
+
+			responseAdmissionReview, err := readAdmissionReview(responseBody)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedAllowed, responseAdmissionReview.Response.Allowed)
+			if !test.expectedAllowed {
+				assert.Equal(t, test.expectedMessage, responseAdmissionReview.Response.Result.Message)
+			}
+		})
+	}
+}
+
+func TestNewMux_AllProfiles(t *testing.T) {
+	registry := registry.New()
+	for _, profileName := range registry.Names() {
+		t.Run(profileName, func(t *testing.T) {
+			registration, err := registry.Get(profileName)
+			require.NoError(t, err)
+			_, err = newMux(map[string]profiles.ConfigHandler{profileName + ".example.com": registration.ConfigHandler})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestNewConfigHandlers(t *testing.T) {
+	tests := map[string]struct {
+		profile             string
+		driverName          string
+		expectedDriverNames []string
+		expectedError       string
+	}{
+		"single profile": {
+			profile:             "gpu",
+			expectedDriverNames: []string{"gpu.example.com"},
+		},
+		"single profile with driver name": {
+			profile:             "gpu",
+			driverName:          "custom.example.com",
+			expectedDriverNames: []string{"custom.example.com"},
+		},
+		"several profiles": {
+			profile:             "gpu,cpu,net",
+			expectedDriverNames: []string{"cpu.example.com", "gpu.example.com", "net.example.com"},
+		},
+		"several profiles with driver name": {
+			profile:       "gpu,cpu",
+			driverName:    "custom.example.com",
+			expectedError: `a driver name cannot be set when validating several device profiles ["gpu" "cpu"]`,
+		},
+		"duplicate profile": {
+			profile:       "gpu,gpu",
+			expectedError: `duplicate device profile "gpu"`,
+		},
+		"unknown profile": {
+			profile:       "gpu,unknown",
+			expectedError: `invalid device profile "unknown"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			flags := &Flags{profile: test.profile, driverName: test.driverName}
+			configHandlers, err := newConfigHandlers(flags, registry.New())
+			if test.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.ElementsMatch(t, test.expectedDriverNames, slices.Collect(maps.Keys(configHandlers)))
+		})
+	}
+}
+
+func TestMultiProfileValidatingWebhook(t *testing.T) {
+	const cpuDriverName = "cpu.example.com"
+
+	configHandlers, err := newConfigHandlers(&Flags{profile: "gpu,cpu"}, registry.New())
+	require.NoError(t, err)
+	mux, err := newMux(configHandlers)
+	require.NoError(t, err)
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	invalidCPUConfig := &cpuconfigapi.CpuConfig{
+		SMT: cpuconfigapi.FullCoresSMTPolicy,
+	}
+	invalidCPUConfig.SetGroupVersionKind(cpuconfigapi.SchemeGroupVersion.WithKind(cpuconfigapi.CpuConfigKind))
+	claim := resourceClaimWithGpuConfigs(&configapi.GpuConfig{
+		Sharing: &configapi.GpuSharing{
+			Strategy: configapi.TimeSlicingStrategy,
+			TimeSlicingConfig: &configapi.TimeSlicingConfig{
+				Interval: configapi.DefaultTimeSlice,
+			},
+		},
+	})
+	claim.Spec.Devices.Config = append(claim.Spec.Devices.Config, resourceapi.DeviceClaimConfiguration{
+		DeviceConfiguration: resourceapi.DeviceConfiguration{
+			Opaque: &resourceapi.OpaqueDeviceConfiguration{
+				Driver:     cpuDriverName,
+				Parameters: runtime.RawExtension{Object: invalidCPUConfig},
+			},
+		},
+	})
+	requestBody, err := json.Marshal(admissionReviewWithObject(claim, resourceClaimResourceV1))
+	require.NoError(t, err)
+
+	res, err := http.Post(s.URL+"/validate-resource-claim-parameters", "application/json", bytes.NewReader(requestBody))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	responseBody, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	responseAdmissionReview, err := readAdmissionReview(responseBody)
+	require.NoError(t, err)
+	assert.False(t, responseAdmissionReview.Response.Allowed)
+	assert.Equal(t, "1 configs failed to validate: object at spec.devices.config[1].opaque.parameters is invalid: SMT policy FullCores requires the Exclusive pinning policy", responseAdmissionReview.Response.Result.Message)
+}
+
 func admissionReviewWithObject(obj runtime.Object, resource metav1.GroupVersionResource) *admissionv1.AdmissionReview {
 	requestedAdmissionReview := &admissionv1.AdmissionReview{
 		Request: &admissionv1.AdmissionRequest{